@@ -0,0 +1,143 @@
+//go:build grpc
+
+package main
+
+import (
+	"context"
+	"github.com/yael-castro/goarch/internal/container"
+	"github.com/yael-castro/goarch/internal/runtime"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"google.golang.org/grpc"
+	"log/slog"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+func main() {
+	// Setting exit code
+	exitCode := 0
+	defer func() {
+		os.Exit(exitCode)
+	}()
+
+	// Building main context
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, os.Kill, syscall.SIGTERM)
+	defer stop()
+
+	// Building DI container
+	c := container.New()
+
+	// Injecting logger
+	var logger *slog.Logger
+
+	if err := c.Inject(ctx, &logger); err != nil {
+		exitCode = 1
+		return
+	}
+
+	slog.SetDefault(logger) // Setting default logger
+
+	// Injecting tracer provider
+	var tracerProvider *sdktrace.TracerProvider
+
+	if err := c.Inject(ctx, &tracerProvider); err != nil {
+		exitCode = 1
+		slog.Error("failed_tracer_provider_built", "error", err)
+		return
+	}
+
+	otel.SetTracerProvider(tracerProvider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	// Injecting dependencies
+	var s *grpc.Server
+
+	if err := c.Inject(ctx, &s); err != nil {
+		exitCode = 1
+		slog.ErrorContext(ctx, "failed_server_built", "error", err)
+		return
+	}
+
+	// Getting grpc port
+	port := os.Getenv("PORT")
+	if len(port) == 0 {
+		const defaultPort = "50051"
+		port = defaultPort
+	}
+
+	listener, err := net.Listen("tcp", ":"+port)
+	if err != nil {
+		exitCode = 1
+		slog.ErrorContext(ctx, "failed_listener_built", "error", err)
+		return
+	}
+
+	// Listening for shutdown gracefully
+	shutdownCh := make(chan struct{}, 1)
+
+	go func() {
+		defer close(shutdownCh)
+
+		<-ctx.Done()
+		shutdown(c, s)
+
+		shutdownCh <- struct{}{}
+	}()
+
+	// Running grpc server
+	errCh := make(chan error, 1)
+
+	go func() {
+		defer close(errCh)
+
+		slog.InfoContext(ctx, "running", "version", runtime.GitCommit, "port", port)
+		errCh <- s.Serve(listener)
+	}()
+
+	// Waiting for cancellation or error
+	select {
+	case <-ctx.Done():
+	case err = <-errCh:
+		exitCode = 1
+	}
+
+	stop()
+	<-shutdownCh
+
+	slog.Error("exit", "code", exitCode, "error", err)
+}
+
+func shutdown(c container.Container, s *grpc.Server) {
+	const gracePeriod = 10 * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), gracePeriod)
+	defer cancel()
+
+	// Closing grpc server, the in-flight calls are canceled once the grace period is over
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+		s.GracefulStop()
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		slog.ErrorContext(ctx, "failed_server_shutdown", "error", ctx.Err())
+		s.Stop()
+	}
+
+	// Closing DI container
+	err := c.Close(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed_container_shutdown", "error", err)
+		return
+	}
+
+	slog.InfoContext(ctx, "success_shutdown")
+}
@@ -4,10 +4,15 @@ package main
 
 import (
 	"context"
+	"errors"
 	"github.com/labstack/echo/v4"
 	"github.com/yael-castro/goarch/internal/container"
 	"github.com/yael-castro/goarch/internal/runtime"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
@@ -38,6 +43,18 @@ func main() {
 
 	slog.SetDefault(logger) // Setting default logger
 
+	// Injecting tracer provider
+	var tracerProvider *sdktrace.TracerProvider
+
+	if err := c.Inject(ctx, &tracerProvider); err != nil {
+		exitCode = 1
+		slog.Error("failed_tracer_provider_built", "error", err)
+		return
+	}
+
+	otel.SetTracerProvider(tracerProvider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
 	// Injecting dependencies
 	var e *echo.Echo
 
@@ -46,6 +63,28 @@ func main() {
 		return
 	}
 
+	// Injecting admin server (optional)
+	var admin container.AdminServer
+
+	adminPort := os.Getenv("ADMIN_PORT")
+
+	if len(adminPort) > 0 {
+		if err := c.Inject(ctx, &admin); err != nil {
+			exitCode = 1
+			slog.ErrorContext(ctx, "failed_admin_server_built", "error", err)
+			return
+		}
+
+		go func() {
+			slog.InfoContext(ctx, "running_admin_server", "port", adminPort)
+
+			err := admin.Start(":" + adminPort)
+			if !errors.Is(err, http.ErrServerClosed) {
+				slog.Error("failed_admin_server", "error", err)
+			}
+		}()
+	}
+
 	// Getting http port
 	port := os.Getenv("PORT")
 	if len(port) == 0 {
@@ -60,7 +99,7 @@ func main() {
 		defer close(shutdownCh)
 
 		<-ctx.Done()
-		shutdown(c, e)
+		shutdown(c, e, admin.Echo)
 
 		shutdownCh <- struct{}{}
 	}()
@@ -90,11 +129,19 @@ func main() {
 	slog.Error("exit", "code", exitCode, "error", err)
 }
 
-func shutdown(c container.Container, e *echo.Echo) {
+func shutdown(c container.Container, e *echo.Echo, admin *echo.Echo) {
 	const gracePeriod = 10 * time.Second
 	ctx, cancel := context.WithTimeout(context.Background(), gracePeriod)
 	defer cancel()
 
+	// Closing admin server
+	if admin != nil {
+		err := admin.Shutdown(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed_admin_server_shutdown", "error", err)
+		}
+	}
+
 	// Closing http server
 	err := e.Shutdown(ctx)
 	if err != nil {
@@ -0,0 +1,88 @@
+//go:build keys
+
+package main
+
+import (
+	"context"
+	"github.com/yael-castro/goarch/internal/container"
+	"github.com/yael-castro/goarch/internal/runtime"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+func main() {
+	// Setting exit code
+	exitCode := 0
+	defer func() {
+		os.Exit(exitCode)
+	}()
+
+	// Building main context
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, os.Kill, syscall.SIGTERM)
+	defer stop()
+
+	// Building DI container
+	c := container.New()
+
+	// Injecting logger
+	var logger *slog.Logger
+
+	if err := c.Inject(ctx, &logger); err != nil {
+		exitCode = 1
+		return
+	}
+
+	slog.SetDefault(logger) // Setting default logger
+
+	// Injecting tracer provider
+	var tracerProvider *sdktrace.TracerProvider
+
+	if err := c.Inject(ctx, &tracerProvider); err != nil {
+		exitCode = 1
+		slog.Error("failed_tracer_provider_built", "error", err)
+		return
+	}
+
+	otel.SetTracerProvider(tracerProvider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	// Injecting command
+	var cmd func(context.Context, ...string) int
+
+	if err := c.Inject(ctx, &cmd); err != nil {
+		exitCode = 1
+		slog.Error("failed_command_built", "error", err)
+		return
+	}
+
+	// Re-wrapping the data keys, the rotation is resumed by running the command again if it is interrupted
+	slog.InfoContext(ctx, "running", "version", runtime.GitCommit)
+	exitCode = cmd(ctx, os.Args[1:]...)
+
+	stop()
+	shutdown(c)
+
+	slog.Info("exit", "code", exitCode)
+}
+
+func shutdown(c container.Container) {
+	const gracePeriod = 10 * time.Second
+
+	ctx, cancel := context.WithTimeout(context.Background(), gracePeriod)
+	defer cancel()
+
+	// Closing DI container
+	err := c.Close(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed_container_shutdown", "error", err)
+		return
+	}
+
+	slog.InfoContext(ctx, "success_shutdown")
+}
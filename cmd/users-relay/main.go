@@ -1,10 +1,18 @@
+//go:build relay
+
 package main
 
 import (
 	"context"
+	"errors"
+	"github.com/labstack/echo/v4"
 	"github.com/yael-castro/goarch/internal/container"
 	"github.com/yael-castro/goarch/internal/runtime"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
@@ -35,6 +43,18 @@ func main() {
 
 	slog.SetDefault(logger) // Setting a default logger
 
+	// Injecting tracer provider
+	var tracerProvider *sdktrace.TracerProvider
+
+	if err := c.Inject(ctx, &tracerProvider); err != nil {
+		exitCode = 1
+		slog.Error("failed_tracer_provider_built", "error", err)
+		return
+	}
+
+	otel.SetTracerProvider(tracerProvider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
 	// Injecting command
 	var cmd func(context.Context, ...string) int
 
@@ -44,25 +64,27 @@ func main() {
 		return
 	}
 
-	// Listening for shutdown gracefully
-	shutdownCh := make(chan struct{}, 1)
+	// Injecting admin server (optional)
+	var e *echo.Echo
 
-	go func() {
-		// Waiting for close gracefully
-		<-ctx.Done()
-
-		// Shutting down
-		const gracePeriod = 10 * time.Second
+	adminPort := os.Getenv("ADMIN_PORT")
 
-		ctx, cancel := context.WithTimeout(context.Background(), gracePeriod)
-		defer cancel()
+	if len(adminPort) > 0 {
+		if err := c.Inject(ctx, &e); err != nil {
+			exitCode = 1
+			slog.Error("failed_server_built", "error", err)
+			return
+		}
 
-		_ = c.Close(ctx)
+		go func() {
+			slog.InfoContext(ctx, "running_admin_server", "port", adminPort)
 
-		// Confirm shutdown gracefully
-		shutdownCh <- struct{}{}
-		close(shutdownCh)
-	}()
+			err := e.Start(":" + adminPort)
+			if !errors.Is(err, http.ErrServerClosed) {
+				slog.Error("failed_admin_server", "error", err)
+			}
+		}()
+	}
 
 	// Executing message relay
 	exitCodeCh := make(chan int, 1)
@@ -77,11 +99,52 @@ func main() {
 	// Waiting for cancellation or exit code
 	select {
 	case <-ctx.Done():
+		exitCode = wait(exitCodeCh)
 	case exitCode = <-exitCodeCh:
 	}
 
 	stop()
-	<-shutdownCh
+	shutdown(c, e)
+
+	slog.Info("exit", "code", exitCode)
+}
+
+// wait gives the message relay time to finish the in-flight batch after the stop signal
+func wait(exitCodeCh <-chan int) int {
+	const gracePeriod = 10 * time.Second
+
+	slog.Info("waiting_in_flight_batch", "grace_period", gracePeriod)
+
+	select {
+	case exitCode := <-exitCodeCh:
+		return exitCode
+	case <-time.After(gracePeriod):
+		slog.Error("in_flight_batch_timeout", "grace_period", gracePeriod)
+		return 1
+	}
+}
+
+// shutdown closes the DI container once the message relay is stopped, so the Kafka producer is flushed before the DB is closed
+func shutdown(c container.Container, e *echo.Echo) {
+	const gracePeriod = 10 * time.Second
+
+	ctx, cancel := context.WithTimeout(context.Background(), gracePeriod)
+	defer cancel()
+
+	// Closing admin server
+	if e != nil {
+		err := e.Shutdown(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed_server_shutdown", "error", err)
+		}
+	}
+
+	// Closing DI container
+	err := c.Close(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed_container_shutdown", "error", err)
+		return
+	}
 
-	slog.InfoContext(ctx, "exit", "code", exitCode)
+	slog.InfoContext(ctx, "success_shutdown")
 }
@@ -0,0 +1,9 @@
+// Package docs embeds the documentation that is served or validated at runtime
+package docs
+
+import _ "embed"
+
+// OpenAPI is the specification of the users HTTP API
+//
+//go:embed OpenAPI.json
+var OpenAPI []byte
@@ -0,0 +1,77 @@
+package business
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+func NewAPIKeyCases(store APIKeyStore, policy UserPolicy) (APIKeyCases, error) {
+	if store == nil || policy == nil {
+		return nil, errors.New("some dependencies are nil")
+	}
+
+	return apiKeyCases{
+		store:  store,
+		policy: policy,
+	}, nil
+}
+
+type apiKeyCases struct {
+	store  APIKeyStore
+	policy UserPolicy
+}
+
+func (a apiKeyCases) IssueAPIKey(ctx context.Context, key *APIKey) (secret APIKeySecret, err error) {
+	if err = a.policy.AuthorizeUser(ctx, ActionIssueAPIKey, 0); err != nil {
+		return
+	}
+
+	if err = key.Validate(); err != nil {
+		return
+	}
+
+	secret, err = NewAPIKeySecret()
+	if err != nil {
+		return
+	}
+
+	key.Hash = secret.Hash()
+	key.Prefix = secret.Prefix()
+
+	if err = a.store.CreateAPIKey(ctx, key); err != nil {
+		return "", err
+	}
+
+	return secret, nil
+}
+
+func (a apiKeyCases) RevokeAPIKey(ctx context.Context, id APIKeyID) (err error) {
+	if err = a.policy.AuthorizeUser(ctx, ActionRevokeAPIKey, 0); err != nil {
+		return
+	}
+
+	if err = id.Validate(); err != nil {
+		return
+	}
+
+	return a.store.RevokeAPIKey(ctx, id)
+}
+
+func (a apiKeyCases) AuthenticateAPIKey(ctx context.Context, secret APIKeySecret) (_ Principal, err error) {
+	if len(secret) == 0 {
+		return Principal{}, ErrInvalidAPIKey
+	}
+
+	key, err := a.store.UseAPIKey(ctx, secret.Hash())
+	if errors.Is(err, ErrAPIKeyNotFound) {
+		// Unknown and revoked keys are not distinguished
+		return Principal{}, fmt.Errorf("%w: unknown or revoked API key", ErrInvalidAPIKey)
+	}
+
+	if err != nil {
+		return Principal{}, err
+	}
+
+	return key.Principal(), nil
+}
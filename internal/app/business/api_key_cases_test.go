@@ -0,0 +1,133 @@
+package business_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/yael-castro/goarch/internal/app/business"
+	"github.com/yael-castro/goarch/internal/app/business/mock"
+	"log/slog"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestAPIKeyCases_IssueAPIKey(t *testing.T) {
+	admin := business.ContextWithPrincipal(context.Background(), business.Principal{
+		Subject: "1",
+		Scopes:  []business.Scope{business.ScopeUsersAdmin},
+	})
+
+	cases := [...]struct {
+		ctx         context.Context
+		key         *business.APIKey
+		expectedErr error
+	}{
+		// Test case: only admins issue API keys
+		{
+			ctx: business.ContextWithPrincipal(context.Background(), business.Principal{
+				Subject: "1",
+				Scopes:  []business.Scope{business.ScopeUsersWrite},
+			}),
+			key:         &business.APIKey{Name: "billing", Scopes: []business.Scope{business.ScopeUsersRead}},
+			expectedErr: business.ErrAccessDenied,
+		},
+		// Test case: missing name
+		{
+			ctx:         admin,
+			key:         &business.APIKey{Scopes: []business.Scope{business.ScopeUsersRead}},
+			expectedErr: business.ErrInvalidAPIKeyName,
+		},
+		// Test case: unsupported scope
+		{
+			ctx:         admin,
+			key:         &business.APIKey{Name: "billing", Scopes: []business.Scope{"orders:read"}},
+			expectedErr: business.ErrInvalidScope,
+		},
+		// Test case: success
+		{
+			ctx: admin,
+			key: &business.APIKey{Name: "billing", Scopes: []business.Scope{business.ScopeUsersRead}},
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			var keys []business.APIKey
+
+			logic, err := business.NewAPIKeyCases(mock.APIKeyStore{Keys: &keys}, business.NewScopePolicy())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			secret, err := logic.IssueAPIKey(c.ctx, c.key)
+			if !errors.Is(err, c.expectedErr) {
+				t.Fatalf("expected error '%v' got '%v'", c.expectedErr, err)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if len(keys) != 1 {
+				t.Fatalf("expected 1 stored key got %d", len(keys))
+			}
+
+			// The secret itself is not stored
+			if !bytes.Equal(keys[0].Hash, secret.Hash()) || strings.Contains(fmt.Sprintf("%+v", keys[0]), secret.Reveal()) {
+				t.Fatal("expected only the hash of the secret to be stored")
+			}
+
+			if !strings.HasPrefix(secret.Reveal(), keys[0].Prefix) {
+				t.Fatalf("expected prefix '%s' of the secret", keys[0].Prefix)
+			}
+
+			principal, err := logic.AuthenticateAPIKey(context.Background(), secret)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(principal.Scopes, c.key.Scopes) {
+				t.Fatalf("expected scopes '%v' got '%v'", c.key.Scopes, principal.Scopes)
+			}
+
+			if keys[0].LastUsedAt.IsZero() {
+				t.Fatal("expected the use of the key to be recorded")
+			}
+
+			// Revoked keys are invalid
+			if err = logic.RevokeAPIKey(c.ctx, c.key.ID); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err = logic.AuthenticateAPIKey(context.Background(), secret); !errors.Is(err, business.ErrInvalidAPIKey) {
+				t.Fatalf("expected error '%v' got '%v'", business.ErrInvalidAPIKey, err)
+			}
+		})
+	}
+}
+
+func TestAPIKeySecret_String(t *testing.T) {
+	secret, err := business.NewAPIKeySecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var logs bytes.Buffer
+
+	slog.New(slog.NewJSONHandler(&logs, nil)).Info("api_key", "secret", secret)
+
+	printed := [...]string{
+		fmt.Sprint(secret),
+		fmt.Sprintf("%s %v %+v %#v %q", secret, secret, secret, secret, secret),
+		logs.String(),
+	}
+
+	for i, s := range printed {
+		if strings.Contains(s, secret.Reveal()) {
+			t.Errorf("%d: secret is printed in '%s'", i, s)
+		}
+	}
+}
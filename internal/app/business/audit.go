@@ -0,0 +1,105 @@
+package business
+
+import (
+	"context"
+	"time"
+)
+
+// anonymousActor is the actor of the mutations without Principal
+const anonymousActor = "anonymous"
+
+type AuditEntryID uint64
+
+// AuditEntry records who mutated a user, when and how
+type AuditEntry struct {
+	ID     AuditEntryID
+	UserID UserID
+	// Actor is the Subject of the Principal that performed the Action
+	Actor     string
+	RequestID string
+	Action    Action
+	Changes   []FieldChange
+	Time      time.Time
+}
+
+// FieldChange is the value of a field before and after a mutation, the values are empty if the field is absent
+type FieldChange struct {
+	Field  string
+	Before string
+	After  string
+}
+
+// NewAuditEntry records the Action on the user from before to after, the actor and the request ID are taken from ctx
+func NewAuditEntry(ctx context.Context, action Action, before, after User) AuditEntry {
+	entry := AuditEntry{
+		UserID:    max(before.ID, after.ID),
+		Actor:     anonymousActor,
+		RequestID: RequestIDFromContext(ctx),
+		Action:    action,
+		Changes:   DiffUsers(before, after),
+		Time:      time.Now(),
+	}
+
+	if principal, ok := PrincipalFromContext(ctx); ok {
+		entry.Actor = principal.Subject
+	}
+
+	return entry
+}
+
+// DiffUsers returns the fields that differ from before to after
+func DiffUsers(before, after User) []FieldChange {
+	fields := [...]FieldChange{
+		{Field: "name", Before: before.Name.String(), After: after.Name.String()},
+		{Field: "email", Before: before.Email.String(), After: after.Email.String()},
+		{Field: "birth_date", Before: before.BirthDate.String(), After: after.BirthDate.String()},
+		{Field: "email_verified_at", Before: formatTime(before.EmailVerifiedAt), After: formatTime(after.EmailVerifiedAt)},
+	}
+
+	changes := make([]FieldChange, 0, len(fields))
+
+	for _, field := range fields {
+		if field.Before != field.After {
+			changes = append(changes, field)
+		}
+	}
+
+	return changes
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.UTC().Format(time.RFC3339)
+}
+
+// HistoryPage selects the AuditEntry(s) of a user whose ID is greater than AfterID (keyset pagination)
+type HistoryPage struct {
+	UserID  UserID
+	AfterID AuditEntryID
+	Size    PageSize
+}
+
+func (h HistoryPage) Validate() error {
+	var violations ValidationErrors
+
+	violations.Add("id", h.UserID.Validate())
+	violations.Add("page_size", h.Size.Validate())
+
+	return violations.Err()
+}
+
+type requestIDKey struct{}
+
+// ContextWithRequestID returns a copy of ctx that carries the ID of the request that started the use case
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFromContext returns the request ID carried by ctx or an empty string
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
@@ -0,0 +1,80 @@
+package business_test
+
+import (
+	"context"
+	"github.com/yael-castro/goarch/internal/app/business"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestNewAuditEntry(t *testing.T) {
+	ctx := business.ContextWithPrincipal(context.Background(), business.Principal{Subject: "7"})
+	ctx = business.ContextWithRequestID(ctx, "req-1")
+
+	user := business.User{ID: 1, Name: "Yael", Email: "contacto@yael.mx", BirthDate: "2001-07-14"}
+
+	cases := [...]struct {
+		ctx             context.Context
+		action          business.Action
+		before          business.User
+		after           business.User
+		expectedActor   string
+		expectedChanges []business.FieldChange
+	}{
+		// Test case: creation records every field
+		{
+			ctx:           ctx,
+			action:        business.ActionCreateUser,
+			after:         user,
+			expectedActor: "7",
+			expectedChanges: []business.FieldChange{
+				{Field: "name", After: "Yael"},
+				{Field: "email", After: "contacto@yael.mx"},
+				{Field: "birth_date", After: "2001-07-14"},
+			},
+		},
+		// Test case: update records only the changed fields
+		{
+			ctx:           ctx,
+			action:        business.ActionUpdateUser,
+			before:        user,
+			after:         business.User{ID: 1, Name: "Yael", Email: "yael@example.com", BirthDate: "2001-07-14"},
+			expectedActor: "7",
+			expectedChanges: []business.FieldChange{
+				{Field: "email", Before: "contacto@yael.mx", After: "yael@example.com"},
+			},
+		},
+		// Test case: deletion without principal
+		{
+			ctx:           context.Background(),
+			action:        business.ActionDeleteUser,
+			before:        user,
+			after:         business.User{ID: 1},
+			expectedActor: "anonymous",
+			expectedChanges: []business.FieldChange{
+				{Field: "name", Before: "Yael"},
+				{Field: "email", Before: "contacto@yael.mx"},
+				{Field: "birth_date", Before: "2001-07-14"},
+			},
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			entry := business.NewAuditEntry(c.ctx, c.action, c.before, c.after)
+
+			if entry.UserID != user.ID || entry.Action != c.action || entry.Actor != c.expectedActor {
+				t.Fatalf("unexpected entry '%+v'", entry)
+			}
+
+			if entry.RequestID != business.RequestIDFromContext(c.ctx) {
+				t.Fatalf("expected request id '%s' got '%s'", business.RequestIDFromContext(c.ctx), entry.RequestID)
+			}
+
+			if !reflect.DeepEqual(entry.Changes, c.expectedChanges) {
+				t.Fatalf("expected changes '%+v' got '%+v'", c.expectedChanges, entry.Changes)
+			}
+		})
+	}
+}
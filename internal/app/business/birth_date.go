@@ -0,0 +1,113 @@
+package business
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// maxBirthDateYears is how many years ago a BirthDate can be
+const maxBirthDateYears = 150
+
+// BirthDate is the date of birth of a User in ISO 8601 format (YYYY-MM-DD).
+// The age is not stored, it is computed from the BirthDate when the User is read (see BirthDate.Age)
+type BirthDate string
+
+// NewBirthDate returns the BirthDate of the date of t
+func NewBirthDate(t time.Time) BirthDate {
+	if t.IsZero() {
+		return ""
+	}
+
+	return BirthDate(t.Format(time.DateOnly))
+}
+
+// ApproximateBirthDate approximates the BirthDate of a User of the given age at the date of at, like the migration of
+// the stored ages (2_birth_date.sql) it is the middle of the year in which the User was born. It is empty if age is 0
+func ApproximateBirthDate(age Age, at time.Time) BirthDate {
+	if age == 0 {
+		return ""
+	}
+
+	return NewBirthDate(at.AddDate(-int(age), -6, 0))
+}
+
+func (b BirthDate) Validate() error {
+	date, err := b.Time()
+	if err != nil {
+		return err
+	}
+
+	// The dates are compared without the time of day
+	today, _ := NewBirthDate(time.Now()).Time()
+
+	if date.After(today) {
+		return fmt.Errorf("%w: %s is in the future", ErrInvalidUserBirthDate, b)
+	}
+
+	if date.Before(today.AddDate(-maxBirthDateYears, 0, 0)) {
+		return fmt.Errorf("%w: %s is more than %d years ago", ErrInvalidUserBirthDate, b, maxBirthDateYears)
+	}
+
+	return nil
+}
+
+// Time returns the midnight (UTC) of the BirthDate
+func (b BirthDate) Time() (time.Time, error) {
+	if len(b) == 0 {
+		return time.Time{}, fmt.Errorf("%w: birth date is required", ErrInvalidUserBirthDate)
+	}
+
+	date, err := time.Parse(time.DateOnly, string(b))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: '%s' is not a date (YYYY-MM-DD)", ErrInvalidUserBirthDate, b)
+	}
+
+	return date, nil
+}
+
+// Age returns the completed years from the BirthDate to the date of at (at most 255), it is 0 if the BirthDate is not
+// valid
+func (b BirthDate) Age(at time.Time) Age {
+	date, err := b.Time()
+	if err != nil {
+		return 0
+	}
+
+	years := at.Year() - date.Year()
+
+	// The birthday of this year has not come yet (the people born on February 29 are a year older on March 1)
+	if at.Month() < date.Month() || (at.Month() == date.Month() && at.Day() < date.Day()) {
+		years--
+	}
+
+	return Age(max(0, min(years, math.MaxUint8)))
+}
+
+func (b BirthDate) String() string {
+	return string(b)
+}
+
+// Age is the completed years of a User, it is always computed from its BirthDate
+type Age uint8
+
+func (a Age) String() string {
+	return strconv.Itoa(int(a))
+}
+
+// BirthDateRules restricts the birth dates of the users. The zero value allows every valid BirthDate
+type BirthDateRules struct {
+	// MinAge is the minimum age of the users on the day they are created or updated
+	MinAge Age
+}
+
+// Validate checks that the age of a valid BirthDate is at least the MinAge
+func (r BirthDateRules) Validate(b BirthDate) error {
+	if age := b.Age(time.Now()); age < r.MinAge {
+		err := ErrInvalidUserAge.With("min", r.MinAge.String())
+		return fmt.Errorf("%w: user must be at least %d years old", err, r.MinAge)
+	}
+
+	return nil
+}
@@ -0,0 +1,184 @@
+package business_test
+
+import (
+	"errors"
+	"github.com/yael-castro/goarch/internal/app/business"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestBirthDate_Validate(t *testing.T) {
+	cases := [...]struct {
+		birthDate   business.BirthDate
+		expectedErr error
+	}{
+		// Test case: missing birth date
+		{
+			expectedErr: business.ErrInvalidUserBirthDate,
+		},
+		// Test case: not a date
+		{
+			birthDate:   "14/07/2001",
+			expectedErr: business.ErrInvalidUserBirthDate,
+		},
+		// Test case: birth date in the future
+		{
+			birthDate:   business.NewBirthDate(time.Now().AddDate(0, 0, 2)),
+			expectedErr: business.ErrInvalidUserBirthDate,
+		},
+		// Test case: born today
+		{
+			birthDate: business.NewBirthDate(time.Now()),
+		},
+		// Test case: birth date more than 150 years ago
+		{
+			birthDate:   business.NewBirthDate(time.Now().AddDate(-150, 0, -2)),
+			expectedErr: business.ErrInvalidUserBirthDate,
+		},
+		// Test case: year zero
+		{
+			birthDate:   "0000-01-01",
+			expectedErr: business.ErrInvalidUserBirthDate,
+		},
+		// Test case: born 150 years ago
+		{
+			birthDate: business.NewBirthDate(time.Now().AddDate(-150, 0, 0)),
+		},
+		// Test case: valid birth date
+		{
+			birthDate: "2001-07-14",
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			err := c.birthDate.Validate()
+			if !errors.Is(err, c.expectedErr) {
+				t.Fatalf("expected error '%v' got '%v'", c.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestBirthDate_Age(t *testing.T) {
+	cases := [...]struct {
+		birthDate   business.BirthDate
+		at          time.Time
+		expectedAge business.Age
+	}{
+		// Test case: the day before the birthday
+		{
+			birthDate:   "2001-07-14",
+			at:          time.Date(2024, time.July, 13, 23, 59, 0, 0, time.UTC),
+			expectedAge: 22,
+		},
+		// Test case: the birthday
+		{
+			birthDate:   "2001-07-14",
+			at:          time.Date(2024, time.July, 14, 0, 0, 0, 0, time.UTC),
+			expectedAge: 23,
+		},
+		// Test case: born on February 29 in a year without February 29
+		{
+			birthDate:   "2000-02-29",
+			at:          time.Date(2023, time.February, 28, 12, 0, 0, 0, time.UTC),
+			expectedAge: 22,
+		},
+		// Test case: birth date after the date
+		{
+			birthDate: "2001-07-14",
+			at:        time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC),
+		},
+		// Test case: invalid birth date
+		{
+			birthDate: "2001-13-01",
+			at:        time.Now(),
+		},
+		// Test case: the age is clamped to its range
+		{
+			birthDate:   "1700-01-01",
+			at:          time.Date(2024, time.July, 14, 0, 0, 0, 0, time.UTC),
+			expectedAge: 255,
+		},
+		// Test case: year zero
+		{
+			birthDate:   "0000-01-01",
+			at:          time.Date(2024, time.July, 14, 0, 0, 0, 0, time.UTC),
+			expectedAge: 255,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			age := c.birthDate.Age(c.at)
+			if age != c.expectedAge {
+				t.Fatalf("expected age %d got %d", c.expectedAge, age)
+			}
+		})
+	}
+}
+
+func TestApproximateBirthDate(t *testing.T) {
+	at := time.Date(2024, time.July, 14, 10, 0, 0, 0, time.UTC)
+
+	cases := [...]struct {
+		age               business.Age
+		expectedBirthDate business.BirthDate
+	}{
+		// Test case: without age there is no birth date
+		{},
+		// Test case: the middle of the year in which the user was born
+		{
+			age:               23,
+			expectedBirthDate: "2001-01-14",
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			birthDate := business.ApproximateBirthDate(c.age, at)
+			if birthDate != c.expectedBirthDate {
+				t.Fatalf("expected birth date '%s' got '%s'", c.expectedBirthDate, birthDate)
+			}
+
+			// The computed age is the given age
+			if c.age > 0 && birthDate.Age(at) != c.age {
+				t.Fatalf("expected age %d got %d", c.age, birthDate.Age(at))
+			}
+		})
+	}
+}
+
+func TestBirthDateRules_Validate(t *testing.T) {
+	cases := [...]struct {
+		rules       business.BirthDateRules
+		birthDate   business.BirthDate
+		expectedErr error
+	}{
+		// Test case: every age is allowed by default
+		{
+			birthDate: business.NewBirthDate(time.Now()),
+		},
+		// Test case: the user turns the minimum age today
+		{
+			rules:     business.BirthDateRules{MinAge: 18},
+			birthDate: business.NewBirthDate(time.Now().AddDate(-18, 0, 0)),
+		},
+		// Test case: the user turns the minimum age tomorrow
+		{
+			rules:       business.BirthDateRules{MinAge: 18},
+			birthDate:   business.NewBirthDate(time.Now().AddDate(-18, 0, 1)),
+			expectedErr: business.ErrInvalidUserAge,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			err := c.rules.Validate(c.birthDate)
+			if !errors.Is(err, c.expectedErr) {
+				t.Fatalf("expected error '%v' got '%v'", c.expectedErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package business
+
+import (
+	"fmt"
+	"golang.org/x/net/idna"
+	"strings"
+)
+
+// EmailRules normalizes the emails and restricts their domains.
+// The zero value only trims the email and converts its domain to lowercase ASCII (punycode)
+type EmailRules struct {
+	// LowercaseLocalPart lowercases the stored local part, RFC 5321 considers it case-sensitive but most providers do not.
+	// It only changes how the emails are stored, the emails are unique regardless of their case anyway
+	LowercaseLocalPart bool
+	// StripSubaddress removes the subaddress of the local part (user+tag@example.com is user@example.com)
+	StripSubaddress bool
+	// AllowedDomains restricts the emails to these domains and their subdomains, every domain is allowed if it is empty
+	AllowedDomains []string
+	// DeniedDomains rejects the emails of these domains and their subdomains
+	DeniedDomains []string
+}
+
+// Normalize returns the canonical form of e, the emails that are not valid are only trimmed (see Email.Validate)
+func (r EmailRules) Normalize(e Email) Email {
+	e = Email(strings.TrimSpace(string(e)))
+
+	localPart, domain := e.split()
+	if len(domain) == 0 {
+		return e
+	}
+
+	domain, err := idna.Lookup.ToASCII(domain)
+	if err != nil {
+		return e
+	}
+
+	if r.StripSubaddress {
+		if plus := strings.IndexByte(localPart, '+'); plus > 0 {
+			localPart = localPart[:plus]
+		}
+	}
+
+	if r.LowercaseLocalPart {
+		localPart = strings.ToLower(localPart)
+	}
+
+	return Email(localPart + "@" + strings.ToLower(domain))
+}
+
+// Validate checks that the domain of a normalized email is allowed
+func (r EmailRules) Validate(e Email) error {
+	domain := e.Domain()
+	err := ErrUserEmailDomainNotAllowed.With("domain", domain)
+
+	if len(r.AllowedDomains) > 0 && !matchDomain(domain, r.AllowedDomains) {
+		return fmt.Errorf("%w: '%s' is not an allowed domain", err, domain)
+	}
+
+	if matchDomain(domain, r.DeniedDomains) {
+		return fmt.Errorf("%w: '%s' is a denied domain", err, domain)
+	}
+
+	return nil
+}
+
+// matchDomain checks if domain is one of domains or one of their subdomains
+func matchDomain(domain string, domains []string) bool {
+	for _, d := range domains {
+		d = strings.ToLower(d)
+
+		if domain == d || strings.HasSuffix(domain, "."+d) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,93 @@
+package business_test
+
+import (
+	"errors"
+	"github.com/yael-castro/goarch/internal/app/business"
+	"strconv"
+	"testing"
+)
+
+func TestEmailRules_Normalize(t *testing.T) {
+	cases := [...]struct {
+		rules         business.EmailRules
+		email         business.Email
+		expectedEmail business.Email
+	}{
+		// Test case: the domain is lowercased, the local part is case-sensitive by default
+		{
+			email:         "  Contacto@Yael.MX ",
+			expectedEmail: "Contacto@yael.mx",
+		},
+		// Test case: internationalized domain converted to punycode
+		{
+			email:         "contacto@México.mx",
+			expectedEmail: "contacto@xn--mxico-bsa.mx",
+		},
+		// Test case: lowercase local part and no subaddress
+		{
+			rules:         business.EmailRules{LowercaseLocalPart: true, StripSubaddress: true},
+			email:         "Contacto+News@yael.mx",
+			expectedEmail: "contacto@yael.mx",
+		},
+		// Test case: invalid emails are only trimmed
+		{
+			rules:         business.EmailRules{LowercaseLocalPart: true},
+			email:         " ABC ",
+			expectedEmail: "ABC",
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			email := c.rules.Normalize(c.email)
+			if email != c.expectedEmail {
+				t.Fatalf("expected email '%s' got '%s'", c.expectedEmail, email)
+			}
+		})
+	}
+}
+
+func TestEmailRules_Validate(t *testing.T) {
+	cases := [...]struct {
+		rules       business.EmailRules
+		email       business.Email
+		expectedErr error
+	}{
+		// Test case: every domain is allowed by default
+		{
+			email: "contacto@yael.mx",
+		},
+		// Test case: subdomain of an allowed domain
+		{
+			rules: business.EmailRules{AllowedDomains: []string{"yael.mx"}},
+			email: "contacto@mail.yael.mx",
+		},
+		// Test case: domain not allowed
+		{
+			rules:       business.EmailRules{AllowedDomains: []string{"yael.mx"}},
+			email:       "contacto@example.com",
+			expectedErr: business.ErrUserEmailDomainNotAllowed,
+		},
+		// Test case: the suffix of the domain is not a subdomain
+		{
+			rules:       business.EmailRules{AllowedDomains: []string{"yael.mx"}},
+			email:       "contacto@notyael.mx",
+			expectedErr: business.ErrUserEmailDomainNotAllowed,
+		},
+		// Test case: denied domain
+		{
+			rules:       business.EmailRules{DeniedDomains: []string{"mailinator.com"}},
+			email:       "contacto@mailinator.com",
+			expectedErr: business.ErrUserEmailDomainNotAllowed,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			err := c.rules.Validate(c.email)
+			if !errors.Is(err, c.expectedErr) {
+				t.Fatalf("expected error '%v' got '%v'", c.expectedErr, err)
+			}
+		})
+	}
+}
@@ -1,25 +1,231 @@
 package business
 
-import "strconv"
+import (
+	"maps"
+	"strings"
+)
 
 // Supported values for Error
 //
-// WARNING: Do not change the order of error declarations.
+// The codes are part of the API contract, do not change them once they are published.
+var (
+	ErrInvalidUserID = &Error{
+		Code:     "USER_ID_INVALID",
+		Message:  "Invalid user ID",
+		Category: CategoryValidation,
+	}
+	ErrInvalidUserAge = &Error{
+		Code:     "USER_AGE_INVALID",
+		Message:  "Invalid user age",
+		Category: CategoryValidation,
+	}
+	ErrInvalidUserBirthDate = &Error{
+		Code:     "USER_BIRTH_DATE_INVALID",
+		Message:  "Invalid user birth date",
+		Category: CategoryValidation,
+	}
+	ErrInvalidUserName = &Error{
+		Code:     "USER_NAME_INVALID",
+		Message:  "Invalid user name",
+		Category: CategoryValidation,
+	}
+	ErrInvalidUserEmail = &Error{
+		Code:     "USER_EMAIL_INVALID",
+		Message:  "Invalid user email",
+		Category: CategoryValidation,
+	}
+	ErrDuplicateUserEmail = &Error{
+		Code:     "USER_EMAIL_DUPLICATE",
+		Message:  "User email already exists",
+		Category: CategoryConflict,
+	}
+	ErrUserEmailDomainNotAllowed = &Error{
+		Code:     "USER_EMAIL_DOMAIN_NOT_ALLOWED",
+		Message:  "User email domain not allowed",
+		Category: CategoryValidation,
+	}
+	ErrInvalidEmailVerificationToken = &Error{
+		Code:     "EMAIL_VERIFICATION_TOKEN_INVALID",
+		Message:  "Invalid email verification token",
+		Category: CategoryValidation,
+	}
+	ErrUserNotFound = &Error{
+		Code:     "USER_NOT_FOUND",
+		Message:  "User not found",
+		Category: CategoryNotFound,
+	}
+	ErrMessageDeliveryFailed = &Error{
+		Code:      "MESSAGE_DELIVERY_FAILED",
+		Message:   "Message delivery failed",
+		Category:  CategoryUnavailable,
+		Retryable: true,
+	}
+	ErrUnableToDeliverMessages = &Error{
+		Code:      "MESSAGES_UNDELIVERABLE",
+		Message:   "Unable to deliver messages",
+		Category:  CategoryUnavailable,
+		Retryable: true,
+	}
+	ErrIncompatibleMessageSchema = &Error{
+		Code:     "MESSAGE_SCHEMA_INCOMPATIBLE",
+		Message:  "Incompatible message schema",
+		Category: CategoryInternal,
+	}
+	ErrInvalidPageSize = &Error{
+		Code:     "PAGE_SIZE_INVALID",
+		Message:  "Invalid page size",
+		Category: CategoryValidation,
+	}
+	ErrInvalidAPIKeyName = &Error{
+		Code:     "API_KEY_NAME_INVALID",
+		Message:  "Invalid API key name",
+		Category: CategoryValidation,
+	}
+	ErrInvalidScope = &Error{
+		Code:     "SCOPE_INVALID",
+		Message:  "Invalid scope",
+		Category: CategoryValidation,
+	}
+	ErrAPIKeyNotFound = &Error{
+		Code:     "API_KEY_NOT_FOUND",
+		Message:  "API key not found",
+		Category: CategoryNotFound,
+	}
+	ErrInvalidAPIKey = &Error{
+		Code:     "API_KEY_INVALID",
+		Message:  "Invalid API key",
+		Category: CategoryUnauthenticated,
+	}
+	ErrUnauthenticated = &Error{
+		Code:     "AUTHENTICATION_REQUIRED",
+		Message:  "Authentication required",
+		Category: CategoryUnauthenticated,
+	}
+	ErrAccessDenied = &Error{
+		Code:     "ACCESS_DENIED",
+		Message:  "Access denied",
+		Category: CategoryForbidden,
+	}
+)
+
+// Supported values for Category
 const (
-	_ Error = iota
-	ErrInvalidUserID
-	ErrInvalidUserAge
-	ErrInvalidUserName
-	ErrInvalidUserEmail
-	ErrDuplicateUserEmail
-	ErrUserNotFound
-	ErrMessageDeliveryFailed
-	ErrUnableToDeliverMessages
+	CategoryInternal Category = iota
+	CategoryValidation
+	CategoryConflict
+	CategoryNotFound
+	CategoryUnavailable
+	CategoryUnauthenticated
+	CategoryForbidden
 )
 
-type Error uint8
+// Category classifies the Error(s), the adapters map their responses (status codes, exit codes) from it
+type Category uint8
+
+func (c Category) String() string {
+	switch c {
+	case CategoryValidation:
+		return "validation"
+	case CategoryConflict:
+		return "conflict"
+	case CategoryNotFound:
+		return "not_found"
+	case CategoryUnavailable:
+		return "unavailable"
+	case CategoryUnauthenticated:
+		return "unauthenticated"
+	case CategoryForbidden:
+		return "forbidden"
+	}
+
+	return "internal"
+}
+
+// Error is a business error identified by a stable Code
+type Error struct {
+	// Code is the stable symbolic identifier of the error (e.g. USER_EMAIL_DUPLICATE)
+	Code string
+	// Message is a short summary of the error that does not change from occurrence to occurrence
+	Message  string
+	Category Category
+	// Retryable indicates if the operation could succeed if it is tried again
+	Retryable bool
+	// Metadata is the context of an occurrence of the error (see Error.With)
+	Metadata map[string]string
+}
+
+func (e *Error) Error() string {
+	return e.Code
+}
+
+// Is reports if target is an Error with the same Code, so the copies made by With match the declared errors
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// With returns a copy of the Error with the key-value pair in its Metadata
+func (e *Error) With(key, value string) *Error {
+	err := *e
+	err.Metadata = maps.Clone(e.Metadata)
+
+	if err.Metadata == nil {
+		err.Metadata = make(map[string]string, 1)
+	}
+
+	err.Metadata[key] = value
+	return &err
+}
+
+// FieldError is a violation of the rules of a field
+type FieldError struct {
+	Field string
+	Err   error
+}
+
+func (f FieldError) Error() string {
+	return f.Field + ": " + f.Err.Error()
+}
+
+func (f FieldError) Unwrap() error {
+	return f.Err
+}
+
+// ValidationErrors collects all the FieldError(s) of an entity instead of stopping at the first one
+type ValidationErrors []FieldError
+
+// Add adds err as a violation of field (if it is not nil)
+func (v *ValidationErrors) Add(field string, err error) {
+	if err != nil {
+		*v = append(*v, FieldError{Field: field, Err: err})
+	}
+}
+
+// Err returns the ValidationErrors as error or nil if there are no violations
+func (v ValidationErrors) Err() error {
+	if len(v) == 0 {
+		return nil
+	}
+
+	return v
+}
+
+func (v ValidationErrors) Error() string {
+	messages := make([]string, len(v))
+
+	for i, fieldErr := range v {
+		messages[i] = fieldErr.Error()
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+func (v ValidationErrors) Unwrap() []error {
+	errs := make([]error, len(v))
+
+	for i, fieldErr := range v {
+		errs[i] = fieldErr
+	}
 
-func (e Error) Error() string {
-	const errorPrefix = "E"
-	return errorPrefix + strconv.FormatUint(uint64(e), 10)
+	return errs
 }
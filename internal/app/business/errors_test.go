@@ -0,0 +1,38 @@
+package business_test
+
+import (
+	"errors"
+	"fmt"
+	"github.com/yael-castro/goarch/internal/app/business"
+	"reflect"
+	"testing"
+)
+
+func TestError_With(t *testing.T) {
+	err := fmt.Errorf("%w: user 1 not found", business.ErrUserNotFound.With("user_id", "1"))
+
+	if !errors.Is(err, business.ErrUserNotFound) {
+		t.Fatalf("expected '%v' to match '%v'", err, business.ErrUserNotFound)
+	}
+
+	if errors.Is(err, business.ErrInvalidUserID) {
+		t.Fatalf("unexpected match of '%v' with '%v'", err, business.ErrInvalidUserID)
+	}
+
+	var userErr *business.Error
+
+	if !errors.As(err, &userErr) {
+		t.Fatalf("expected business error got '%T'", err)
+	}
+
+	expectedMetadata := map[string]string{"user_id": "1"}
+
+	if !reflect.DeepEqual(userErr.Metadata, expectedMetadata) {
+		t.Fatalf("expected metadata '%v' got '%v'", expectedMetadata, userErr.Metadata)
+	}
+
+	// The declared error must not be modified
+	if business.ErrUserNotFound.Metadata != nil {
+		t.Fatalf("unexpected metadata '%v' in the declared error", business.ErrUserNotFound.Metadata)
+	}
+}
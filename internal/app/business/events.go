@@ -0,0 +1,95 @@
+package business
+
+import "time"
+
+// Event is something that happened in the business that other systems must know
+type Event interface {
+	// OccurredAt indicates when the Event happened
+	OccurredAt() time.Time
+}
+
+var (
+	_ Event = UserCreated{}
+	_ Event = UserUpdated{}
+	_ Event = UserDeleted{}
+	_ Event = UserErased{}
+	_ Event = UserEmailVerificationRequested{}
+	_ Event = UserEmailVerified{}
+)
+
+// UserCreated is emitted when a User is created
+type UserCreated struct {
+	User User
+	Time time.Time
+}
+
+func (u UserCreated) OccurredAt() time.Time {
+	return u.Time
+}
+
+// UserUpdated is emitted when a User is updated
+type UserUpdated struct {
+	User User
+	Time time.Time
+}
+
+func (u UserUpdated) OccurredAt() time.Time {
+	return u.Time
+}
+
+// UserDeleted is emitted when a User is (soft) deleted
+type UserDeleted struct {
+	UserID UserID
+	Time   time.Time
+}
+
+func (u UserDeleted) OccurredAt() time.Time {
+	return u.Time
+}
+
+// UserErased is emitted when the personal data of a User is erased, the consumers must purge their copies
+type UserErased struct {
+	UserID UserID
+	Time   time.Time
+}
+
+func (u UserErased) OccurredAt() time.Time {
+	return u.Time
+}
+
+// UserEmailVerificationRequested is emitted when the Email of a User must be verified, the mailer sends the Token
+// to the Email
+type UserEmailVerificationRequested struct {
+	UserID    UserID
+	Email     Email
+	Token     EmailVerificationToken
+	ExpiresAt time.Time
+	Time      time.Time
+}
+
+func (u UserEmailVerificationRequested) OccurredAt() time.Time {
+	return u.Time
+}
+
+// UserEmailVerified is emitted when the owner of the Email of a User verifies it
+type UserEmailVerified struct {
+	UserID UserID
+	Email  Email
+	Time   time.Time
+}
+
+func (u UserEmailVerified) OccurredAt() time.Time {
+	return u.Time
+}
+
+// UpdateFunc completes the update of a User with its state before the update (e.g. keeps its EmailVerifiedAt).
+// It is called by the UserStore inside the transaction that updates the User, after locking the stored User
+type UpdateFunc func(before User, user *User)
+
+// OutboxFunc builds the outbox Message(s) of a change in a User.
+// It is called by the UserStore inside the transaction that changes the User, so the Message(s) are stored atomically
+type OutboxFunc func(User) ([]Message, error)
+
+// ScrubFunc removes the personal data of a Message built by an OutboxFunc.
+// It is called by the UserStore for every Message of a User, delivered or not, inside the transaction that erases the User
+type ScrubFunc func(Message) (Message, error)
@@ -0,0 +1,75 @@
+//go:build relay
+
+package business
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+)
+
+// ErrLeadershipLost is the cause (see context.Cause) of the cancellation of the context of a leadership that is lost
+// before it is given up, another replica can be elected while the former leader is still working
+var ErrLeadershipLost = errors.New("leadership lost")
+
+// NewLeaderMessagesRelay builds a MessagesRelay that only relays messages while it holds the leadership
+func NewLeaderMessagesRelay(relay MessagesRelay, elector LeaderElector, logger *slog.Logger) (MessagesRelay, error) {
+	if relay == nil || elector == nil || logger == nil {
+		return nil, errors.New("some dependencies are nil")
+	}
+
+	return leaderMessagesRelay{
+		relay:   relay,
+		elector: elector,
+		logger:  logger,
+	}, nil
+}
+
+type leaderMessagesRelay struct {
+	relay   MessagesRelay
+	elector LeaderElector
+	logger  *slog.Logger
+}
+
+func (l leaderMessagesRelay) RelayMessages(ctx context.Context) error {
+	for {
+		l.logger.InfoContext(ctx, "standing_by")
+
+		leaderCtx, resign, err := l.elector.Campaign(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			return err
+		}
+
+		l.logger.InfoContext(ctx, "leadership_acquired")
+
+		err = l.relay.RelayMessages(leaderCtx)
+
+		// The in-flight batch is finished, another replica can relay the messages that are not confirmed
+		resign()
+
+		if err != nil {
+			return err
+		}
+
+		// Stopped by the caller
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		l.logger.WarnContext(ctx, "leadership_lost", "cause", context.Cause(leaderCtx))
+	}
+}
+
+func (l leaderMessagesRelay) RelayStats(ctx context.Context) (RelayStats, error) {
+	stats, err := l.relay.RelayStats(ctx)
+	if err != nil {
+		return RelayStats{}, err
+	}
+
+	stats.Leader = l.elector.IsLeader()
+	return stats, nil
+}
@@ -0,0 +1,112 @@
+//go:build relay
+
+package business_test
+
+import (
+	"context"
+	"errors"
+	"github.com/yael-castro/goarch/internal/app/business"
+	"github.com/yael-castro/goarch/internal/app/business/mock"
+	"io"
+	"log/slog"
+	"strconv"
+	"testing"
+)
+
+func TestLeaderMessagesRelay_RelayMessages(t *testing.T) {
+	errRelay := errors.New("relay failure")
+	errCampaign := errors.New("campaign failure")
+
+	cases := [...]struct {
+		// terms is the number of times that the leadership is acquired before the relay is stopped
+		terms          int
+		relayErr       error
+		campaignErr    error
+		expectedErr    error
+		expectedRelays int
+	}{
+		// Test case: the leadership is lost and acquired again
+		{
+			terms:          3,
+			expectedRelays: 3,
+		},
+		// Test case: the relay fails while leading
+		{
+			terms:          3,
+			relayErr:       errRelay,
+			expectedErr:    errRelay,
+			expectedRelays: 1,
+		},
+		// Test case: the leadership can't be campaigned
+		{
+			terms:       3,
+			campaignErr: errCampaign,
+			expectedErr: errCampaign,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			ctx, stop := context.WithCancel(context.Background())
+			defer stop()
+
+			campaigns, relays, resigns := 0, 0, 0
+			relaying := false
+
+			// The leadership is given up only after the relay returns
+			resign := func() {
+				if relaying {
+					t.Error("resigned while relaying")
+				}
+
+				resigns++
+			}
+
+			elector := mock.LeaderElector(func(ctx context.Context) (context.Context, func(), error) {
+				if c.campaignErr != nil {
+					return nil, nil, c.campaignErr
+				}
+
+				campaigns++
+
+				leaderCtx, lose := context.WithCancel(ctx)
+
+				// Losing the leadership immediately
+				lose()
+
+				// Stopping the relay on the last term
+				if campaigns == c.terms {
+					stop()
+				}
+
+				return leaderCtx, resign, nil
+			})
+
+			relay := mock.MessagesRelay(func(ctx context.Context) error {
+				relays++
+				relaying = true
+				<-ctx.Done()
+				relaying = false
+				return c.relayErr
+			})
+
+			leaderRelay, err := business.NewLeaderMessagesRelay(relay, elector, slog.New(slog.NewTextHandler(io.Discard, nil)))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = leaderRelay.RelayMessages(ctx)
+			if !errors.Is(err, c.expectedErr) {
+				t.Fatalf("expected error '%v' got '%v'", c.expectedErr, err)
+			}
+
+			if relays != c.expectedRelays {
+				t.Fatalf("expected %d relays got %d", c.expectedRelays, relays)
+			}
+
+			if resigns != relays {
+				t.Fatalf("expected %d resignations got %d", relays, resigns)
+			}
+		})
+	}
+}
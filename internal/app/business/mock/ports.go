@@ -1,26 +1,228 @@
 package mock
 
 import (
+	"bytes"
 	"context"
 	"github.com/yael-castro/goarch/internal/app/business"
+	"time"
 )
 
-type MessageSender struct{}
+type MessagesReader func(context.Context, []business.Message) (int, error)
 
-func (MessageSender) SendMessage(context.Context, *business.Message) error {
+func (f MessagesReader) ReadMessages(ctx context.Context, messages []business.Message) (int, error) {
+	return f(ctx, messages)
+}
+
+func (MessagesReader) Close() error {
 	return nil
 }
 
-type UserStore struct{}
+type MessagesBacklogReader func(context.Context) (business.Backlog, error)
 
-func (UserStore) CreateUser(context.Context, *business.User) error {
-	return nil
+func (f MessagesBacklogReader) ReadBacklog(ctx context.Context) (business.Backlog, error) {
+	return f(ctx)
+}
+
+type MessageSender func(context.Context, ...business.Message) error
+
+func (f MessageSender) SendMessage(ctx context.Context, messages ...business.Message) error {
+	return f(ctx, messages...)
+}
+
+type MessageDeliveryConfirmer func(context.Context, ...business.Message) error
+
+func (f MessageDeliveryConfirmer) ConfirmMessageDelivery(ctx context.Context, messages ...business.Message) error {
+	return f(ctx, messages...)
+}
+
+type LeaderElector func(context.Context) (context.Context, func(), error)
+
+func (f LeaderElector) Campaign(ctx context.Context) (context.Context, func(), error) {
+	return f(ctx)
+}
+
+func (LeaderElector) IsLeader() bool {
+	return true
+}
+
+type MessagesRelay func(context.Context) error
+
+func (f MessagesRelay) RelayMessages(ctx context.Context) error {
+	return f(ctx)
 }
 
-func (UserStore) UpdateUser(context.Context, *business.User) error {
+func (MessagesRelay) RelayStats(context.Context) (business.RelayStats, error) {
+	return business.RelayStats{}, nil
+}
+
+type KeyRotator func(context.Context) (int, error)
+
+func (f KeyRotator) RotateKeys(ctx context.Context) (int, error) {
+	return f(ctx)
+}
+
+type EmailIndexer func(context.Context) (int, error)
+
+func (f EmailIndexer) IndexEmails(ctx context.Context) (int, error) {
+	return f(ctx)
+}
+
+// UserStore simulates the persistence of users, the Message(s) built by the business.OutboxFunc are kept in Outbox
+// and the last business.EmailVerification is kept in Verification. Current is the stored User read by UpdateUser
+type UserStore struct {
+	Outbox       *[]business.Message
+	Verification *business.EmailVerification
+	Current      *business.User
+}
+
+func (u UserStore) CreateUser(_ context.Context, user *business.User, outbox business.OutboxFunc) error {
+	u.verify(user.EmailVerification)
+	return u.store(*user, outbox)
+}
+
+func (u UserStore) UpdateUser(_ context.Context, user *business.User, update business.UpdateFunc, outbox business.OutboxFunc) error {
+	var before business.User
+
+	if u.Current != nil {
+		before = *u.Current
+	}
+
+	update(before, user)
+
+	u.verify(user.EmailVerification)
+	return u.store(*user, outbox)
+}
+
+func (u UserStore) verify(verification business.EmailVerification) {
+	if u.Verification != nil {
+		*u.Verification = verification
+	}
+}
+
+// VerifyEmail verifies the email if hash is the hash of the pending Verification and it has not expired
+func (u UserStore) VerifyEmail(_ context.Context, id business.UserID, hash []byte, outbox business.OutboxFunc) error {
+	if u.Verification == nil || u.Verification.IsZero() || !bytes.Equal(u.Verification.Hash, hash) || time.Now().After(u.Verification.ExpiresAt) {
+		return business.ErrInvalidEmailVerificationToken
+	}
+
+	// The verification is used only once
+	*u.Verification = business.EmailVerification{}
+
+	return u.store(business.User{ID: id, EmailVerifiedAt: time.Now()}, outbox)
+}
+
+func (u UserStore) DeleteUser(_ context.Context, id business.UserID, outbox business.OutboxFunc) error {
+	return u.store(business.User{ID: id}, outbox)
+}
+
+func (u UserStore) store(user business.User, outbox business.OutboxFunc) error {
+	messages, err := outbox(user)
+	if err != nil {
+		return err
+	}
+
+	if u.Outbox != nil {
+		*u.Outbox = append(*u.Outbox, messages...)
+	}
+
 	return nil
 }
 
 func (UserStore) QueryUser(context.Context, business.UserID) (business.User, error) {
 	return business.User{}, nil
 }
+
+func (UserStore) QueryUsers(context.Context, business.UsersPage) ([]business.User, error) {
+	return nil, nil
+}
+
+func (UserStore) QueryUserHistory(context.Context, business.HistoryPage) ([]business.AuditEntry, error) {
+	return nil, nil
+}
+
+func (u UserStore) ExportUser(_ context.Context, id business.UserID) (business.UserExport, error) {
+	export := business.UserExport{User: business.User{ID: id}}
+
+	if u.Outbox != nil {
+		export.Messages = *u.Outbox
+	}
+
+	return export, nil
+}
+
+// EraseUser scrubs every Message kept in Outbox before storing the Message(s) of the erasure
+func (u UserStore) EraseUser(_ context.Context, id business.UserID, scrub business.ScrubFunc, outbox business.OutboxFunc) (err error) {
+	if u.Outbox != nil {
+		for i := range *u.Outbox {
+			(*u.Outbox)[i], err = scrub((*u.Outbox)[i])
+			if err != nil {
+				return
+			}
+		}
+	}
+
+	return u.store(business.User{ID: id}, outbox)
+}
+
+// EventEncoder simulates the encoding of events, the encoded business.Event(s) are kept in Events
+type EventEncoder struct {
+	Events *[]business.Event
+}
+
+func (e EventEncoder) EncodeEvent(event business.Event) (business.Message, error) {
+	if e.Events != nil {
+		*e.Events = append(*e.Events, event)
+	}
+
+	return business.Message{}, nil
+}
+
+// ScrubMessage simulates the removal of the personal data by removing the value of the message
+func (EventEncoder) ScrubMessage(message business.Message) (business.Message, error) {
+	message.Value = nil
+	return message, nil
+}
+
+// UserPolicy simulates the authorization of the use cases, a nil UserPolicy allows every business.Action
+type UserPolicy func(context.Context, business.Action, business.UserID) error
+
+func (f UserPolicy) AuthorizeUser(ctx context.Context, action business.Action, id business.UserID) error {
+	if f == nil {
+		return nil
+	}
+
+	return f(ctx, action, id)
+}
+
+// APIKeyStore simulates the persistence of the API keys, the created business.APIKey(s) are kept in Keys
+type APIKeyStore struct {
+	Keys *[]business.APIKey
+}
+
+func (a APIKeyStore) CreateAPIKey(_ context.Context, key *business.APIKey) error {
+	key.ID = business.APIKeyID(len(*a.Keys) + 1)
+	*a.Keys = append(*a.Keys, *key)
+	return nil
+}
+
+func (a APIKeyStore) RevokeAPIKey(_ context.Context, id business.APIKeyID) error {
+	for i, key := range *a.Keys {
+		if key.ID == id {
+			*a.Keys = append((*a.Keys)[:i], (*a.Keys)[i+1:]...)
+			return nil
+		}
+	}
+
+	return business.ErrAPIKeyNotFound
+}
+
+func (a APIKeyStore) UseAPIKey(_ context.Context, hash []byte) (business.APIKey, error) {
+	for i, key := range *a.Keys {
+		if bytes.Equal(key.Hash, hash) {
+			(*a.Keys)[i].LastUsedAt = time.Now()
+			return (*a.Keys)[i], nil
+		}
+	}
+
+	return business.APIKey{}, business.ErrAPIKeyNotFound
+}
@@ -1,8 +1,17 @@
 package business
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
 	"fmt"
 	"github.com/google/uuid"
+	"golang.org/x/net/idna"
+	"log/slog"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
 	"unicode"
 )
 
@@ -10,42 +19,57 @@ type User struct {
 	ID    UserID
 	Name  Name
 	Email Email
-	Age   Age
+	// BirthDate is stored instead of the age, it is computed when the User is read (see BirthDate.Age)
+	BirthDate BirthDate
+	// EmailVerifiedAt is zero until the owner of the Email verifies it
+	EmailVerifiedAt time.Time
+	// EmailVerification is the verification issued for the Email by the creation or the update of the User,
+	// it is zero if the Email does not have to be verified again
+	EmailVerification EmailVerification
 }
 
 func (p User) Validate() error {
-	if err := p.Name.Validate(); err != nil {
-		return err
-	}
+	var violations ValidationErrors
 
-	if err := p.Age.Validate(); err != nil {
-		return err
-	}
+	violations.Add("name", p.Name.Validate())
+	violations.Add("email", p.Email.Validate())
+	violations.Add("birth_date", p.BirthDate.Validate())
 
-	return p.Email.Validate()
+	return violations.Err()
 }
 
-type Age uint8
-
-func (a Age) Validate() error {
-	const minAge, maxAge = 1, 100
-
-	if a < minAge {
-		return fmt.Errorf("%w: user can't be a baby", ErrInvalidUserAge)
-	}
+type UserID uint64
 
-	if a > maxAge {
-		return fmt.Errorf("%w: user is not alive", ErrInvalidUserAge)
+func (u UserID) Validate() error {
+	if u == 0 {
+		return fmt.Errorf("%w: %d is not a valid user id", ErrInvalidUserID.With("user_id", strconv.FormatUint(uint64(u), 10)), u)
 	}
 
 	return nil
 }
 
-type UserID uint64
+// UsersPage selects the users whose ID is greater than AfterID (keyset pagination)
+type UsersPage struct {
+	AfterID UserID
+	Size    PageSize
+}
 
-func (u UserID) Validate() error {
-	if u == 0 {
-		return fmt.Errorf("%w: %d is not a valid user id", ErrInvalidUserID, u)
+func (p UsersPage) Validate() error {
+	var violations ValidationErrors
+
+	violations.Add("page_size", p.Size.Validate())
+
+	return violations.Err()
+}
+
+type PageSize uint16
+
+func (s PageSize) Validate() error {
+	const minPageSize, maxPageSize = 1, 100
+
+	if s < minPageSize || s > maxPageSize {
+		err := ErrInvalidPageSize.With("min", strconv.Itoa(minPageSize)).With("max", strconv.Itoa(maxPageSize))
+		return fmt.Errorf("%w: page size must be between %d and %d", err, minPageSize, maxPageSize)
 	}
 
 	return nil
@@ -55,16 +79,51 @@ var _ fmt.Stringer = Email("")
 
 type Email string
 
+// Validate checks that the Email is an addr-spec (RFC 5322) within the limits of RFC 5321, whose domain is a valid
+// internationalized domain name (IDNA2008)
 func (e Email) Validate() error {
-	const minEmailLength = 3
+	const maxEmailLength, maxLocalPartLength = 254, 64
+
+	err := ErrInvalidUserEmail.With("email", string(e))
+
+	if len(e) > maxEmailLength {
+		return fmt.Errorf("%w: email must have at most %d characters", err, maxEmailLength)
+	}
 
-	if len(e) < minEmailLength {
-		return fmt.Errorf("%w: '%s' is not a valid email address", ErrInvalidUserEmail, e)
+	// The display names (Name <addr>) and the comments are not part of an addr-spec
+	address, parseErr := mail.ParseAddress(string(e))
+	if parseErr != nil || len(address.Name) > 0 || strings.HasSuffix(string(e), ">") {
+		return fmt.Errorf("%w: '%s' is not a valid email address", err, e)
+	}
+
+	localPart, domain := e.split()
+
+	if len(localPart) > maxLocalPartLength {
+		return fmt.Errorf("%w: local part must have at most %d characters", err, maxLocalPartLength)
+	}
+
+	if _, idnaErr := idna.Lookup.ToASCII(domain); idnaErr != nil {
+		return fmt.Errorf("%w: '%s' is not a valid domain: %v", err, domain, idnaErr)
 	}
 
 	return nil
 }
 
+// Domain returns the part after the last '@'
+func (e Email) Domain() string {
+	_, domain := e.split()
+	return domain
+}
+
+func (e Email) split() (localPart, domain string) {
+	at := strings.LastIndexByte(string(e), '@')
+	if at < 0 {
+		return string(e), ""
+	}
+
+	return string(e[:at]), string(e[at+1:])
+}
+
 func (e Email) String() string {
 	return string(e)
 }
@@ -76,13 +135,16 @@ type Name string
 func (n Name) Validate() error {
 	const minNameLength = 4
 
+	// Params of the localized messages
+	err := ErrInvalidUserName.With("min_length", strconv.Itoa(minNameLength))
+
 	if len(n) < minNameLength {
-		return fmt.Errorf("%w: name must be at least %d characters", ErrInvalidUserName, minNameLength)
+		return fmt.Errorf("%w: name must be at least %d characters", err, minNameLength)
 	}
 
 	for _, char := range n {
 		if !unicode.IsLetter(char) && !unicode.IsSpace(char) {
-			return fmt.Errorf("%w: '%s' is not a letter", ErrInvalidUserName, string(char))
+			return fmt.Errorf("%w: '%s' is not a letter", err, string(char))
 		}
 	}
 
@@ -93,6 +155,115 @@ func (n Name) String() string {
 	return string(n)
 }
 
+type APIKeyID uint64
+
+func (a APIKeyID) Validate() error {
+	if a == 0 {
+		return fmt.Errorf("%w: %d is not a valid API key id", ErrAPIKeyNotFound.With("api_key_id", strconv.FormatUint(uint64(a), 10)), a)
+	}
+
+	return nil
+}
+
+// APIKey identifies a service-to-service caller with the granted Scopes
+type APIKey struct {
+	ID   APIKeyID
+	Name string
+	// Hash is the SHA-256 of the APIKeySecret, the secret itself is never stored
+	Hash []byte
+	// Prefix is the beginning of the APIKeySecret, it identifies the key without revealing it
+	Prefix     string
+	Scopes     []Scope
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+}
+
+func (a APIKey) Validate() error {
+	const maxNameLength = 100
+
+	var violations ValidationErrors
+
+	if len(strings.TrimSpace(a.Name)) == 0 || len(a.Name) > maxNameLength {
+		err := ErrInvalidAPIKeyName.With("max_length", strconv.Itoa(maxNameLength))
+		violations.Add("name", fmt.Errorf("%w: name must have between 1 and %d characters", err, maxNameLength))
+	}
+
+	if len(a.Scopes) == 0 {
+		violations.Add("scopes", fmt.Errorf("%w: at least one scope is required", ErrInvalidScope.With("scope", "")))
+	}
+
+	for _, scope := range a.Scopes {
+		violations.Add("scopes", scope.Validate())
+	}
+
+	return violations.Err()
+}
+
+// Principal returns the Principal of the callers that present the APIKey
+func (a APIKey) Principal() Principal {
+	return Principal{
+		Subject: "api_key:" + strconv.FormatUint(uint64(a.ID), 10),
+		Scopes:  a.Scopes,
+	}
+}
+
+// redacted replaces the APIKeySecret in the strings and the logs
+const redacted = "[REDACTED]"
+
+var (
+	_ fmt.Stringer   = APIKeySecret("")
+	_ slog.LogValuer = APIKeySecret("")
+)
+
+// APIKeySecret is the value of an APIKey, it is only known by its owner and it is never printed or logged
+type APIKeySecret string
+
+// NewAPIKeySecret generates a random APIKeySecret (256 bits)
+func NewAPIKeySecret() (APIKeySecret, error) {
+	const (
+		secretPrefix = "gak_"
+		secretSize   = 32
+	)
+
+	b := make([]byte, secretSize)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return APIKeySecret(secretPrefix + base64.RawURLEncoding.EncodeToString(b)), nil
+}
+
+// Hash returns the SHA-256 of the secret, the secret has enough entropy to make a slow hash unnecessary
+func (a APIKeySecret) Hash() []byte {
+	sum := sha256.Sum256([]byte(a))
+	return sum[:]
+}
+
+// Prefix returns the beginning of the secret to identify it without revealing it
+func (a APIKeySecret) Prefix() string {
+	const prefixLength = 12
+
+	return string(a[:min(len(a), prefixLength)])
+}
+
+// Reveal returns the value of the secret, it must be used only to respond the secret to its owner
+func (a APIKeySecret) Reveal() string {
+	return string(a)
+}
+
+func (a APIKeySecret) String() string {
+	return redacted
+}
+
+func (a APIKeySecret) GoString() string {
+	return redacted
+}
+
+func (a APIKeySecret) LogValue() slog.Value {
+	return slog.StringValue(redacted)
+}
+
 type Header struct {
 	Key   string
 	Value []byte
@@ -128,3 +299,16 @@ func (m *Message) Idempotent() (err error) {
 
 	return
 }
+
+// Backlog describes the Message(s) pending to be delivered
+type Backlog struct {
+	Pending          uint64
+	OldestPendingAge time.Duration
+}
+
+// RelayStats describes the state of the message relay
+type RelayStats struct {
+	Backlog
+	LastBatchAt time.Time
+	Leader      bool
+}
@@ -0,0 +1,126 @@
+package business_test
+
+import (
+	"errors"
+	"github.com/yael-castro/goarch/internal/app/business"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestUser_Validate(t *testing.T) {
+	cases := [...]struct {
+		user           business.User
+		expectedFields []string
+		expectedErrs   []error
+	}{
+		// Test case: every field is invalid
+		{
+			user:           business.User{Name: "1"},
+			expectedFields: []string{"name", "email", "birth_date"},
+			expectedErrs:   []error{business.ErrInvalidUserName, business.ErrInvalidUserEmail, business.ErrInvalidUserBirthDate},
+		},
+		// Test case: only the email is invalid
+		{
+			user:           business.User{Name: "Yael", BirthDate: "2001-07-14"},
+			expectedFields: []string{"email"},
+			expectedErrs:   []error{business.ErrInvalidUserEmail},
+		},
+		// Test case: valid user
+		{
+			user: business.User{Name: "Yael", Email: "contacto@yael.mx", BirthDate: "2001-07-14"},
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			err := c.user.Validate()
+
+			var violations business.ValidationErrors
+
+			if !errors.As(err, &violations) && len(c.expectedFields) > 0 {
+				t.Fatalf("expected validation errors got '%v'", err)
+			}
+
+			fields := make([]string, 0, len(violations))
+
+			for _, violation := range violations {
+				fields = append(fields, violation.Field)
+			}
+
+			if len(fields) > 0 && !reflect.DeepEqual(fields, c.expectedFields) {
+				t.Fatalf("expected fields '%v' got '%v'", c.expectedFields, fields)
+			}
+
+			if len(fields) != len(c.expectedFields) {
+				t.Fatalf("expected %d violations got %d", len(c.expectedFields), len(fields))
+			}
+
+			for _, expectedErr := range c.expectedErrs {
+				if !errors.Is(err, expectedErr) {
+					t.Fatalf("expected error '%v' in '%v'", expectedErr, err)
+				}
+			}
+		})
+	}
+}
+
+func TestEmail_Validate(t *testing.T) {
+	cases := [...]struct {
+		email       business.Email
+		expectedErr error
+	}{
+		// Test case: valid email
+		{
+			email: "contacto@yael.mx",
+		},
+		// Test case: quoted local part
+		{
+			email: `"yael castro"@yael.mx`,
+		},
+		// Test case: internationalized domain
+		{
+			email: "contacto@méxico.mx",
+		},
+		// Test case: missing domain
+		{
+			email:       "abc",
+			expectedErr: business.ErrInvalidUserEmail,
+		},
+		// Test case: display name
+		{
+			email:       "Yael <contacto@yael.mx>",
+			expectedErr: business.ErrInvalidUserEmail,
+		},
+		// Test case: angle brackets
+		{
+			email:       "<contacto@yael.mx>",
+			expectedErr: business.ErrInvalidUserEmail,
+		},
+		// Test case: unquoted spaces
+		{
+			email:       "yael castro@yael.mx",
+			expectedErr: business.ErrInvalidUserEmail,
+		},
+		// Test case: local part longer than 64 characters
+		{
+			email:       business.Email(strings.Repeat("a", 65) + "@yael.mx"),
+			expectedErr: business.ErrInvalidUserEmail,
+		},
+		// Test case: invalid domain label
+		{
+			email:       "contacto@-yael.mx",
+			expectedErr: business.ErrInvalidUserEmail,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			err := c.email.Validate()
+			if !errors.Is(err, c.expectedErr) {
+				t.Fatalf("expected error '%v' got '%v'", c.expectedErr, err)
+			}
+		})
+	}
+}
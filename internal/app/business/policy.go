@@ -0,0 +1,132 @@
+package business
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+)
+
+// Supported values for Scope
+const (
+	ScopeUsersRead  Scope = "users:read"
+	ScopeUsersWrite Scope = "users:write"
+	// ScopeUsersAdmin allows every Action on the records of any user
+	ScopeUsersAdmin Scope = "users:admin"
+)
+
+// Scope is a permission granted to a Principal
+type Scope string
+
+func (s Scope) Validate() error {
+	switch s {
+	case ScopeUsersRead, ScopeUsersWrite, ScopeUsersAdmin:
+		return nil
+	}
+
+	return fmt.Errorf("%w: '%s' is not a supported scope", ErrInvalidScope.With("scope", string(s)), s)
+}
+
+// Principal is the caller of the use cases, the drive adapters put it on the context (see ContextWithPrincipal)
+type Principal struct {
+	// Subject identifies the caller, it is the UserID when the caller is a user
+	Subject string
+	Scopes  []Scope
+}
+
+// NewPrincipal returns the Principal identified by subject that holds the scopes, e.g. the scopes of a token
+func NewPrincipal(subject string, scopes []string) Principal {
+	principal := Principal{
+		Subject: subject,
+		Scopes:  make([]Scope, len(scopes)),
+	}
+
+	for i, scope := range scopes {
+		principal.Scopes[i] = Scope(scope)
+	}
+
+	return principal
+}
+
+// HasScope indicates if scope is granted to the Principal
+func (p Principal) HasScope(scope Scope) bool {
+	return slices.Contains(p.Scopes, scope)
+}
+
+type principalKey struct{}
+
+// ContextWithPrincipal returns a copy of ctx that carries principal
+func ContextWithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the Principal carried by ctx (if any)
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(Principal)
+	return principal, ok
+}
+
+// Supported values for Action
+const (
+	ActionCreateUser  Action = "create_user"
+	ActionUpdateUser  Action = "update_user"
+	ActionDeleteUser  Action = "delete_user"
+	ActionVerifyEmail Action = "verify_email"
+	ActionQueryUser   Action = "query_user"
+	ActionListUsers   Action = "list_users"
+	// The history of the users is audited by admins
+	ActionQueryUserHistory Action = "query_user_history"
+	// The data subject requests are answered by admins
+	ActionExportUser Action = "export_user"
+	ActionEraseUser  Action = "erase_user"
+	// The API keys are managed by admins
+	ActionIssueAPIKey  Action = "issue_api_key"
+	ActionRevokeAPIKey Action = "revoke_api_key"
+)
+
+// Action is an operation of the UserCases or the APIKeyCases
+type Action string
+
+// NewScopePolicy builds the UserPolicy that authorizes by the scopes of the Principal:
+// reads require ScopeUsersRead, writes require ScopeUsersWrite and a Principal may update, delete (or verify the email of)
+// only its own record, the API keys, the history, the export and the erasure of the users require ScopeUsersAdmin.
+// ScopeUsersAdmin allows every Action on any user without the other scopes
+func NewScopePolicy() UserPolicy {
+	return scopePolicy{}
+}
+
+type scopePolicy struct{}
+
+func (scopePolicy) AuthorizeUser(ctx context.Context, action Action, id UserID) error {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return ErrUnauthenticated
+	}
+
+	required := ScopeUsersWrite
+
+	switch action {
+	case ActionQueryUser, ActionListUsers:
+		required = ScopeUsersRead
+	case ActionCreateUser:
+	case ActionIssueAPIKey, ActionRevokeAPIKey, ActionQueryUserHistory, ActionExportUser, ActionEraseUser:
+		required = ScopeUsersAdmin
+	case ActionUpdateUser, ActionDeleteUser, ActionVerifyEmail:
+		if principal.Subject != strconv.FormatUint(uint64(id), 10) {
+			required = ScopeUsersAdmin
+		}
+	default:
+		return ErrAccessDenied.With("action", string(action))
+	}
+
+	// Admins act on any user
+	if principal.HasScope(ScopeUsersAdmin) {
+		return nil
+	}
+
+	if !principal.HasScope(required) {
+		return ErrAccessDenied.With("action", string(action)).With("scope", string(required))
+	}
+
+	return nil
+}
@@ -0,0 +1,212 @@
+package business_test
+
+import (
+	"context"
+	"errors"
+	"github.com/yael-castro/goarch/internal/app/business"
+	"github.com/yael-castro/goarch/internal/app/business/mock"
+	"strconv"
+	"testing"
+)
+
+func TestScopePolicy_AuthorizeUser(t *testing.T) {
+	withPrincipal := func(subject string, scopes ...business.Scope) context.Context {
+		return business.ContextWithPrincipal(context.Background(), business.Principal{
+			Subject: subject,
+			Scopes:  scopes,
+		})
+	}
+
+	cases := [...]struct {
+		ctx         context.Context
+		action      business.Action
+		userID      business.UserID
+		expectedErr error
+	}{
+		// Test case: missing principal
+		{
+			ctx:         context.Background(),
+			action:      business.ActionQueryUser,
+			userID:      1,
+			expectedErr: business.ErrUnauthenticated,
+		},
+		// Test case: reads require users:read
+		{
+			ctx:         withPrincipal("1", business.ScopeUsersWrite),
+			action:      business.ActionListUsers,
+			expectedErr: business.ErrAccessDenied,
+		},
+		// Test case: read
+		{
+			ctx:    withPrincipal("1", business.ScopeUsersRead),
+			action: business.ActionQueryUser,
+			userID: 2,
+		},
+		// Test case: writes require users:write
+		{
+			ctx:         withPrincipal("1", business.ScopeUsersRead),
+			action:      business.ActionCreateUser,
+			expectedErr: business.ErrAccessDenied,
+		},
+		// Test case: write
+		{
+			ctx:    withPrincipal("1", business.ScopeUsersWrite),
+			action: business.ActionCreateUser,
+		},
+		// Test case: deletion of its own record
+		{
+			ctx:    withPrincipal("1", business.ScopeUsersWrite),
+			action: business.ActionDeleteUser,
+			userID: 1,
+		},
+		// Test case: deletion of the record of another user
+		{
+			ctx:         withPrincipal("1", business.ScopeUsersWrite),
+			action:      business.ActionDeleteUser,
+			userID:      2,
+			expectedErr: business.ErrAccessDenied,
+		},
+		// Test case: update of its own record
+		{
+			ctx:    withPrincipal("1", business.ScopeUsersWrite),
+			action: business.ActionUpdateUser,
+			userID: 1,
+		},
+		// Test case: update of the record of another user
+		{
+			ctx:         withPrincipal("1", business.ScopeUsersWrite),
+			action:      business.ActionUpdateUser,
+			userID:      2,
+			expectedErr: business.ErrAccessDenied,
+		},
+		// Test case: verification of the email of another user
+		{
+			ctx:         withPrincipal("1", business.ScopeUsersWrite),
+			action:      business.ActionVerifyEmail,
+			userID:      2,
+			expectedErr: business.ErrAccessDenied,
+		},
+		// Test case: verification of its own email
+		{
+			ctx:    withPrincipal("1", business.ScopeUsersWrite),
+			action: business.ActionVerifyEmail,
+			userID: 1,
+		},
+		// Test case: admin update of the record of another user
+		{
+			ctx:    withPrincipal("1", business.ScopeUsersWrite, business.ScopeUsersAdmin),
+			action: business.ActionUpdateUser,
+			userID: 2,
+		},
+		// Test case: admins update other users without the write scope
+		{
+			ctx:    withPrincipal("1", business.ScopeUsersAdmin),
+			action: business.ActionUpdateUser,
+			userID: 2,
+		},
+		// Test case: admins delete other users without the write scope
+		{
+			ctx:    withPrincipal("1", business.ScopeUsersAdmin),
+			action: business.ActionDeleteUser,
+			userID: 2,
+		},
+		// Test case: admins verify the email of other users without the write scope
+		{
+			ctx:    withPrincipal("1", business.ScopeUsersAdmin),
+			action: business.ActionVerifyEmail,
+			userID: 2,
+		},
+		// Test case: admins read the users without the read scope
+		{
+			ctx:    withPrincipal("1", business.ScopeUsersAdmin),
+			action: business.ActionListUsers,
+		},
+		// Test case: admins create users without the write scope
+		{
+			ctx:    withPrincipal("1", business.ScopeUsersAdmin),
+			action: business.ActionCreateUser,
+		},
+		// Test case: the history requires users:admin
+		{
+			ctx:         withPrincipal("1", business.ScopeUsersRead, business.ScopeUsersWrite),
+			action:      business.ActionQueryUserHistory,
+			userID:      1,
+			expectedErr: business.ErrAccessDenied,
+		},
+		// Test case: the erasure requires users:admin even for the own record
+		{
+			ctx:         withPrincipal("1", business.ScopeUsersRead, business.ScopeUsersWrite),
+			action:      business.ActionEraseUser,
+			userID:      1,
+			expectedErr: business.ErrAccessDenied,
+		},
+		// Test case: admins export the users
+		{
+			ctx:    withPrincipal("1", business.ScopeUsersAdmin),
+			action: business.ActionExportUser,
+			userID: 2,
+		},
+		// Test case: unknown action
+		{
+			ctx:         withPrincipal("1", business.ScopeUsersRead, business.ScopeUsersWrite, business.ScopeUsersAdmin),
+			action:      "drop_users",
+			expectedErr: business.ErrAccessDenied,
+		},
+	}
+
+	policy := business.NewScopePolicy()
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			err := policy.AuthorizeUser(c.ctx, c.action, c.userID)
+			if !errors.Is(err, c.expectedErr) {
+				t.Fatalf("expected error '%v' got '%v'", c.expectedErr, err)
+			}
+		})
+	}
+}
+
+// TestUserCases_AuthorizeUser checks that every use case consults the policy before calling the store
+func TestUserCases_AuthorizeUser(t *testing.T) {
+	var actions []business.Action
+
+	policy := mock.UserPolicy(func(_ context.Context, action business.Action, _ business.UserID) error {
+		actions = append(actions, action)
+		return business.ErrAccessDenied
+	})
+
+	logic, err := business.NewUserCases(mock.UserStore{}, mock.EventEncoder{}, policy, business.EmailRules{}, business.BirthDateRules{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	user := &business.User{ID: 1, Name: "Yael", Email: "contacto@yael.mx", BirthDate: "2001-07-14"}
+
+	_, queryErr := logic.QueryUser(ctx, 1)
+	_, listErr := logic.ListUsers(ctx, business.UsersPage{Size: 1})
+	_, historyErr := logic.QueryUserHistory(ctx, business.HistoryPage{UserID: 1, Size: 1})
+	_, exportErr := logic.ExportUser(ctx, 1)
+
+	errs := [...]error{
+		logic.CreateUser(ctx, user),
+		logic.UpdateUser(ctx, user),
+		logic.DeleteUser(ctx, 1),
+		queryErr,
+		listErr,
+		historyErr,
+		exportErr,
+		logic.EraseUser(ctx, 1),
+		logic.VerifyEmail(ctx, 1, "gev_token"),
+	}
+
+	for i, err := range errs {
+		if !errors.Is(err, business.ErrAccessDenied) {
+			t.Errorf("%d: expected error '%v' got '%v'", i, business.ErrAccessDenied, err)
+		}
+	}
+
+	if len(actions) != len(errs) {
+		t.Fatalf("expected %d authorized actions got %d", len(errs), len(actions))
+	}
+}
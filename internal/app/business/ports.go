@@ -11,22 +11,90 @@ type (
 	UserCases interface {
 		CreateUser(context.Context, *User) error
 		UpdateUser(context.Context, *User) error
+		DeleteUser(context.Context, UserID) error
+		// QueryUser returns the stored User, the adapters compute its age from the BirthDate when they respond it
 		QueryUser(context.Context, UserID) (User, error)
+		ListUsers(context.Context, UsersPage) ([]User, error)
+		// VerifyEmail verifies the email of a user with the EmailVerificationToken sent to it and emits UserEmailVerified
+		VerifyEmail(context.Context, UserID, EmailVerificationToken) error
+		// QueryUserHistory returns the AuditEntry(s) of the mutations of a user
+		QueryUserHistory(context.Context, HistoryPage) ([]AuditEntry, error)
+		// ExportUser returns everything stored about a user (data subject access request)
+		ExportUser(context.Context, UserID) (UserExport, error)
+		// EraseUser irreversibly anonymizes a user and emits UserErased (data subject erasure request)
+		EraseUser(context.Context, UserID) error
 	}
 
-	// MessagesRelay defines a way to relay Message(s)
+	// APIKeyCases defines business cases related to the API keys of the service-to-service callers
+	APIKeyCases interface {
+		// IssueAPIKey stores the APIKey and returns its secret, the secret is not recoverable afterward
+		IssueAPIKey(context.Context, *APIKey) (APIKeySecret, error)
+		RevokeAPIKey(context.Context, APIKeyID) error
+		// AuthenticateAPIKey returns the Principal of the APIKey identified by the secret
+		AuthenticateAPIKey(context.Context, APIKeySecret) (Principal, error)
+	}
+
+	// MessagesRelay defines a way to relay Message(s) and to know the state of the relay
 	MessagesRelay interface {
 		RelayMessages(context.Context) error
+		RelayStats(context.Context) (RelayStats, error)
 	}
 )
 
 // Ports for driven adapters
 type (
-	// UserStore defines business cases related to user operations
+	// UserStore defines business cases related to user operations, every mutation records an AuditEntry
+	// (see NewAuditEntry) in the same transaction
 	UserStore interface {
-		CreateUser(context.Context, *User) error
-		UpdateUser(context.Context, *User) error
+		// CreateUser stores the User along its pending EmailVerification
+		CreateUser(context.Context, *User, OutboxFunc) error
+		// UpdateUser locks the stored User and completes the update with the UpdateFunc before replacing it
+		UpdateUser(context.Context, *User, UpdateFunc, OutboxFunc) error
+		// VerifyEmail verifies the email of the User whose pending EmailVerification has the given hash and has
+		// not expired, the EmailVerification is used only once
+		VerifyEmail(context.Context, UserID, []byte, OutboxFunc) error
+		DeleteUser(context.Context, UserID, OutboxFunc) error
 		QueryUser(context.Context, UserID) (User, error)
+		QueryUsers(context.Context, UsersPage) ([]User, error)
+		QueryUserHistory(context.Context, HistoryPage) ([]AuditEntry, error)
+		// ExportUser returns the User (even if it is deleted), its AuditEntry(s) and its outbox Message(s) except the
+		// UserEmailVerificationRequested
+		ExportUser(context.Context, UserID) (UserExport, error)
+		// EraseUser anonymizes the User and its AuditEntry(s), scrubs its Message(s) (delivered or not) and stores the
+		// Message(s) of the erasure
+		EraseUser(context.Context, UserID, ScrubFunc, OutboxFunc) error
+	}
+
+	// APIKeyStore defines the persistence of the API keys, only their hashes are stored
+	APIKeyStore interface {
+		CreateAPIKey(context.Context, *APIKey) error
+		RevokeAPIKey(context.Context, APIKeyID) error
+		// UseAPIKey returns the active APIKey whose Hash is the given hash and records its use
+		UseAPIKey(context.Context, []byte) (APIKey, error)
+	}
+
+	// KeyRotator defines a way to re-wrap the data keys of the stored PII with the primary master key,
+	// it returns the number of re-wrapped records
+	KeyRotator interface {
+		RotateKeys(context.Context) (int, error)
+	}
+
+	// EmailIndexer defines a way to recompute the blind index of the stored emails after the normalization of the index
+	// changes, it returns the number of re-indexed records
+	EmailIndexer interface {
+		IndexEmails(context.Context) (int, error)
+	}
+
+	// UserPolicy decides if the Principal of the context is allowed to perform an Action on the user identified by UserID
+	UserPolicy interface {
+		AuthorizeUser(context.Context, Action, UserID) error
+	}
+
+	// EventEncoder defines a way to encode an Event as a Message
+	EventEncoder interface {
+		EncodeEvent(Event) (Message, error)
+		// ScrubMessage removes the personal data of a Message encoded by EncodeEvent (see ScrubFunc)
+		ScrubMessage(Message) (Message, error)
 	}
 
 	// MessagesReader defines a way to read the pending Message(s)
@@ -35,6 +103,11 @@ type (
 		ReadMessages(context.Context, []Message) (int, error)
 	}
 
+	// MessagesBacklogReader defines a way to read the Backlog of pending Message(s)
+	MessagesBacklogReader interface {
+		ReadBacklog(context.Context) (Backlog, error)
+	}
+
 	// MessageSender defines a way to send a Message
 	MessageSender interface {
 		SendMessage(context.Context, ...Message) error
@@ -44,4 +117,15 @@ type (
 	MessageDeliveryConfirmer interface {
 		ConfirmMessageDelivery(context.Context, ...Message) error
 	}
+
+	// LeaderElector defines a way to elect a single leader among several replicas
+	LeaderElector interface {
+		// Campaign blocks until the leadership is acquired, the returned context is canceled when the leadership is lost
+		// (with the cause ErrLeadershipLost).
+		// The leadership is held until resign is called (even if the context is canceled), so the work of the term
+		// finishes before another replica is elected
+		Campaign(context.Context) (leaderCtx context.Context, resign func(), err error)
+		// IsLeader indicates if the current replica holds the leadership
+		IsLeader() bool
+	}
 )
@@ -0,0 +1,16 @@
+package business
+
+import "time"
+
+// UserExport is everything stored about a User, it answers a data subject access request
+type UserExport struct {
+	User User
+	// DeletedAt and ErasedAt are zero unless the User is deleted or erased
+	DeletedAt time.Time
+	ErasedAt  time.Time
+	History   []AuditEntry
+	// Messages are the outbox Message(s) of the User, delivered or not, except the UserEmailVerificationRequested
+	// (its token is a credential)
+	Messages   []Message
+	ExportedAt time.Time
+}
@@ -6,14 +6,19 @@ import (
 	"context"
 	"errors"
 	"log/slog"
+	"sync/atomic"
 	"time"
 )
 
 type MessagesRelayConfig struct {
 	Confirmer MessageDeliveryConfirmer
 	Reader    MessagesReader
+	Backlog   MessagesBacklogReader
 	Sender    MessageSender
 	Logger    *slog.Logger
+	// GracePeriod is the time that an in-flight batch has to be sent and confirmed after the relay is stopped, if the
+	// leadership is lost (see ErrLeadershipLost) the batch is not sent anymore, it only has the GracePeriod to be confirmed
+	GracePeriod time.Duration
 }
 
 func (m MessagesRelayConfig) Validate() error {
@@ -27,6 +32,10 @@ func (m MessagesRelayConfig) Validate() error {
 		return err
 	}
 
+	if m.Backlog == nil {
+		return err
+	}
+
 	if m.Sender == nil {
 		return err
 	}
@@ -35,6 +44,10 @@ func (m MessagesRelayConfig) Validate() error {
 		return err
 	}
 
+	if m.GracePeriod <= 0 {
+		return errors.New("grace period must be greater than zero")
+	}
+
 	return nil
 }
 
@@ -44,18 +57,24 @@ func NewMessagesRelay(config MessagesRelayConfig) (MessagesRelay, error) {
 	}
 
 	return &messagesRelay{
-		confirmer: config.Confirmer,
-		reader:    config.Reader,
-		sender:    config.Sender,
-		logger:    config.Logger,
+		confirmer:   config.Confirmer,
+		reader:      config.Reader,
+		backlog:     config.Backlog,
+		sender:      config.Sender,
+		logger:      config.Logger,
+		gracePeriod: config.GracePeriod,
 	}, nil
 }
 
 type messagesRelay struct {
-	confirmer MessageDeliveryConfirmer
-	reader    MessagesReader
-	sender    MessageSender
-	logger    *slog.Logger
+	confirmer   MessageDeliveryConfirmer
+	reader      MessagesReader
+	backlog     MessagesBacklogReader
+	sender      MessageSender
+	logger      *slog.Logger
+	gracePeriod time.Duration
+	// lastBatchAt is the unix time in nanoseconds of the last confirmed batch
+	lastBatchAt atomic.Int64
 }
 
 func (m *messagesRelay) RelayMessages(ctx context.Context) (err error) {
@@ -65,8 +84,18 @@ func (m *messagesRelay) RelayMessages(ctx context.Context) (err error) {
 	messages := make([]Message, messageLimit)
 
 	for {
+		// Stop reading new batches once the relay is stopped
+		if ctx.Err() != nil {
+			m.logger.InfoContext(ctx, "stopped_message_relay")
+			return m.reader.Close()
+		}
+
 		length, err = m.reader.ReadMessages(ctx, messages)
 		if err != nil {
+			if ctx.Err() != nil {
+				continue
+			}
+
 			return
 		}
 
@@ -76,7 +105,7 @@ func (m *messagesRelay) RelayMessages(ctx context.Context) (err error) {
 
 			select {
 			case <-ctx.Done():
-				return errors.Join(err, m.reader.Close())
+				continue
 			case <-time.After(retryDelay): // TODO: explore alternative solutions to wait
 				continue
 			}
@@ -85,15 +114,38 @@ func (m *messagesRelay) RelayMessages(ctx context.Context) (err error) {
 		// Relaying messages...
 		err = m.relayMessages(ctx, messages[:length])
 		if err != nil && !errors.Is(err, ErrUnableToDeliverMessages) {
+			// The batch interrupted by the loss of the leadership is relayed by the next leader
+			if errors.Is(context.Cause(ctx), ErrLeadershipLost) {
+				continue
+			}
+
 			return
 		}
 	}
 }
 
 func (m *messagesRelay) relayMessages(ctx context.Context, messages []Message) (err error) {
+	leaderCtx := ctx
+
+	// The in-flight batch must not be interrupted by the stop signal, it only has a grace period to finish
+	ctx, cancel := m.batchContext(leaderCtx)
+	defer cancel()
+
+	// Once the leadership is lost another replica can read the same messages, so the sending is interrupted right
+	// away and only the messages already sent are confirmed
+	sendCtx, cancelSend := context.WithCancelCause(ctx)
+	defer cancelSend(nil)
+
+	stop := context.AfterFunc(leaderCtx, func() {
+		if cause := context.Cause(leaderCtx); errors.Is(cause, ErrLeadershipLost) {
+			cancelSend(cause)
+		}
+	})
+	defer stop()
+
 	m.logger.InfoContext(ctx, "relaying_messages", "messages", len(messages))
 
-	err = m.sender.SendMessage(ctx, messages...)
+	err = m.sender.SendMessage(sendCtx, messages...)
 	if err != nil {
 		m.logger.InfoContext(ctx, "failed_sent_messages", "error", err)
 		return
@@ -107,6 +159,44 @@ func (m *messagesRelay) relayMessages(ctx context.Context, messages []Message) (
 		return
 	}
 
+	m.lastBatchAt.Store(time.Now().UnixNano())
+
 	m.logger.InfoContext(ctx, "confirmed_messages", "messages", len(messages))
 	return
 }
+
+func (m *messagesRelay) RelayStats(ctx context.Context) (stats RelayStats, err error) {
+	stats.Backlog, err = m.backlog.ReadBacklog(ctx)
+	if err != nil {
+		return
+	}
+
+	if lastBatchAt := m.lastBatchAt.Load(); lastBatchAt > 0 {
+		stats.LastBatchAt = time.Unix(0, lastBatchAt)
+	}
+
+	return
+}
+
+// batchContext builds a context that outlives the cancellation of ctx for at most the grace period
+func (m *messagesRelay) batchContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	batchCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+
+	go func() {
+		select {
+		case <-batchCtx.Done():
+			return
+		case <-ctx.Done():
+		}
+
+		m.logger.InfoContext(batchCtx, "finishing_in_flight_batch", "grace_period", m.gracePeriod)
+
+		select {
+		case <-batchCtx.Done():
+		case <-time.After(m.gracePeriod):
+			cancel()
+		}
+	}()
+
+	return batchCtx, cancel
+}
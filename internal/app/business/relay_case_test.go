@@ -1 +1,208 @@
-package business
+//go:build relay
+
+package business_test
+
+import (
+	"context"
+	"errors"
+	"github.com/yael-castro/goarch/internal/app/business"
+	"github.com/yael-castro/goarch/internal/app/business/mock"
+	"io"
+	"log/slog"
+	"os/signal"
+	"strconv"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestMessagesRelay_RelayMessages(t *testing.T) {
+	cases := [...]struct {
+		gracePeriod       time.Duration
+		sendDuration      time.Duration
+		expectedErr       error
+		expectedConfirmed bool
+	}{
+		// Test case: SIGTERM mid-batch, the in-flight batch is sent and confirmed within the grace period
+		{
+			gracePeriod:       time.Second,
+			sendDuration:      50 * time.Millisecond,
+			expectedConfirmed: true,
+		},
+		// Test case: SIGTERM mid-batch, the in-flight batch exceeds the grace period
+		{
+			gracePeriod:  50 * time.Millisecond,
+			sendDuration: time.Second,
+			expectedErr:  context.Canceled,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM)
+			defer stop()
+
+			var reads, confirmations atomic.Int32
+
+			reader := mock.MessagesReader(func(_ context.Context, messages []business.Message) (int, error) {
+				reads.Add(1)
+				messages[0] = business.Message{ID: 1}
+				return 1, nil
+			})
+
+			sender := mock.MessageSender(func(batchCtx context.Context, _ ...business.Message) error {
+				// Simulating SIGTERM while the batch is in-flight
+				if err := syscall.Kill(syscall.Getpid(), syscall.SIGTERM); err != nil {
+					return err
+				}
+
+				<-ctx.Done()
+
+				select {
+				case <-batchCtx.Done():
+					return batchCtx.Err()
+				case <-time.After(c.sendDuration):
+					return nil
+				}
+			})
+
+			confirmer := mock.MessageDeliveryConfirmer(func(context.Context, ...business.Message) error {
+				confirmations.Add(1)
+				return nil
+			})
+
+			backlog := mock.MessagesBacklogReader(func(context.Context) (business.Backlog, error) {
+				return business.Backlog{}, nil
+			})
+
+			relay, err := business.NewMessagesRelay(business.MessagesRelayConfig{
+				Reader:      reader,
+				Backlog:     backlog,
+				Sender:      sender,
+				Confirmer:   confirmer,
+				Logger:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+				GracePeriod: c.gracePeriod,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = relay.RelayMessages(ctx)
+			if !errors.Is(err, c.expectedErr) {
+				t.Fatalf("expected error '%v' got '%v'", c.expectedErr, err)
+			}
+
+			if reads.Load() != 1 {
+				t.Fatalf("expected 1 batch read got %d", reads.Load())
+			}
+
+			if confirmed := confirmations.Load() == 1; confirmed != c.expectedConfirmed {
+				t.Fatalf("expected confirmed '%v' got '%v'", c.expectedConfirmed, confirmed)
+			}
+
+			stats, err := relay.RelayStats(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if recorded := !stats.LastBatchAt.IsZero(); recorded != c.expectedConfirmed {
+				t.Fatalf("expected last batch recorded '%v' got '%v'", c.expectedConfirmed, recorded)
+			}
+		})
+	}
+}
+
+func TestMessagesRelay_RelayMessages_LeadershipLost(t *testing.T) {
+	cases := [...]struct {
+		// loseWhileSending loses the leadership while the batch is sent, otherwise while it is confirmed
+		loseWhileSending  bool
+		expectedConfirmed bool
+	}{
+		// Test case: leadership lost while the batch is sent, the sending is interrupted right away
+		{
+			loseWhileSending: true,
+		},
+		// Test case: leadership lost while the batch is confirmed, the messages already sent are confirmed
+		{
+			expectedConfirmed: true,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			leaderCtx, lose := context.WithCancelCause(context.Background())
+			defer lose(nil)
+
+			var confirmations atomic.Int32
+
+			reader := mock.MessagesReader(func(_ context.Context, messages []business.Message) (int, error) {
+				messages[0] = business.Message{ID: 1}
+				return 1, nil
+			})
+
+			sender := mock.MessageSender(func(sendCtx context.Context, _ ...business.Message) error {
+				if !c.loseWhileSending {
+					return nil
+				}
+
+				lose(business.ErrLeadershipLost)
+
+				select {
+				case <-sendCtx.Done():
+					return context.Cause(sendCtx)
+				case <-time.After(time.Second):
+					return nil
+				}
+			})
+
+			confirmer := mock.MessageDeliveryConfirmer(func(batchCtx context.Context, _ ...business.Message) error {
+				if !c.loseWhileSending {
+					lose(business.ErrLeadershipLost)
+				}
+
+				// The confirmation has the grace period even if the leadership is lost
+				select {
+				case <-batchCtx.Done():
+					return batchCtx.Err()
+				case <-time.After(50 * time.Millisecond):
+				}
+
+				confirmations.Add(1)
+				return nil
+			})
+
+			backlog := mock.MessagesBacklogReader(func(context.Context) (business.Backlog, error) {
+				return business.Backlog{}, nil
+			})
+
+			relay, err := business.NewMessagesRelay(business.MessagesRelayConfig{
+				Reader:      reader,
+				Backlog:     backlog,
+				Sender:      sender,
+				Confirmer:   confirmer,
+				Logger:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+				GracePeriod: time.Second,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// The relay stops without error, the next leader relays the messages that are not confirmed
+			start := time.Now()
+
+			err = relay.RelayMessages(leaderCtx)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if elapsed := time.Since(start); elapsed >= time.Second {
+				t.Fatalf("expected the batch to be interrupted got %v", elapsed)
+			}
+
+			if confirmed := confirmations.Load() == 1; confirmed != c.expectedConfirmed {
+				t.Fatalf("expected confirmed '%v' got '%v'", c.expectedConfirmed, confirmed)
+			}
+		})
+	}
+}
@@ -3,42 +3,266 @@ package business
 import (
 	"context"
 	"errors"
+	"strings"
+	"time"
 )
 
-func NewUserCases(store UserStore) (UserCases, error) {
-	if store == nil {
-		return nil, errors.New("store is nil")
+func NewUserCases(store UserStore, encoder EventEncoder, policy UserPolicy, emails EmailRules, birthDates BirthDateRules) (UserCases, error) {
+	if store == nil || encoder == nil || policy == nil {
+		return nil, errors.New("some dependencies are nil")
 	}
 
 	return userCases{
-		store: store,
+		store:   store,
+		encoder: encoder,
+		policy:  policy,
+		emails:  emails,
+
+		birthDates: birthDates,
 	}, nil
 }
 
 type userCases struct {
-	store UserStore
+	store   UserStore
+	encoder EventEncoder
+	policy  UserPolicy
+	emails  EmailRules
+
+	birthDates BirthDateRules
 }
 
-func (p userCases) CreateUser(ctx context.Context, user *User) error {
-	if err := user.Validate(); err != nil {
-		return err
+func (p userCases) CreateUser(ctx context.Context, user *User) (err error) {
+	if err = p.policy.AuthorizeUser(ctx, ActionCreateUser, 0); err != nil {
+		return
+	}
+
+	if err = p.validateUser(user); err != nil {
+		return
+	}
+
+	token, err := p.requestEmailVerification(user)
+	if err != nil {
+		return
+	}
+
+	return p.store.CreateUser(ctx, user, func(user User) ([]Message, error) {
+		now := time.Now()
+
+		return p.encode(
+			UserCreated{
+				User: user,
+				Time: now,
+			},
+			UserEmailVerificationRequested{
+				UserID:    user.ID,
+				Email:     user.Email,
+				Token:     token,
+				ExpiresAt: user.EmailVerification.ExpiresAt,
+				Time:      now,
+			},
+		)
+	})
+}
+
+func (p userCases) UpdateUser(ctx context.Context, user *User) (err error) {
+	if err = p.policy.AuthorizeUser(ctx, ActionUpdateUser, user.ID); err != nil {
+		return
+	}
+
+	if err = p.validateUser(user); err != nil {
+		return
+	}
+
+	verification, token, err := NewEmailVerification()
+	if err != nil {
+		return
+	}
+
+	update := func(before User, user *User) {
+		// The email is verified again only if it changes (regardless of its case, like its uniqueness)
+		if strings.EqualFold(before.Email.String(), user.Email.String()) {
+			user.EmailVerifiedAt, user.EmailVerification = before.EmailVerifiedAt, EmailVerification{}
+			return
+		}
+
+		user.EmailVerifiedAt, user.EmailVerification = time.Time{}, verification
+	}
+
+	return p.store.UpdateUser(ctx, user, update, func(user User) ([]Message, error) {
+		now := time.Now()
+
+		events := []Event{
+			UserUpdated{
+				User: user,
+				Time: now,
+			},
+		}
+
+		// The verification is requested only if the email changed
+		if !user.EmailVerification.IsZero() {
+			events = append(events, UserEmailVerificationRequested{
+				UserID:    user.ID,
+				Email:     user.Email,
+				Token:     token,
+				ExpiresAt: user.EmailVerification.ExpiresAt,
+				Time:      now,
+			})
+		}
+
+		return p.encode(events...)
+	})
+}
+
+// requestEmailVerification issues the EmailVerification of the email of the user and returns its token
+func (p userCases) requestEmailVerification(user *User) (EmailVerificationToken, error) {
+	verification, token, err := NewEmailVerification()
+	if err != nil {
+		return "", err
+	}
+
+	user.EmailVerification = verification
+	return token, nil
+}
+
+func (p userCases) VerifyEmail(ctx context.Context, id UserID, token EmailVerificationToken) (err error) {
+	if err = p.policy.AuthorizeUser(ctx, ActionVerifyEmail, id); err != nil {
+		return
+	}
+
+	var violations ValidationErrors
+
+	violations.Add("id", id.Validate())
+	violations.Add("token", token.Validate())
+
+	if err = violations.Err(); err != nil {
+		return
+	}
+
+	return p.store.VerifyEmail(ctx, id, token.Hash(), func(user User) ([]Message, error) {
+		return p.encode(UserEmailVerified{
+			UserID: user.ID,
+			Email:  user.Email,
+			Time:   user.EmailVerifiedAt,
+		})
+	})
+}
+
+func (p userCases) DeleteUser(ctx context.Context, id UserID) (err error) {
+	if err = p.policy.AuthorizeUser(ctx, ActionDeleteUser, id); err != nil {
+		return
 	}
 
-	return p.store.CreateUser(ctx, user)
+	if err = id.Validate(); err != nil {
+		return
+	}
+
+	return p.store.DeleteUser(ctx, id, func(user User) ([]Message, error) {
+		return p.encode(UserDeleted{
+			UserID: user.ID,
+			Time:   time.Now(),
+		})
+	})
 }
 
-func (p userCases) UpdateUser(ctx context.Context, user *User) error {
+// validateUser normalizes the email of the user before validating it, then it applies the email and birth date rules
+func (p userCases) validateUser(user *User) error {
+	user.Email = p.emails.Normalize(user.Email)
+
 	if err := user.Validate(); err != nil {
 		return err
 	}
 
-	return p.store.UpdateUser(ctx, user)
+	var violations ValidationErrors
+
+	violations.Add("email", p.emails.Validate(user.Email))
+	violations.Add("birth_date", p.birthDates.Validate(user.BirthDate))
+
+	return violations.Err()
 }
 
-func (p userCases) QueryUser(ctx context.Context, id UserID) (User, error) {
-	if err := id.Validate(); err != nil {
-		return User{}, err
+func (p userCases) QueryUser(ctx context.Context, id UserID) (user User, err error) {
+	if err = p.policy.AuthorizeUser(ctx, ActionQueryUser, id); err != nil {
+		return
+	}
+
+	if err = id.Validate(); err != nil {
+		return
 	}
 
 	return p.store.QueryUser(ctx, id)
 }
+
+func (p userCases) ListUsers(ctx context.Context, page UsersPage) (users []User, err error) {
+	if err = p.policy.AuthorizeUser(ctx, ActionListUsers, 0); err != nil {
+		return
+	}
+
+	if err = page.Validate(); err != nil {
+		return
+	}
+
+	return p.store.QueryUsers(ctx, page)
+}
+
+func (p userCases) QueryUserHistory(ctx context.Context, page HistoryPage) (entries []AuditEntry, err error) {
+	if err = p.policy.AuthorizeUser(ctx, ActionQueryUserHistory, page.UserID); err != nil {
+		return
+	}
+
+	if err = page.Validate(); err != nil {
+		return
+	}
+
+	return p.store.QueryUserHistory(ctx, page)
+}
+
+func (p userCases) ExportUser(ctx context.Context, id UserID) (export UserExport, err error) {
+	if err = p.policy.AuthorizeUser(ctx, ActionExportUser, id); err != nil {
+		return
+	}
+
+	if err = id.Validate(); err != nil {
+		return
+	}
+
+	export, err = p.store.ExportUser(ctx, id)
+	if err != nil {
+		return
+	}
+
+	export.ExportedAt = time.Now()
+	return
+}
+
+func (p userCases) EraseUser(ctx context.Context, id UserID) (err error) {
+	if err = p.policy.AuthorizeUser(ctx, ActionEraseUser, id); err != nil {
+		return
+	}
+
+	if err = id.Validate(); err != nil {
+		return
+	}
+
+	return p.store.EraseUser(ctx, id, p.encoder.ScrubMessage, func(user User) ([]Message, error) {
+		return p.encode(UserErased{
+			UserID: user.ID,
+			Time:   time.Now(),
+		})
+	})
+}
+
+// encode encodes the events as outbox Message(s)
+func (p userCases) encode(events ...Event) ([]Message, error) {
+	messages := make([]Message, len(events))
+
+	for i, event := range events {
+		message, err := p.encoder.EncodeEvent(event)
+		if err != nil {
+			return nil, err
+		}
+
+		messages[i] = message
+	}
+
+	return messages, nil
+}
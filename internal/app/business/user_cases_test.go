@@ -8,6 +8,7 @@ import (
 	"reflect"
 	"strconv"
 	"testing"
+	"time"
 )
 
 func TestUserCases_CreateUser(t *testing.T) {
@@ -30,29 +31,29 @@ func TestUserCases_CreateUser(t *testing.T) {
 			},
 			expectedErr: business.ErrInvalidUserName,
 		},
-		// Test case: user can't be a baby
+		// Test case: missing birth date
 		{
 			ctx: context.Background(),
 			user: &business.User{
 				Name: "Yael",
 			},
-			expectedErr: business.ErrInvalidUserAge,
+			expectedErr: business.ErrInvalidUserBirthDate,
 		},
-		// Test case: user is not alive
+		// Test case: birth date in the future
 		{
 			ctx: context.Background(),
 			user: &business.User{
-				Name: "Yael",
-				Age:  120,
+				Name:      "Yael",
+				BirthDate: "2999-01-01",
 			},
-			expectedErr: business.ErrInvalidUserAge,
+			expectedErr: business.ErrInvalidUserBirthDate,
 		},
 		// Test case: missing email
 		{
 			ctx: context.Background(),
 			user: &business.User{
-				Name: "Yael",
-				Age:  23,
+				Name:      "Yael",
+				BirthDate: "2001-07-14",
 			},
 			expectedErr: business.ErrInvalidUserEmail,
 		},
@@ -60,14 +61,14 @@ func TestUserCases_CreateUser(t *testing.T) {
 		{
 			ctx: context.Background(),
 			user: &business.User{
-				Name:  "Yael",
-				Age:   23,
-				Email: "contacto@yael.mx",
+				Name:      "Yael",
+				Email:     "contacto@yael.mx",
+				BirthDate: "2001-07-14",
 			},
 		},
 	}
 
-	logic, err := business.NewUserCases(mock.UserStore{})
+	logic, err := business.NewUserCases(mock.UserStore{}, mock.EventEncoder{}, mock.UserPolicy(nil), business.EmailRules{}, business.BirthDateRules{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -109,29 +110,29 @@ func TestUserCases_UpdateUser(t *testing.T) {
 			},
 			expectedErr: business.ErrInvalidUserName,
 		},
-		// Test case: user can't be a baby
+		// Test case: missing birth date
 		{
 			ctx: context.Background(),
 			user: &business.User{
 				Name: "Yael",
 			},
-			expectedErr: business.ErrInvalidUserAge,
+			expectedErr: business.ErrInvalidUserBirthDate,
 		},
-		// Test case: user is not alive
+		// Test case: birth date in the future
 		{
 			ctx: context.Background(),
 			user: &business.User{
-				Name: "Yael",
-				Age:  120,
+				Name:      "Yael",
+				BirthDate: "2999-01-01",
 			},
-			expectedErr: business.ErrInvalidUserAge,
+			expectedErr: business.ErrInvalidUserBirthDate,
 		},
 		// Test case: missing email
 		{
 			ctx: context.Background(),
 			user: &business.User{
-				Name: "Yael",
-				Age:  23,
+				Name:      "Yael",
+				BirthDate: "2001-07-14",
 			},
 			expectedErr: business.ErrInvalidUserEmail,
 		},
@@ -139,14 +140,14 @@ func TestUserCases_UpdateUser(t *testing.T) {
 		{
 			ctx: context.Background(),
 			user: &business.User{
-				Name:  "Yael",
-				Age:   23,
-				Email: "contacto@yael.mx",
+				Name:      "Yael",
+				Email:     "contacto@yael.mx",
+				BirthDate: "2001-07-14",
 			},
 		},
 	}
 
-	logic, err := business.NewUserCases(mock.UserStore{})
+	logic, err := business.NewUserCases(mock.UserStore{}, mock.EventEncoder{}, mock.UserPolicy(nil), business.EmailRules{}, business.BirthDateRules{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -188,7 +189,7 @@ func TestUserCases_QueryUser(t *testing.T) {
 		},
 	}
 
-	logic, err := business.NewUserCases(mock.UserStore{})
+	logic, err := business.NewUserCases(mock.UserStore{}, mock.EventEncoder{}, mock.UserPolicy(nil), business.EmailRules{}, business.BirthDateRules{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -213,3 +214,341 @@ func TestUserCases_QueryUser(t *testing.T) {
 		})
 	}
 }
+
+func TestUserCases_DeleteUser(t *testing.T) {
+	cases := [...]struct {
+		ctx         context.Context
+		userID      business.UserID
+		expectedErr error
+	}{
+		// Test case: invalid user id
+		{
+			ctx:         context.Background(),
+			userID:      0,
+			expectedErr: business.ErrInvalidUserID,
+		},
+		// Test case: success
+		{
+			ctx:    context.Background(),
+			userID: 1,
+		},
+	}
+
+	logic, err := business.NewUserCases(mock.UserStore{}, mock.EventEncoder{}, mock.UserPolicy(nil), business.EmailRules{}, business.BirthDateRules{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			err := logic.DeleteUser(c.ctx, c.userID)
+			if !errors.Is(err, c.expectedErr) {
+				t.Fatal(err)
+			}
+
+			if err != nil {
+				t.Log(err)
+			}
+		})
+	}
+}
+
+func TestUserCases_ListUsers(t *testing.T) {
+	cases := [...]struct {
+		ctx         context.Context
+		page        business.UsersPage
+		expectedErr error
+	}{
+		// Test case: empty page
+		{
+			ctx:         context.Background(),
+			expectedErr: business.ErrInvalidPageSize,
+		},
+		// Test case: page too big
+		{
+			ctx:         context.Background(),
+			page:        business.UsersPage{Size: 101},
+			expectedErr: business.ErrInvalidPageSize,
+		},
+		// Test case: success
+		{
+			ctx:  context.Background(),
+			page: business.UsersPage{AfterID: 1, Size: 20},
+		},
+	}
+
+	logic, err := business.NewUserCases(mock.UserStore{}, mock.EventEncoder{}, mock.UserPolicy(nil), business.EmailRules{}, business.BirthDateRules{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			_, err := logic.ListUsers(c.ctx, c.page)
+			if !errors.Is(err, c.expectedErr) {
+				t.Fatal(err)
+			}
+
+			if err != nil {
+				t.Log(err)
+			}
+		})
+	}
+}
+
+func TestUserCases_Events(t *testing.T) {
+	validUser := business.User{
+		ID:        1,
+		Name:      "Yael",
+		BirthDate: "2001-07-14",
+		Email:     "contacto@yael.mx",
+	}
+
+	cases := [...]struct {
+		do             func(context.Context, business.UserCases) error
+		expectedEvents []business.Event
+	}{
+		// Test case: user created, its email must be verified
+		{
+			do: func(ctx context.Context, cases business.UserCases) error {
+				user := validUser
+				return cases.CreateUser(ctx, &user)
+			},
+			expectedEvents: []business.Event{
+				business.UserCreated{User: validUser},
+				business.UserEmailVerificationRequested{UserID: validUser.ID, Email: validUser.Email},
+			},
+		},
+		// Test case: user updated without changing its email, the email is not verified again
+		{
+			do: func(ctx context.Context, cases business.UserCases) error {
+				user := validUser
+				user.Name = "Yael Castro"
+				return cases.UpdateUser(ctx, &user)
+			},
+			expectedEvents: []business.Event{
+				business.UserUpdated{User: business.User{ID: validUser.ID, Name: "Yael Castro", BirthDate: validUser.BirthDate, Email: validUser.Email}},
+			},
+		},
+		// Test case: user updated changing only the case of its email, the email is not verified again
+		{
+			do: func(ctx context.Context, cases business.UserCases) error {
+				user := validUser
+				user.Email = "Contacto@yael.mx"
+				return cases.UpdateUser(ctx, &user)
+			},
+			expectedEvents: []business.Event{
+				business.UserUpdated{User: business.User{ID: validUser.ID, Name: validUser.Name, BirthDate: validUser.BirthDate, Email: "Contacto@yael.mx"}},
+			},
+		},
+		// Test case: user updated, its new email must be verified
+		{
+			do: func(ctx context.Context, cases business.UserCases) error {
+				user := validUser
+				user.Email = "yael@example.com"
+				return cases.UpdateUser(ctx, &user)
+			},
+			expectedEvents: []business.Event{
+				business.UserUpdated{User: business.User{ID: validUser.ID, Name: validUser.Name, BirthDate: validUser.BirthDate, Email: "yael@example.com"}},
+				business.UserEmailVerificationRequested{UserID: validUser.ID, Email: "yael@example.com"},
+			},
+		},
+		// Test case: user deleted
+		{
+			do: func(ctx context.Context, cases business.UserCases) error {
+				return cases.DeleteUser(ctx, validUser.ID)
+			},
+			expectedEvents: []business.Event{business.UserDeleted{UserID: validUser.ID}},
+		},
+		// Test case: user erased
+		{
+			do: func(ctx context.Context, cases business.UserCases) error {
+				return cases.EraseUser(ctx, validUser.ID)
+			},
+			expectedEvents: []business.Event{business.UserErased{UserID: validUser.ID}},
+		},
+		// Test case: invalid user, nothing is emitted
+		{
+			do: func(ctx context.Context, cases business.UserCases) error {
+				_ = cases.CreateUser(ctx, &business.User{})
+				return nil
+			},
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			var (
+				events   []business.Event
+				messages []business.Message
+			)
+
+			// The stored user is validUser
+			store := mock.UserStore{Outbox: &messages, Current: &validUser}
+
+			logic, err := business.NewUserCases(store, mock.EventEncoder{Events: &events}, mock.UserPolicy(nil), business.EmailRules{}, business.BirthDateRules{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = c.do(context.Background(), logic)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(events) != len(c.expectedEvents) || len(messages) != len(c.expectedEvents) {
+				t.Fatalf("expected %d events and messages got %d and %d", len(c.expectedEvents), len(events), len(messages))
+			}
+
+			for j := range events {
+				if events[j].OccurredAt().IsZero() {
+					t.Fatal("event time is not set")
+				}
+
+				event := withoutVolatileFields(events[j])
+
+				if !reflect.DeepEqual(event, c.expectedEvents[j]) {
+					t.Fatalf("expected '%+v' got '%+v'", c.expectedEvents[j], event)
+				}
+			}
+		})
+	}
+}
+
+// withoutVolatileFields returns a copy of the event without the fields that change on every run
+func withoutVolatileFields(event business.Event) business.Event {
+	e := reflect.New(reflect.TypeOf(event)).Elem()
+	e.Set(reflect.ValueOf(event))
+
+	e.FieldByName("Time").Set(reflect.ValueOf(time.Time{}))
+
+	if field := e.FieldByName("User"); field.IsValid() {
+		field.FieldByName("EmailVerification").Set(reflect.ValueOf(business.EmailVerification{}))
+	}
+
+	if field := e.FieldByName("Token"); field.IsValid() {
+		field.SetString("")
+		e.FieldByName("ExpiresAt").Set(reflect.ValueOf(time.Time{}))
+	}
+
+	return e.Interface().(business.Event)
+}
+
+func TestUserCases_VerifyEmail(t *testing.T) {
+	token, err := business.NewEmailVerificationToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := [...]struct {
+		verification business.EmailVerification
+		token        business.EmailVerificationToken
+		expectedErr  error
+	}{
+		// Test case: missing token
+		{
+			verification: business.EmailVerification{Hash: token.Hash(), ExpiresAt: time.Now().Add(time.Hour)},
+			expectedErr:  business.ErrInvalidEmailVerificationToken,
+		},
+		// Test case: token of another verification
+		{
+			verification: business.EmailVerification{Hash: token.Hash(), ExpiresAt: time.Now().Add(time.Hour)},
+			token:        "gev_other",
+			expectedErr:  business.ErrInvalidEmailVerificationToken,
+		},
+		// Test case: expired verification
+		{
+			verification: business.EmailVerification{Hash: token.Hash(), ExpiresAt: time.Now().Add(-time.Hour)},
+			token:        token,
+			expectedErr:  business.ErrInvalidEmailVerificationToken,
+		},
+		// Test case: success
+		{
+			verification: business.EmailVerification{Hash: token.Hash(), ExpiresAt: time.Now().Add(time.Hour)},
+			token:        token,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			var events []business.Event
+
+			verification := c.verification
+
+			logic, err := business.NewUserCases(mock.UserStore{Verification: &verification}, mock.EventEncoder{Events: &events}, mock.UserPolicy(nil), business.EmailRules{}, business.BirthDateRules{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = logic.VerifyEmail(context.Background(), 1, c.token)
+			if !errors.Is(err, c.expectedErr) {
+				t.Fatalf("expected error '%v' got '%v'", c.expectedErr, err)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if len(events) != 1 {
+				t.Fatalf("expected 1 event got %d", len(events))
+			}
+
+			if _, ok := events[0].(business.UserEmailVerified); !ok {
+				t.Fatalf("expected UserEmailVerified got '%T'", events[0])
+			}
+
+			// The token is used only once
+			err = logic.VerifyEmail(context.Background(), 1, c.token)
+			if !errors.Is(err, business.ErrInvalidEmailVerificationToken) {
+				t.Fatalf("expected error '%v' got '%v'", business.ErrInvalidEmailVerificationToken, err)
+			}
+		})
+	}
+}
+
+func TestUserCases_EraseUser(t *testing.T) {
+	cases := [...]struct {
+		userID      business.UserID
+		expectedErr error
+	}{
+		// Test case: invalid user id
+		{
+			expectedErr: business.ErrInvalidUserID,
+		},
+		// Test case: success
+		{
+			userID: 1,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			// Pending message of the user
+			messages := []business.Message{
+				{Topic: "user_creation", Key: []byte("1"), Value: []byte(`{"id":1,"email":"contacto@yael.mx"}`)},
+			}
+
+			logic, err := business.NewUserCases(mock.UserStore{Outbox: &messages}, mock.EventEncoder{}, mock.UserPolicy(nil), business.EmailRules{}, business.BirthDateRules{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = logic.EraseUser(context.Background(), c.userID)
+			if !errors.Is(err, c.expectedErr) {
+				t.Fatalf("expected error '%v' got '%v'", c.expectedErr, err)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if len(messages) != 2 {
+				t.Fatalf("expected the message of the erasure got %d messages", len(messages))
+			}
+
+			if messages[0].Value != nil {
+				t.Fatalf("expected the pending message to be scrubbed got '%s'", messages[0].Value)
+			}
+		})
+	}
+}
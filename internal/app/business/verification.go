@@ -0,0 +1,93 @@
+package business
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// emailVerificationTTL is the time to verify an email since its EmailVerificationToken is issued
+const emailVerificationTTL = 24 * time.Hour
+
+// EmailVerification is the pending verification of the email of a User, only the hash of its token is stored
+type EmailVerification struct {
+	// Hash is the SHA-256 of the EmailVerificationToken
+	Hash      []byte
+	ExpiresAt time.Time
+}
+
+// NewEmailVerification issues the EmailVerificationToken of a new EmailVerification
+func NewEmailVerification() (EmailVerification, EmailVerificationToken, error) {
+	token, err := NewEmailVerificationToken()
+	if err != nil {
+		return EmailVerification{}, "", err
+	}
+
+	return EmailVerification{
+		Hash:      token.Hash(),
+		ExpiresAt: time.Now().Add(emailVerificationTTL),
+	}, token, nil
+}
+
+// IsZero indicates that there is no pending EmailVerification
+func (e EmailVerification) IsZero() bool {
+	return len(e.Hash) == 0
+}
+
+var (
+	_ fmt.Stringer   = EmailVerificationToken("")
+	_ slog.LogValuer = EmailVerificationToken("")
+)
+
+// EmailVerificationToken proves the ownership of an email, it is sent only to the email and it is used once
+type EmailVerificationToken string
+
+// NewEmailVerificationToken generates a random EmailVerificationToken (256 bits)
+func NewEmailVerificationToken() (EmailVerificationToken, error) {
+	const (
+		tokenPrefix = "gev_"
+		tokenSize   = 32
+	)
+
+	b := make([]byte, tokenSize)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return EmailVerificationToken(tokenPrefix + base64.RawURLEncoding.EncodeToString(b)), nil
+}
+
+func (e EmailVerificationToken) Validate() error {
+	if len(e) == 0 {
+		return fmt.Errorf("%w: token is required", ErrInvalidEmailVerificationToken)
+	}
+
+	return nil
+}
+
+// Hash returns the SHA-256 of the token, the token has enough entropy to make a slow hash unnecessary
+func (e EmailVerificationToken) Hash() []byte {
+	sum := sha256.Sum256([]byte(e))
+	return sum[:]
+}
+
+// Reveal returns the value of the token, it must be used only to send the token to the email
+func (e EmailVerificationToken) Reveal() string {
+	return string(e)
+}
+
+func (e EmailVerificationToken) String() string {
+	return redacted
+}
+
+func (e EmailVerificationToken) GoString() string {
+	return redacted
+}
+
+func (e EmailVerificationToken) LogValue() slog.Value {
+	return slog.StringValue(redacted)
+}
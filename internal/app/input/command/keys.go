@@ -0,0 +1,55 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"github.com/yael-castro/goarch/internal/app/business"
+	"log/slog"
+)
+
+// reindexEmails is the argument of the command built by Keys that re-indexes the emails instead of rotating the keys
+const reindexEmails = "reindex-emails"
+
+// Keys builds the command that re-wraps the data keys of the stored PII with the primary master key, it is run after a
+// new primary key is added to the keyring. With the "reindex-emails" argument the command recomputes the blind index of
+// the stored emails instead
+func Keys(rotator business.KeyRotator, indexer business.EmailIndexer, logger *slog.Logger) (func(context.Context, ...string) int, error) {
+	if rotator == nil || indexer == nil || logger == nil {
+		return nil, errors.New("some dependencies are nil")
+	}
+
+	return func(ctx context.Context, args ...string) int {
+		if len(args) == 0 {
+			return rotateKeys(ctx, rotator, logger)
+		}
+
+		if args[0] == reindexEmails && len(args) == 1 {
+			return indexEmails(ctx, indexer, logger)
+		}
+
+		logger.ErrorContext(ctx, "invalid_arguments", "args", args, "usage", "users-keys ["+reindexEmails+"]")
+		return usageExitCode
+	}, nil
+}
+
+func rotateKeys(ctx context.Context, rotator business.KeyRotator, logger *slog.Logger) int {
+	rotated, err := rotator.RotateKeys(ctx)
+	if err != nil {
+		logger.ErrorContext(ctx, "fatal_error_key_rotation", "error", err, "records", rotated)
+		return exitCode(err)
+	}
+
+	logger.InfoContext(ctx, "key_rotation_finished", "records", rotated)
+	return successExitCode
+}
+
+func indexEmails(ctx context.Context, indexer business.EmailIndexer, logger *slog.Logger) int {
+	indexed, err := indexer.IndexEmails(ctx)
+	if err != nil {
+		logger.ErrorContext(ctx, "fatal_error_email_indexing", "error", err)
+		return exitCode(err)
+	}
+
+	logger.InfoContext(ctx, "email_indexing_finished", "records", indexed)
+	return successExitCode
+}
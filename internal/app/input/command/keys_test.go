@@ -0,0 +1,67 @@
+package command
+
+import (
+	"context"
+	"github.com/yael-castro/goarch/internal/app/business/mock"
+	"io"
+	"log/slog"
+	"strconv"
+	"testing"
+)
+
+func TestKeys(t *testing.T) {
+	cases := [...]struct {
+		args             []string
+		expectedExitCode int
+		expectedRun      string
+	}{
+		// Test case: the keys are rotated without arguments
+		{
+			expectedRun: "rotate",
+		},
+		// Test case: the emails are re-indexed
+		{
+			args:        []string{"reindex-emails"},
+			expectedRun: "reindex",
+		},
+		// Test case: unknown argument
+		{
+			args:             []string{"rotate-everything"},
+			expectedExitCode: usageExitCode,
+		},
+		// Test case: extra arguments
+		{
+			args:             []string{"reindex-emails", "now"},
+			expectedExitCode: usageExitCode,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			var run string
+
+			rotator := mock.KeyRotator(func(context.Context) (int, error) {
+				run = "rotate"
+				return 0, nil
+			})
+
+			indexer := mock.EmailIndexer(func(context.Context) (int, error) {
+				run = "reindex"
+				return 0, nil
+			})
+
+			cmd, err := Keys(rotator, indexer, slog.New(slog.NewTextHandler(io.Discard, nil)))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if exitCode := cmd(context.Background(), c.args...); exitCode != c.expectedExitCode {
+				t.Fatalf("expected exit code %d got %d", c.expectedExitCode, exitCode)
+			}
+
+			if run != c.expectedRun {
+				t.Fatalf("expected run '%s' got '%s'", c.expectedRun, run)
+			}
+		})
+	}
+}
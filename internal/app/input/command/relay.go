@@ -7,9 +7,15 @@ import (
 	"log/slog"
 )
 
+// Exit codes (see sysexits.h)
 const (
-	successExitCode = 0
-	fatalExitCode   = 1
+	successExitCode     = 0
+	fatalExitCode       = 1
+	usageExitCode       = 64
+	dataErrExitCode     = 65
+	unavailableExitCode = 69
+	tempFailExitCode    = 75
+	noPermExitCode      = 77
 )
 
 // Relay builds the command for message relay
@@ -22,9 +28,33 @@ func Relay(relay business.MessagesRelay, logger *slog.Logger) (func(context.Cont
 		err := relay.RelayMessages(ctx)
 		if err != nil {
 			logger.ErrorContext(ctx, "fatal_error_message_relay", "error", err)
-			return fatalExitCode
+			return exitCode(err)
 		}
 
 		return successExitCode
 	}, nil
 }
+
+// exitCode maps the Category of a business.Error to an exit code, so the supervisor knows if it is worth restarting
+func exitCode(err error) int {
+	var userErr *business.Error
+
+	if !errors.As(err, &userErr) {
+		return fatalExitCode
+	}
+
+	if userErr.Retryable {
+		return tempFailExitCode
+	}
+
+	switch userErr.Category {
+	case business.CategoryValidation, business.CategoryConflict, business.CategoryNotFound:
+		return dataErrExitCode
+	case business.CategoryUnavailable:
+		return unavailableExitCode
+	case business.CategoryUnauthenticated, business.CategoryForbidden:
+		return noPermExitCode
+	}
+
+	return fatalExitCode
+}
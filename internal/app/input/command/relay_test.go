@@ -0,0 +1,55 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"github.com/yael-castro/goarch/internal/app/business"
+	"strconv"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	cases := [...]struct {
+		err              error
+		expectedExitCode int
+	}{
+		// Test case: retryable error
+		{
+			err:              fmt.Errorf("%w: broker down", business.ErrMessageDeliveryFailed),
+			expectedExitCode: tempFailExitCode,
+		},
+		// Test case: non-retryable unavailable error
+		{
+			err:              &business.Error{Code: "DATABASE_UNAVAILABLE", Category: business.CategoryUnavailable},
+			expectedExitCode: unavailableExitCode,
+		},
+		// Test case: invalid data
+		{
+			err:              business.ErrIncompatibleMessageSchema.With("topic", "user_creation"),
+			expectedExitCode: fatalExitCode,
+		},
+		// Test case: validation error
+		{
+			err:              business.ErrInvalidUserID,
+			expectedExitCode: dataErrExitCode,
+		},
+		// Test case: permission error
+		{
+			err:              business.ErrAccessDenied,
+			expectedExitCode: noPermExitCode,
+		},
+		// Test case: unexpected error
+		{
+			err:              errors.New("connection refused"),
+			expectedExitCode: fatalExitCode,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			if exitCode := exitCode(c.err); exitCode != c.expectedExitCode {
+				t.Fatalf("expected exit code %d got %d", c.expectedExitCode, exitCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"github.com/yael-castro/goarch/internal/app/business"
+	"github.com/yael-castro/goarch/pkg/jwtauth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Metadata keys of the credentials of the callers, the same credentials of the HTTP headers X-API-Key and Authorization
+const (
+	apiKeyKey        = "x-api-key"
+	authorizationKey = "authorization"
+)
+
+type AuthenticationConfig struct {
+	Cases    business.APIKeyCases
+	Verifier *jwtauth.Verifier
+}
+
+// Authentication builds an interceptor that authenticates the callers like the HTTP server: the API key of the
+// x-api-key metadata or the JWT of the authorization metadata (bearer token) is resolved to the business.Principal of
+// the caller. The calls without credentials carry no Principal, so the business.UserPolicy rejects them
+func Authentication(config AuthenticationConfig) (grpc.UnaryServerInterceptor, error) {
+	if config.Cases == nil || config.Verifier == nil {
+		return nil, errors.New("some dependencies are nil")
+	}
+
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if values := metadata.ValueFromIncomingContext(ctx, apiKeyKey); len(values) > 0 {
+			principal, err := config.Cases.AuthenticateAPIKey(ctx, business.APIKeySecret(values[0]))
+			if err != nil {
+				return nil, err
+			}
+
+			return handler(business.ContextWithPrincipal(ctx, principal), req)
+		}
+
+		values := metadata.ValueFromIncomingContext(ctx, authorizationKey)
+		if len(values) == 0 {
+			return handler(ctx, req)
+		}
+
+		tokenString, ok := jwtauth.BearerToken(values[0])
+		if !ok {
+			return nil, status.Error(codes.Unauthenticated, "missing bearer token")
+		}
+
+		claims, err := config.Verifier.Verify(tokenString)
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, "invalid bearer token")
+		}
+
+		subject, _ := claims.GetSubject()
+
+		principal := business.NewPrincipal(subject, jwtauth.Scopes(claims))
+
+		return handler(business.ContextWithPrincipal(ctx, principal), req)
+	}, nil
+}
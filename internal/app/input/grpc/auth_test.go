@@ -0,0 +1,152 @@
+package grpc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/yael-castro/goarch/internal/app/business"
+	"github.com/yael-castro/goarch/pkg/jwtauth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// apiKeyCases authenticates only the secret "valid"
+type apiKeyCases struct {
+	business.APIKeyCases
+}
+
+func (apiKeyCases) AuthenticateAPIKey(_ context.Context, secret business.APIKeySecret) (business.Principal, error) {
+	if secret != "valid" {
+		return business.Principal{}, business.ErrInvalidAPIKey
+	}
+
+	return business.Principal{Subject: "api_key:1", Scopes: []business.Scope{business.ScopeUsersRead}}, nil
+}
+
+func TestAuthentication(t *testing.T) {
+	const (
+		issuer   = "https://auth.example.com/"
+		audience = "users-api"
+	)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verifier, err := jwtauth.NewVerifier(jwtauth.VerifierConfig{
+		Keyfunc: func(*jwt.Token) (any, error) {
+			return &key.PublicKey, nil
+		},
+		Issuer:   issuer,
+		Audience: audience,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authentication, err := Authentication(AuthenticationConfig{
+		Cases:    apiKeyCases{},
+		Verifier: verifier,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sign := func(audience string) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+			"sub":   "1234",
+			"iss":   issuer,
+			"aud":   audience,
+			"exp":   time.Now().Add(time.Minute).Unix(),
+			"scope": "users:read users:write",
+		})
+
+		tokenString, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return "Bearer " + tokenString
+	}
+
+	cases := [...]struct {
+		md                metadata.MD
+		expectedCode      codes.Code
+		expectedPrincipal *business.Principal
+	}{
+		// Test case: without credentials the call carries no principal
+		{
+			md: metadata.MD{},
+		},
+		// Test case: valid API key
+		{
+			md: metadata.Pairs(apiKeyKey, "valid"),
+			expectedPrincipal: &business.Principal{
+				Subject: "api_key:1",
+				Scopes:  []business.Scope{business.ScopeUsersRead},
+			},
+		},
+		// Test case: unknown or revoked API key
+		{
+			md:           metadata.Pairs(apiKeyKey, "revoked"),
+			expectedCode: codes.Unauthenticated,
+		},
+		// Test case: valid token
+		{
+			md: metadata.Pairs(authorizationKey, sign(audience)),
+			expectedPrincipal: &business.Principal{
+				Subject: "1234",
+				Scopes:  []business.Scope{business.ScopeUsersRead, business.ScopeUsersWrite},
+			},
+		},
+		// Test case: token issued for another audience
+		{
+			md:           metadata.Pairs(authorizationKey, sign("orders-api")),
+			expectedCode: codes.Unauthenticated,
+		},
+		// Test case: authorization without the bearer scheme
+		{
+			md:           metadata.Pairs(authorizationKey, "Basic dXNlcjpwYXNz"),
+			expectedCode: codes.Unauthenticated,
+		},
+	}
+
+	info := &grpc.UnaryServerInfo{FullMethod: "/goarch.users.v1.UserService/GetUser"}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			ctx := metadata.NewIncomingContext(context.Background(), c.md)
+
+			var principal *business.Principal
+
+			handler := func(ctx context.Context, _ any) (any, error) {
+				if p, ok := business.PrincipalFromContext(ctx); ok {
+					principal = &p
+				}
+
+				return nil, nil
+			}
+
+			// The errors of the authentication are converted by the ErrorInterceptor like in the server
+			_, err := ErrorInterceptor()(ctx, nil, info, func(ctx context.Context, req any) (any, error) {
+				return authentication(ctx, req, info, handler)
+			})
+			if code := status.Code(err); code != c.expectedCode {
+				t.Fatalf("expected code '%v' got '%v' (%v)", c.expectedCode, code, err)
+			}
+
+			if !reflect.DeepEqual(principal, c.expectedPrincipal) {
+				t.Fatalf("expected principal '%v' got '%v'", c.expectedPrincipal, principal)
+			}
+		})
+	}
+}
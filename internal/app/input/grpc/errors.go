@@ -0,0 +1,103 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"github.com/yael-castro/goarch/internal/app/business"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+// errorDomain identifies the business errors in the details of a gRPC status
+const errorDomain = "users.goarch.yael-castro.github.com"
+
+// ErrorInterceptor converts the errors returned by the handlers into gRPC status, like http.ErrorHandler does with the HTTP status
+func ErrorInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		resp, err := handler(ctx, req)
+		if err != nil {
+			return nil, Status(err).Err()
+		}
+
+		return resp, nil
+	}
+}
+
+// Status maps err to a gRPC status, the business.Error code and metadata are included as errdetails.ErrorInfo
+func Status(err error) *status.Status {
+	if s, ok := status.FromError(err); ok {
+		return s
+	}
+
+	var userErr *business.Error
+
+	if !errors.As(err, &userErr) {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return status.New(codes.DeadlineExceeded, err.Error())
+		}
+
+		if errors.Is(err, context.Canceled) {
+			return status.New(codes.Canceled, err.Error())
+		}
+
+		return status.New(codes.Internal, "internal error")
+	}
+
+	code := errorCode(userErr)
+
+	s := status.New(code, err.Error())
+
+	details := []protoadapt.MessageV1{
+		&errdetails.ErrorInfo{
+			Reason:   userErr.Code,
+			Domain:   errorDomain,
+			Metadata: userErr.Metadata,
+		},
+	}
+
+	// Listing every invalid field
+	var violations business.ValidationErrors
+
+	if errors.As(err, &violations) {
+		badRequest := &errdetails.BadRequest{}
+
+		for _, violation := range violations {
+			badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       violation.Field,
+				Description: violation.Err.Error(),
+			})
+		}
+
+		details = append(details, badRequest)
+	}
+
+	detailed, detailsErr := s.WithDetails(details...)
+	if detailsErr != nil {
+		return s
+	}
+
+	return detailed
+}
+
+// errorCode maps the Category of a business.Error to a gRPC status code
+func errorCode(userErr *business.Error) codes.Code {
+	switch userErr.Category {
+	case business.CategoryValidation:
+		return codes.InvalidArgument
+	case business.CategoryConflict:
+		return codes.AlreadyExists
+	case business.CategoryNotFound:
+		return codes.NotFound
+	case business.CategoryUnavailable:
+		return codes.Unavailable
+	case business.CategoryUnauthenticated:
+		return codes.Unauthenticated
+	case business.CategoryForbidden:
+		return codes.PermissionDenied
+	}
+
+	return codes.Internal
+}
@@ -0,0 +1,104 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/yael-castro/goarch/internal/app/business"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"strconv"
+	"testing"
+)
+
+func TestStatus(t *testing.T) {
+	cases := [...]struct {
+		err                error
+		expectedCode       codes.Code
+		expectedReason     string
+		expectedViolations int
+	}{
+		// Test case: validation error
+		{
+			err:            fmt.Errorf("%w: name must be at least 4 characters", business.ErrInvalidUserName),
+			expectedCode:   codes.InvalidArgument,
+			expectedReason: "USER_NAME_INVALID",
+		},
+		// Test case: several invalid fields
+		{
+			err: business.ValidationErrors{
+				{Field: "name", Err: business.ErrInvalidUserName},
+				{Field: "birth_date", Err: business.ErrInvalidUserBirthDate},
+			},
+			expectedCode:       codes.InvalidArgument,
+			expectedReason:     "USER_NAME_INVALID",
+			expectedViolations: 2,
+		},
+		// Test case: duplicated email
+		{
+			err:            business.ErrDuplicateUserEmail,
+			expectedCode:   codes.AlreadyExists,
+			expectedReason: "USER_EMAIL_DUPLICATE",
+		},
+		// Test case: user not found
+		{
+			err:            fmt.Errorf("%w: user 1 not found", business.ErrUserNotFound),
+			expectedCode:   codes.NotFound,
+			expectedReason: "USER_NOT_FOUND",
+		},
+		// Test case: retryable error
+		{
+			err:            fmt.Errorf("%w: broker down", business.ErrMessageDeliveryFailed),
+			expectedCode:   codes.Unavailable,
+			expectedReason: "MESSAGE_DELIVERY_FAILED",
+		},
+		// Test case: unexpected error
+		{
+			err:          errors.New("connection refused"),
+			expectedCode: codes.Internal,
+		},
+		// Test case: canceled call
+		{
+			err:          context.Canceled,
+			expectedCode: codes.Canceled,
+		},
+		// Test case: gRPC status
+		{
+			err:          status.Error(codes.Unauthenticated, "unauthenticated"),
+			expectedCode: codes.Unauthenticated,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			s := Status(c.err)
+
+			if s.Code() != c.expectedCode {
+				t.Fatalf("expected code '%v' got '%v'", c.expectedCode, s.Code())
+			}
+
+			var (
+				reason     string
+				violations int
+			)
+
+			for _, detail := range s.Details() {
+				switch detail := detail.(type) {
+				case *errdetails.ErrorInfo:
+					reason = detail.Reason
+				case *errdetails.BadRequest:
+					violations = len(detail.FieldViolations)
+				}
+			}
+
+			if violations != c.expectedViolations {
+				t.Fatalf("expected %d violations got %d", c.expectedViolations, violations)
+			}
+
+			if reason != c.expectedReason {
+				t.Fatalf("expected reason '%s' got '%s'", c.expectedReason, reason)
+			}
+		})
+	}
+}
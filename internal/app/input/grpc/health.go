@@ -0,0 +1,29 @@
+package grpc
+
+import (
+	"context"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// NewHealthServer builds a grpc.health.v1.Health server that is serving while all the checks pass
+func NewHealthServer(checks ...func(context.Context) error) healthpb.HealthServer {
+	return healthServer{
+		checks: checks,
+	}
+}
+
+type healthServer struct {
+	healthpb.UnimplementedHealthServer
+	checks []func(context.Context) error
+}
+
+func (h healthServer) Check(ctx context.Context, _ *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	for _, check := range h.checks {
+		err := check(ctx)
+		if err != nil {
+			return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_NOT_SERVING}, nil
+		}
+	}
+
+	return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}, nil
+}
@@ -0,0 +1,55 @@
+package grpc
+
+import (
+	"github.com/yael-castro/goarch/internal/app/business"
+	"github.com/yael-castro/goarch/pkg/pb"
+	"math"
+	"time"
+)
+
+// NewUser builds the response of a user, its age is computed at the time of the response
+func NewUser(u *business.User) *pb.User {
+	return &pb.User{
+		Id:        int64(u.ID),
+		Name:      u.Name.String(),
+		Email:     u.Email.String(),
+		Age:       uint32(u.BirthDate.Age(time.Now())),
+		BirthDate: u.BirthDate.String(),
+	}
+}
+
+// ToBusinessUser builds the user of a request, the deprecated age approximates the birth date only if it is missing
+func ToBusinessUser(u *pb.User) *business.User {
+	return &business.User{
+		ID:        business.UserID(u.GetId()),
+		Name:      business.Name(u.GetName()),
+		Email:     business.Email(u.GetEmail()),
+		BirthDate: toBusinessBirthDate(u.GetBirthDate(), u.GetAge()),
+	}
+}
+
+// toBusinessBirthDate returns the birth date or, if it is missing, the birth date approximated from the age
+func toBusinessBirthDate(birthDate string, age uint32) business.BirthDate {
+	if len(birthDate) > 0 {
+		return business.BirthDate(birthDate)
+	}
+
+	return business.ApproximateBirthDate(business.Age(min(age, math.MaxUint8)), time.Now())
+}
+
+// NewListUsersResponse builds the response of a page, the next page is only set if the page is full
+func NewListUsersResponse(users []business.User, page business.UsersPage) *pb.ListUsersResponse {
+	response := &pb.ListUsersResponse{
+		Users: make([]*pb.User, len(users)),
+	}
+
+	for i := range users {
+		response.Users[i] = NewUser(&users[i])
+	}
+
+	if len(users) > 0 && len(users) == int(page.Size) {
+		response.NextAfterId = int64(users[len(users)-1].ID)
+	}
+
+	return response
+}
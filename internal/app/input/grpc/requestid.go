@@ -0,0 +1,22 @@
+package grpc
+
+import (
+	"context"
+	"github.com/yael-castro/goarch/internal/app/business"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// requestIDKey is the metadata key of the request ID sent by the callers
+const requestIDKey = "x-request-id"
+
+// RequestID builds an interceptor that carries the request ID sent by the caller on the context of the use cases
+func RequestID() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if values := metadata.ValueFromIncomingContext(ctx, requestIDKey); len(values) > 0 {
+			ctx = business.ContextWithRequestID(ctx, values[0])
+		}
+
+		return handler(ctx, req)
+	}
+}
@@ -0,0 +1,88 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"github.com/yael-castro/goarch/internal/app/business"
+	"github.com/yael-castro/goarch/pkg/pb"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func NewUserServer(cases business.UserCases) (pb.UserServiceServer, error) {
+	if cases == nil {
+		return nil, errors.New("business logic is not provided")
+	}
+
+	return userServer{
+		cases: cases,
+	}, nil
+}
+
+type userServer struct {
+	pb.UnimplementedUserServiceServer
+	cases business.UserCases
+}
+
+func (u userServer) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb.User, error) {
+	user := &business.User{
+		Name:      business.Name(req.GetName()),
+		Email:     business.Email(req.GetEmail()),
+		BirthDate: toBusinessBirthDate(req.GetBirthDate(), req.GetAge()),
+	}
+
+	err := u.cases.CreateUser(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewUser(user), nil
+}
+
+func (u userServer) UpdateUser(ctx context.Context, req *pb.UpdateUserRequest) (*pb.User, error) {
+	user := ToBusinessUser(req.GetUser())
+
+	err := u.cases.UpdateUser(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewUser(user), nil
+}
+
+func (u userServer) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.User, error) {
+	user, err := u.cases.QueryUser(ctx, business.UserID(req.GetId()))
+	if err != nil {
+		return nil, err
+	}
+
+	return NewUser(&user), nil
+}
+
+func (u userServer) DeleteUser(ctx context.Context, req *pb.DeleteUserRequest) (*emptypb.Empty, error) {
+	err := u.cases.DeleteUser(ctx, business.UserID(req.GetId()))
+	if err != nil {
+		return nil, err
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (u userServer) ListUsers(ctx context.Context, req *pb.ListUsersRequest) (*pb.ListUsersResponse, error) {
+	const defaultPageSize = 20
+
+	page := business.UsersPage{
+		AfterID: business.UserID(req.GetAfterId()),
+		Size:    defaultPageSize,
+	}
+
+	if req.GetPageSize() > 0 {
+		page.Size = business.PageSize(min(req.GetPageSize(), 1<<16-1))
+	}
+
+	users, err := u.cases.ListUsers(ctx, page)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewListUsersResponse(users, page), nil
+}
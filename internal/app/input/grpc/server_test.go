@@ -0,0 +1,292 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"github.com/yael-castro/goarch/internal/app/business"
+	"github.com/yael-castro/goarch/pkg/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"net"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// userCases records the arguments of the use cases and responds users and err
+type userCases struct {
+	business.UserCases
+	users []business.User
+	err   error
+	// user, id and page are the arguments received by the use cases
+	user *business.User
+	id   business.UserID
+	page business.UsersPage
+}
+
+func (u *userCases) CreateUser(_ context.Context, user *business.User) error {
+	u.user = user
+	return u.err
+}
+
+func (u *userCases) UpdateUser(_ context.Context, user *business.User) error {
+	u.user = user
+	return u.err
+}
+
+func (u *userCases) DeleteUser(_ context.Context, id business.UserID) error {
+	u.id = id
+	return u.err
+}
+
+func (u *userCases) QueryUser(_ context.Context, id business.UserID) (business.User, error) {
+	u.id = id
+
+	if u.err != nil {
+		return business.User{}, u.err
+	}
+
+	return u.users[0], nil
+}
+
+func (u *userCases) ListUsers(_ context.Context, page business.UsersPage) ([]business.User, error) {
+	u.page = page
+	return u.users, u.err
+}
+
+// dialUserServer serves the UserServiceServer of cases in memory, the errors are converted like in the server
+func dialUserServer(t *testing.T, cases business.UserCases) pb.UserServiceClient {
+	t.Helper()
+
+	userServer, err := NewUserServer(cases)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	listener := bufconn.Listen(1 << 20)
+
+	s := grpc.NewServer(grpc.ChainUnaryInterceptor(ErrorInterceptor()))
+	pb.RegisterUserServiceServer(s, userServer)
+
+	go func() {
+		_ = s.Serve(listener)
+	}()
+
+	conn, err := grpc.NewClient(
+		"passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		_ = conn.Close()
+		s.Stop()
+	})
+
+	return pb.NewUserServiceClient(conn)
+}
+
+func TestUserServer(t *testing.T) {
+	const birthDate = "2001-07-14"
+
+	age := uint32(business.BirthDate(birthDate).Age(time.Now()))
+
+	storedUser := business.User{
+		ID:        1,
+		Name:      "Yael",
+		Email:     "contacto@yael.mx",
+		BirthDate: birthDate,
+	}
+
+	storedUserPB := &pb.User{
+		Id:        1,
+		Name:      "Yael",
+		Email:     "contacto@yael.mx",
+		Age:       age,
+		BirthDate: birthDate,
+	}
+
+	cases := [...]struct {
+		call             func(context.Context, pb.UserServiceClient) (proto.Message, error)
+		users            []business.User
+		err              error
+		expectedCode     codes.Code
+		expectedResponse proto.Message
+		expectedUser     *business.User
+		expectedID       business.UserID
+		expectedPage     business.UsersPage
+	}{
+		// Test case: create a user, the age of the response is computed from the birth date
+		{
+			call: func(ctx context.Context, client pb.UserServiceClient) (proto.Message, error) {
+				return client.CreateUser(ctx, &pb.CreateUserRequest{Name: "Yael", Email: "contacto@yael.mx", BirthDate: birthDate})
+			},
+			expectedResponse: &pb.User{Name: "Yael", Email: "contacto@yael.mx", Age: age, BirthDate: birthDate},
+			expectedUser:     &business.User{Name: "Yael", Email: "contacto@yael.mx", BirthDate: birthDate},
+		},
+		// Test case: create a user with the deprecated age, the birth date is approximated
+		{
+			call: func(ctx context.Context, client pb.UserServiceClient) (proto.Message, error) {
+				return client.CreateUser(ctx, &pb.CreateUserRequest{Name: "Yael", Email: "contacto@yael.mx", Age: 23})
+			},
+			expectedResponse: &pb.User{
+				Name:      "Yael",
+				Email:     "contacto@yael.mx",
+				Age:       23,
+				BirthDate: business.ApproximateBirthDate(23, time.Now()).String(),
+			},
+			expectedUser: &business.User{
+				Name:      "Yael",
+				Email:     "contacto@yael.mx",
+				BirthDate: business.ApproximateBirthDate(23, time.Now()),
+			},
+		},
+		// Test case: the birth date takes precedence over the deprecated age
+		{
+			call: func(ctx context.Context, client pb.UserServiceClient) (proto.Message, error) {
+				return client.CreateUser(ctx, &pb.CreateUserRequest{Name: "Yael", Email: "contacto@yael.mx", Age: 60, BirthDate: birthDate})
+			},
+			expectedResponse: &pb.User{Name: "Yael", Email: "contacto@yael.mx", Age: age, BirthDate: birthDate},
+			expectedUser:     &business.User{Name: "Yael", Email: "contacto@yael.mx", BirthDate: birthDate},
+		},
+		// Test case: invalid user
+		{
+			call: func(ctx context.Context, client pb.UserServiceClient) (proto.Message, error) {
+				return client.CreateUser(ctx, &pb.CreateUserRequest{Name: "Y"})
+			},
+			err: business.ValidationErrors{
+				{Field: "name", Err: business.ErrInvalidUserName},
+				{Field: "email", Err: business.ErrInvalidUserEmail},
+			},
+			expectedCode: codes.InvalidArgument,
+			expectedUser: &business.User{Name: "Y"},
+		},
+		// Test case: update a user
+		{
+			call: func(ctx context.Context, client pb.UserServiceClient) (proto.Message, error) {
+				return client.UpdateUser(ctx, &pb.UpdateUserRequest{User: storedUserPB})
+			},
+			expectedResponse: storedUserPB,
+			expectedUser:     &storedUser,
+		},
+		// Test case: update a user of another caller
+		{
+			call: func(ctx context.Context, client pb.UserServiceClient) (proto.Message, error) {
+				return client.UpdateUser(ctx, &pb.UpdateUserRequest{User: storedUserPB})
+			},
+			err:          business.ErrAccessDenied,
+			expectedCode: codes.PermissionDenied,
+			expectedUser: &storedUser,
+		},
+		// Test case: get a user
+		{
+			call: func(ctx context.Context, client pb.UserServiceClient) (proto.Message, error) {
+				return client.GetUser(ctx, &pb.GetUserRequest{Id: 1})
+			},
+			users:            []business.User{storedUser},
+			expectedResponse: storedUserPB,
+			expectedID:       1,
+		},
+		// Test case: user not found
+		{
+			call: func(ctx context.Context, client pb.UserServiceClient) (proto.Message, error) {
+				return client.GetUser(ctx, &pb.GetUserRequest{Id: 2})
+			},
+			err:          business.ErrUserNotFound,
+			expectedCode: codes.NotFound,
+			expectedID:   2,
+		},
+		// Test case: delete a user
+		{
+			call: func(ctx context.Context, client pb.UserServiceClient) (proto.Message, error) {
+				return client.DeleteUser(ctx, &pb.DeleteUserRequest{Id: 1})
+			},
+			expectedResponse: &emptypb.Empty{},
+			expectedID:       1,
+		},
+		// Test case: unauthenticated caller
+		{
+			call: func(ctx context.Context, client pb.UserServiceClient) (proto.Message, error) {
+				return client.DeleteUser(ctx, &pb.DeleteUserRequest{Id: 1})
+			},
+			err:          business.ErrUnauthenticated,
+			expectedCode: codes.Unauthenticated,
+			expectedID:   1,
+		},
+		// Test case: the first page has the default size, it is not full so there is no next page
+		{
+			call: func(ctx context.Context, client pb.UserServiceClient) (proto.Message, error) {
+				return client.ListUsers(ctx, &pb.ListUsersRequest{})
+			},
+			users:            []business.User{storedUser},
+			expectedResponse: &pb.ListUsersResponse{Users: []*pb.User{storedUserPB}},
+			expectedPage:     business.UsersPage{Size: 20},
+		},
+		// Test case: a full page has the ID of its last user as the token of the next page
+		{
+			call: func(ctx context.Context, client pb.UserServiceClient) (proto.Message, error) {
+				return client.ListUsers(ctx, &pb.ListUsersRequest{PageSize: 1})
+			},
+			users:            []business.User{storedUser},
+			expectedResponse: &pb.ListUsersResponse{Users: []*pb.User{storedUserPB}, NextAfterId: 1},
+			expectedPage:     business.UsersPage{Size: 1},
+		},
+		// Test case: the next page starts after the token, the page size is bounded
+		{
+			call: func(ctx context.Context, client pb.UserServiceClient) (proto.Message, error) {
+				return client.ListUsers(ctx, &pb.ListUsersRequest{PageSize: 1 << 20, AfterId: 1})
+			},
+			expectedResponse: &pb.ListUsersResponse{},
+			expectedPage:     business.UsersPage{AfterID: 1, Size: 1<<16 - 1},
+		},
+		// Test case: unexpected error, its details are not exposed
+		{
+			call: func(ctx context.Context, client pb.UserServiceClient) (proto.Message, error) {
+				return client.ListUsers(ctx, &pb.ListUsersRequest{})
+			},
+			err:          errors.New("connection refused"),
+			expectedCode: codes.Internal,
+			expectedPage: business.UsersPage{Size: 20},
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			cases := &userCases{users: c.users, err: c.err}
+
+			client := dialUserServer(t, cases)
+
+			response, err := c.call(context.Background(), client)
+			if code := status.Code(err); code != c.expectedCode {
+				t.Fatalf("expected code '%v' got '%v' (%v)", c.expectedCode, code, err)
+			}
+
+			if err == nil && !proto.Equal(response, c.expectedResponse) {
+				t.Fatalf("expected response '%v' got '%v'", c.expectedResponse, response)
+			}
+
+			if c.expectedUser != nil && (cases.user == nil || !reflect.DeepEqual(*cases.user, *c.expectedUser)) {
+				t.Fatalf("expected user '%+v' got '%+v'", c.expectedUser, cases.user)
+			}
+
+			if cases.id != c.expectedID {
+				t.Fatalf("expected id %d got %d", c.expectedID, cases.id)
+			}
+
+			if cases.page != c.expectedPage {
+				t.Fatalf("expected page '%+v' got '%+v'", c.expectedPage, cases.page)
+			}
+		})
+	}
+}
@@ -0,0 +1,16 @@
+package grpc
+
+import (
+	"context"
+	"github.com/yael-castro/goarch/pkg/pb"
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+// SetServices registers the user, health and reflection services in s
+func SetServices(s *grpc.Server, users pb.UserServiceServer, checks ...func(context.Context) error) {
+	pb.RegisterUserServiceServer(s, users)
+	healthpb.RegisterHealthServer(s, NewHealthServer(checks...))
+	reflection.Register(s)
+}
@@ -0,0 +1,142 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/yael-castro/goarch/internal/app/business"
+	"github.com/yael-castro/goarch/pkg/jwtauth"
+	"net/http"
+)
+
+// publicRoutes are the routes that do not require authentication
+var publicRoutes = map[string]bool{
+	"/v1/health":       true,
+	"/v1/openapi.json": true,
+	"/v1/docs":         true,
+	"/v1/docs/:file":   true,
+}
+
+// IsPublicRoute indicates if the matched route does not require authentication (middleware.Skipper)
+func IsPublicRoute(c echo.Context) bool {
+	return publicRoutes[c.Path()]
+}
+
+// headerAPIKey is the header of the API keys of the service-to-service callers
+const headerAPIKey = "X-API-Key"
+
+type APIKeyAuthenticationConfig struct {
+	Cases business.APIKeyCases
+	// Skipper indicates the requests that are not authenticated (optional)
+	Skipper middleware.Skipper
+}
+
+// APIKeyAuthentication builds a middleware that resolves the API key of the X-API-Key header (if any) to the
+// Identity and the business.Principal of the client, the requests without API key are left to Authentication
+func APIKeyAuthentication(config APIKeyAuthenticationConfig) (echo.MiddlewareFunc, error) {
+	if config.Cases == nil {
+		return nil, errors.New("some dependencies are nil")
+	}
+
+	if config.Skipper == nil {
+		config.Skipper = middleware.DefaultSkipper
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			secret := business.APIKeySecret(c.Request().Header.Get(headerAPIKey))
+			if len(secret) == 0 || config.Skipper(c) {
+				return next(c)
+			}
+
+			ctx := c.Request().Context()
+
+			principal, err := config.Cases.AuthenticateAPIKey(ctx, secret)
+			if err != nil {
+				return err
+			}
+
+			ctx = ContextWithIdentity(ctx, Identity{
+				Subject: principal.Subject,
+			})
+			ctx = business.ContextWithPrincipal(ctx, principal)
+
+			c.SetRequest(c.Request().WithContext(ctx))
+			return next(c)
+		}
+	}, nil
+}
+
+type AuthenticationConfig struct {
+	Verifier *jwtauth.Verifier
+	// Skipper indicates the requests that are not authenticated (optional)
+	Skipper middleware.Skipper
+}
+
+// Authentication builds a middleware that requires a JWT as bearer token, the token is checked by the jwtauth.Verifier.
+// The Identity of the token is put on the request context.
+//
+// The requests authenticated by APIKeyAuthentication do not require a token.
+func Authentication(config AuthenticationConfig) (echo.MiddlewareFunc, error) {
+	if config.Verifier == nil {
+		return nil, errors.New("some dependencies are nil")
+	}
+
+	if config.Skipper == nil {
+		config.Skipper = middleware.DefaultSkipper
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			// The request is already authenticated by APIKeyAuthentication
+			if _, ok := IdentityFromContext(c.Request().Context()); ok || config.Skipper(c) {
+				return next(c)
+			}
+
+			tokenString, ok := jwtauth.BearerToken(c.Request().Header.Get(echo.HeaderAuthorization))
+			if !ok {
+				c.Response().Header().Set(echo.HeaderWWWAuthenticate, "Bearer")
+				return echo.NewHTTPError(http.StatusUnauthorized, "Missing bearer token")
+			}
+
+			claims, err := config.Verifier.Verify(tokenString)
+			if err != nil {
+				c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Bearer error="invalid_token"`)
+				return echo.NewHTTPError(http.StatusUnauthorized, "Invalid bearer token").SetInternal(err)
+			}
+
+			subject, _ := claims.GetSubject()
+
+			ctx := ContextWithIdentity(c.Request().Context(), Identity{
+				Subject: subject,
+				Claims:  claims,
+			})
+
+			// The business.UserPolicy authorizes the Principal
+			ctx = business.ContextWithPrincipal(ctx, business.NewPrincipal(subject, jwtauth.Scopes(claims)))
+
+			c.SetRequest(c.Request().WithContext(ctx))
+			return next(c)
+		}
+	}, nil
+}
+
+// Identity is the authenticated caller of a request
+type Identity struct {
+	Subject string
+	Claims  map[string]any
+}
+
+type identityKey struct{}
+
+// ContextWithIdentity returns a copy of ctx that carries identity
+func ContextWithIdentity(ctx context.Context, identity Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// IdentityFromContext returns the Identity carried by ctx (if any)
+func IdentityFromContext(ctx context.Context) (Identity, bool) {
+	identity, ok := ctx.Value(identityKey{}).(Identity)
+	return identity, ok
+}
@@ -0,0 +1,328 @@
+package http
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+	"github.com/yael-castro/goarch/internal/app/business"
+	"github.com/yael-castro/goarch/pkg/jwtauth"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestAuthentication(t *testing.T) {
+	const (
+		issuer   = "https://auth.example.com/"
+		audience = "users-api"
+	)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encode := func(i *big.Int) string {
+		return base64.RawURLEncoding.EncodeToString(i.Bytes())
+	}
+
+	jwks, _ := json.Marshal(map[string]any{
+		"keys": []map[string]string{
+			{"kty": "RSA", "kid": "rsa", "use": "sig", "n": encode(rsaKey.N), "e": encode(big.NewInt(int64(rsaKey.E)))},
+			{"kty": "EC", "kid": "ec", "crv": "P-256", "x": encode(ecKey.X), "y": encode(ecKey.Y)},
+			{"kty": "oct", "kid": "hmac", "k": "c2VjcmV0"},
+		},
+	})
+
+	file := filepath.Join(t.TempDir(), "jwks.json")
+
+	if err = os.WriteFile(file, jwks, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := jwtauth.NewJWKS(context.Background(), jwtauth.JWKSConfig{
+		File:            file,
+		RefreshInterval: time.Hour,
+		Logger:          slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verifier, err := jwtauth.NewVerifier(jwtauth.VerifierConfig{
+		Keyfunc:  keys.Keyfunc,
+		Issuer:   issuer,
+		Audience: audience,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authentication, err := Authentication(AuthenticationConfig{
+		Verifier: verifier,
+		Skipper:  IsPublicRoute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := echo.New()
+	e.HTTPErrorHandler = ErrorHandler(e.HTTPErrorHandler, nil)
+	e.Use(authentication)
+
+	SetRoutes(e, UserHandler{})
+
+	// The subject of the Identity is echoed instead of calling the business logic
+	e.GET("/v1/users/me", func(c echo.Context) error {
+		identity, _ := IdentityFromContext(c.Request().Context())
+		return c.String(http.StatusOK, identity.Subject)
+	})
+
+	claims := func(modify func(jwt.MapClaims)) jwt.MapClaims {
+		claims := jwt.MapClaims{
+			"sub": "1234",
+			"iss": issuer,
+			"aud": audience,
+			"exp": time.Now().Add(time.Minute).Unix(),
+		}
+
+		if modify != nil {
+			modify(claims)
+		}
+
+		return claims
+	}
+
+	sign := func(method jwt.SigningMethod, kid string, claims jwt.MapClaims, key any) string {
+		token := jwt.NewWithClaims(method, claims)
+		token.Header["kid"] = kid
+
+		tokenString, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return "Bearer " + tokenString
+	}
+
+	cases := [...]struct {
+		target          string
+		authorization   string
+		expectedStatus  int
+		expectedSubject string
+	}{
+		// Test case: missing token
+		{
+			target:         "/v1/users/me",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		// Test case: valid RS256 token
+		{
+			target:          "/v1/users/me",
+			authorization:   sign(jwt.SigningMethodRS256, "rsa", claims(nil), rsaKey),
+			expectedStatus:  http.StatusOK,
+			expectedSubject: "1234",
+		},
+		// Test case: valid ES256 token
+		{
+			target:          "/v1/users/me",
+			authorization:   sign(jwt.SigningMethodES256, "ec", claims(nil), ecKey),
+			expectedStatus:  http.StatusOK,
+			expectedSubject: "1234",
+		},
+		// Test case: expired token
+		{
+			target: "/v1/users/me",
+			authorization: sign(jwt.SigningMethodRS256, "rsa", claims(func(claims jwt.MapClaims) {
+				claims["exp"] = time.Now().Add(-time.Minute).Unix()
+			}), rsaKey),
+			expectedStatus: http.StatusUnauthorized,
+		},
+		// Test case: token without expiry
+		{
+			target: "/v1/users/me",
+			authorization: sign(jwt.SigningMethodRS256, "rsa", claims(func(claims jwt.MapClaims) {
+				delete(claims, "exp")
+			}), rsaKey),
+			expectedStatus: http.StatusUnauthorized,
+		},
+		// Test case: unexpected issuer
+		{
+			target: "/v1/users/me",
+			authorization: sign(jwt.SigningMethodRS256, "rsa", claims(func(claims jwt.MapClaims) {
+				claims["iss"] = "https://evil.example.com/"
+			}), rsaKey),
+			expectedStatus: http.StatusUnauthorized,
+		},
+		// Test case: unexpected audience
+		{
+			target: "/v1/users/me",
+			authorization: sign(jwt.SigningMethodRS256, "rsa", claims(func(claims jwt.MapClaims) {
+				claims["aud"] = "orders-api"
+			}), rsaKey),
+			expectedStatus: http.StatusUnauthorized,
+		},
+		// Test case: token signed by a key out of the JWKS
+		{
+			target:         "/v1/users/me",
+			authorization:  sign(jwt.SigningMethodES256, "unknown", claims(nil), ecKey),
+			expectedStatus: http.StatusUnauthorized,
+		},
+		// Test case: symmetric algorithms are not allowed
+		{
+			target:         "/v1/users/me",
+			authorization:  sign(jwt.SigningMethodHS256, "hmac", claims(nil), []byte("secret")),
+			expectedStatus: http.StatusUnauthorized,
+		},
+		// Test case: health check is public
+		{
+			target:         "/v1/health",
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, c.target, nil)
+
+			if len(c.authorization) > 0 {
+				req.Header.Set(echo.HeaderAuthorization, c.authorization)
+			}
+
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			if rec.Code != c.expectedStatus {
+				t.Fatalf("expected status %d, got %d (%s)", c.expectedStatus, rec.Code, rec.Body)
+			}
+
+			if c.expectedStatus == http.StatusUnauthorized {
+				if contentType := rec.Header().Get(echo.HeaderContentType); contentType != MIMEApplicationProblemJSON {
+					t.Errorf("expected content type '%s', got '%s'", MIMEApplicationProblemJSON, contentType)
+				}
+
+				if len(rec.Header().Get(echo.HeaderWWWAuthenticate)) == 0 {
+					t.Error("expected WWW-Authenticate header")
+				}
+			}
+
+			if len(c.expectedSubject) > 0 && rec.Body.String() != c.expectedSubject {
+				t.Errorf("expected subject '%s', got '%s'", c.expectedSubject, rec.Body)
+			}
+		})
+	}
+}
+
+// apiKeyCases authenticates only the secret "valid"
+type apiKeyCases struct {
+	business.APIKeyCases
+}
+
+func (apiKeyCases) AuthenticateAPIKey(_ context.Context, secret business.APIKeySecret) (business.Principal, error) {
+	if secret != "valid" {
+		return business.Principal{}, business.ErrInvalidAPIKey
+	}
+
+	return business.Principal{Subject: "api_key:1", Scopes: []business.Scope{business.ScopeUsersRead}}, nil
+}
+
+func TestAPIKeyAuthentication(t *testing.T) {
+	apiKeyAuthentication, err := APIKeyAuthentication(APIKeyAuthenticationConfig{
+		Cases:   apiKeyCases{},
+		Skipper: IsPublicRoute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every token is rejected, so only the API keys authenticate
+	verifier, err := jwtauth.NewVerifier(jwtauth.VerifierConfig{
+		Keyfunc: func(*jwt.Token) (any, error) {
+			return nil, errors.New("unknown key")
+		},
+		Issuer:   "https://auth.example.com/",
+		Audience: "users-api",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authentication, err := Authentication(AuthenticationConfig{
+		Verifier: verifier,
+		Skipper:  IsPublicRoute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := echo.New()
+	e.HTTPErrorHandler = ErrorHandler(e.HTTPErrorHandler, nil)
+	e.Use(apiKeyAuthentication, authentication)
+
+	e.GET("/v1/users/me", func(c echo.Context) error {
+		principal, _ := business.PrincipalFromContext(c.Request().Context())
+		return c.String(http.StatusOK, principal.Subject)
+	})
+
+	cases := [...]struct {
+		apiKey          string
+		expectedStatus  int
+		expectedSubject string
+	}{
+		// Test case: valid API key
+		{
+			apiKey:          "valid",
+			expectedStatus:  http.StatusOK,
+			expectedSubject: "api_key:1",
+		},
+		// Test case: unknown or revoked API key
+		{
+			apiKey:         "revoked",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		// Test case: neither API key nor token
+		{
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v1/users/me", nil)
+
+			if len(c.apiKey) > 0 {
+				req.Header.Set(headerAPIKey, c.apiKey)
+			}
+
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			if rec.Code != c.expectedStatus {
+				t.Fatalf("expected status %d, got %d (%s)", c.expectedStatus, rec.Code, rec.Body)
+			}
+
+			if len(c.expectedSubject) > 0 && rec.Body.String() != c.expectedSubject {
+				t.Errorf("expected subject '%s', got '%s'", c.expectedSubject, rec.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package http
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/labstack/echo/v4"
+	swaggerfiles "github.com/swaggo/files/v2"
+	"net/http"
+	"strings"
+)
+
+// docsPage is the page of the docs UI, it renders the spec served in /v1/openapi.json
+//
+//go:embed static/docs.html
+var docsPage []byte
+
+// NewDocsHandler builds the handler of the spec and its docs UI, the gitCommit (if any) is injected into the info block
+func NewDocsHandler(doc *openapi3.T, gitCommit string) (DocsHandler, error) {
+	if doc == nil || doc.Info == nil {
+		return DocsHandler{}, errors.New("openapi document or its info is nil")
+	}
+
+	// The shared document is not modified
+	info := *doc.Info
+	info.Extensions = make(map[string]any, len(doc.Info.Extensions)+1)
+
+	for key, value := range doc.Info.Extensions {
+		info.Extensions[key] = value
+	}
+
+	if len(gitCommit) > 0 {
+		info.Version += "+" + gitCommit // Semantic version with build metadata
+		info.Extensions["x-git-commit"] = gitCommit
+	}
+
+	spec := *doc
+	spec.Info = &info
+
+	data, err := json.Marshal(&spec)
+	if err != nil {
+		return DocsHandler{}, err
+	}
+
+	return DocsHandler{
+		spec: data,
+	}, nil
+}
+
+type DocsHandler struct {
+	spec []byte
+}
+
+func (d DocsHandler) GetSpec(c echo.Context) error {
+	return c.Blob(http.StatusOK, echo.MIMEApplicationJSON, d.spec)
+}
+
+func (d DocsHandler) GetDocs(c echo.Context) error {
+	return c.HTMLBlob(http.StatusOK, docsPage)
+}
+
+// GetDocsFile serves the static files of the docs UI (swagger-ui)
+func (d DocsHandler) GetDocsFile(c echo.Context) error {
+	return echo.StaticFileHandler(c.Param("file"), swaggerfiles.FS)(c)
+}
+
+// VerifyOpenAPI returns an error if any of the routes is missing from doc
+func VerifyOpenAPI(doc *openapi3.T, routes []*echo.Route) error {
+	var missing []string
+
+	for _, route := range routes {
+		pathItem := doc.Paths.Find(OpenAPIPath(route.Path))
+		if pathItem == nil || pathItem.GetOperation(route.Method) == nil {
+			missing = append(missing, route.Method+" "+route.Path)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("routes missing from the OpenAPI document: %s", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
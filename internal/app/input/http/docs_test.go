@@ -0,0 +1,107 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/labstack/echo/v4"
+	"github.com/yael-castro/goarch/docs"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestDocsHandler(t *testing.T) {
+	doc, err := NewOpenAPI(context.Background(), docs.OpenAPI)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	validator, err := OpenAPIValidator(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const gitCommit = "ac35df4"
+
+	handler, err := NewDocsHandler(doc, gitCommit)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := echo.New()
+	e.HTTPErrorHandler = ErrorHandler(e.HTTPErrorHandler, nil)
+	e.Use(validator)
+
+	SetDocsRoutes(e, handler)
+
+	cases := [...]struct {
+		target              string
+		expectedStatus      int
+		expectedContentType string
+	}{
+		// Test case: OpenAPI document
+		{
+			target:              "/v1/openapi.json",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: echo.MIMEApplicationJSON,
+		},
+		// Test case: docs UI page
+		{
+			target:              "/v1/docs",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: echo.MIMETextHTML,
+		},
+		// Test case: static file of the docs UI
+		{
+			target:              "/v1/docs/swagger-ui-bundle.js",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "javascript",
+		},
+		// Test case: missing static file
+		{
+			target:              "/v1/docs/missing.js",
+			expectedStatus:      http.StatusNotFound,
+			expectedContentType: MIMEApplicationProblemJSON,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, c.target, nil))
+
+			if rec.Code != c.expectedStatus {
+				t.Fatalf("expected status %d, got %d (%s)", c.expectedStatus, rec.Code, rec.Body)
+			}
+
+			if contentType := rec.Header().Get(echo.HeaderContentType); !strings.Contains(contentType, c.expectedContentType) {
+				t.Errorf("expected content type '%s', got '%s'", c.expectedContentType, contentType)
+			}
+		})
+	}
+
+	// The version and git commit are injected into the info block
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/openapi.json", nil))
+
+	var spec struct {
+		Info struct {
+			Version   string `json:"version"`
+			GitCommit string `json:"x-git-commit"`
+		} `json:"info"`
+	}
+
+	if err = json.Unmarshal(rec.Body.Bytes(), &spec); err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := doc.Info.Version + "+" + gitCommit; spec.Info.Version != expected {
+		t.Errorf("expected version '%s', got '%s'", expected, spec.Info.Version)
+	}
+
+	if spec.Info.GitCommit != gitCommit {
+		t.Errorf("expected git commit '%s', got '%s'", gitCommit, spec.Info.GitCommit)
+	}
+}
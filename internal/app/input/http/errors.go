@@ -4,39 +4,136 @@ import (
 	"errors"
 	"github.com/labstack/echo/v4"
 	"github.com/yael-castro/goarch/internal/app/business"
+	"github.com/yael-castro/goarch/pkg/i18n"
 	"net/http"
+	"strings"
 )
 
-func ErrorHandler(handler echo.HTTPErrorHandler) echo.HTTPErrorHandler {
-	return func(err error, c echo.Context) {
-		var userErr business.Error
+// MIMEApplicationProblemJSON is the media type of the Problem responses (RFC 7807)
+const MIMEApplicationProblemJSON = "application/problem+json"
+
+// Headers to localize the Problem(s)
+const (
+	headerAcceptLanguage  = "Accept-Language"
+	headerContentLanguage = "Content-Language"
+)
+
+// problemTypes is the documentation of the problem types, each type is an anchor
+const problemTypes = "https://github.com/yael-castro/goarch/blob/main/docs/problems.md#"
 
-		if !errors.As(err, &userErr) {
+// ErrorHandler responds every error as a Problem localized by the Accept-Language header (if the catalog is not nil),
+// handler is only used when the response is already committed
+func ErrorHandler(handler echo.HTTPErrorHandler, catalog *i18n.Catalog) echo.HTTPErrorHandler {
+	return func(err error, c echo.Context) {
+		if c.Response().Committed {
 			handler(err, c)
 			return
 		}
 
-		code := http.StatusInternalServerError
-		response := echo.Map{
-			"code":    userErr.Error(),
-			"message": err.Error(),
+		problem := NewProblem(err)
+		problem.Instance = c.Request().URL.Path
+
+		header := c.Response().Header()
+
+		if catalog != nil {
+			tag := catalog.Match(c.Request().Header.Get(headerAcceptLanguage))
+			problem.Localize(catalog, tag)
+
+			header.Set(headerContentLanguage, tag.String())
+			header.Add(echo.HeaderVary, headerAcceptLanguage)
 		}
 
-		//goland:noinspection ALL
-		switch userErr {
-		case
-			business.ErrInvalidUserName,
-			business.ErrInvalidUserEmail,
-			business.ErrInvalidUserAge:
-			code = http.StatusBadRequest
-		case
-			business.ErrDuplicateUserEmail:
-			code = http.StatusConflict
-		case
-			business.ErrUserNotFound:
-			code = http.StatusNotFound
+		header.Set(echo.HeaderContentType, MIMEApplicationProblemJSON)
+
+		if c.Request().Method == http.MethodHead {
+			_ = c.NoContent(problem.Status)
+			return
 		}
 
-		_ = c.JSON(code, response)
+		_ = c.JSON(problem.Status, problem)
 	}
 }
+
+// NewProblem builds the Problem that describes err
+func NewProblem(err error) *Problem {
+	var (
+		violations business.ValidationErrors
+		userErr    *business.Error
+		httpErr    *echo.HTTPError
+	)
+
+	switch {
+	case errors.As(err, &violations):
+		problem := &Problem{
+			Type:   problemTypes + "validation-error",
+			Title:  "Your request parameters didn't validate",
+			Status: http.StatusBadRequest,
+			Detail: err.Error(),
+			Errors: make([]FieldProblem, 0, len(violations)),
+		}
+
+		for _, violation := range violations {
+			fieldProblem := FieldProblem{
+				Field:  violation.Field,
+				Detail: violation.Err.Error(),
+			}
+
+			if errors.As(violation.Err, &userErr) {
+				fieldProblem.Code = userErr.Code
+				fieldProblem.params = userErr.Metadata
+			}
+
+			problem.Errors = append(problem.Errors, fieldProblem)
+		}
+
+		return problem
+	case errors.As(err, &userErr):
+		return &Problem{
+			Type:     problemTypes + strings.ToLower(userErr.Code),
+			Title:    userErr.Message,
+			Status:   errorStatus(userErr),
+			Detail:   err.Error(),
+			Code:     userErr.Code,
+			Metadata: userErr.Metadata,
+		}
+	case errors.As(err, &httpErr):
+		problem := &Problem{
+			Type:   "about:blank",
+			Title:  http.StatusText(httpErr.Code),
+			Status: httpErr.Code,
+		}
+
+		if message, ok := httpErr.Message.(string); ok && message != problem.Title {
+			problem.Detail = message
+		}
+
+		return problem
+	}
+
+	// The details of unexpected errors are not exposed
+	return &Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(http.StatusInternalServerError),
+		Status: http.StatusInternalServerError,
+	}
+}
+
+// errorStatus maps the Category of a business.Error to an HTTP status
+func errorStatus(userErr *business.Error) int {
+	switch userErr.Category {
+	case business.CategoryValidation:
+		return http.StatusBadRequest
+	case business.CategoryConflict:
+		return http.StatusConflict
+	case business.CategoryNotFound:
+		return http.StatusNotFound
+	case business.CategoryUnavailable:
+		return http.StatusServiceUnavailable
+	case business.CategoryUnauthenticated:
+		return http.StatusUnauthorized
+	case business.CategoryForbidden:
+		return http.StatusForbidden
+	}
+
+	return http.StatusInternalServerError
+}
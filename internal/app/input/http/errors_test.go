@@ -0,0 +1,160 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"github.com/yael-castro/goarch/internal/app/business"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestErrorHandler(t *testing.T) {
+	cases := [...]struct {
+		err              error
+		acceptLanguage   string
+		expectedLanguage string
+		expectedProblem  Problem
+	}{
+		// Test case: every invalid field is listed
+		{
+			err: business.User{Name: "1"}.Validate(),
+			expectedProblem: Problem{
+				Type:     problemTypes + "validation-error",
+				Title:    "Your request parameters didn't validate",
+				Status:   http.StatusBadRequest,
+				Instance: "/v1/users",
+				Errors: []FieldProblem{
+					{Field: "name", Code: "USER_NAME_INVALID"},
+					{Field: "email", Code: "USER_EMAIL_INVALID"},
+					{Field: "birth_date", Code: "USER_BIRTH_DATE_INVALID"},
+				},
+			},
+		},
+		// Test case: business error
+		{
+			err: fmt.Errorf("%w: user 1 not found", business.ErrUserNotFound.With("user_id", "1")),
+			expectedProblem: Problem{
+				Type:     problemTypes + "user_not_found",
+				Title:    "User not found",
+				Status:   http.StatusNotFound,
+				Detail:   "The user 1 does not exist",
+				Instance: "/v1/users",
+				Code:     "USER_NOT_FOUND",
+				Metadata: map[string]string{"user_id": "1"},
+			},
+		},
+		// Test case: localized business error
+		{
+			err:              fmt.Errorf("%w: user 1 not found", business.ErrUserNotFound.With("user_id", "1")),
+			acceptLanguage:   "es-MX,es;q=0.9,en;q=0.8",
+			expectedLanguage: "es",
+			expectedProblem: Problem{
+				Type:     problemTypes + "user_not_found",
+				Title:    "Usuario no encontrado",
+				Status:   http.StatusNotFound,
+				Detail:   "El usuario 1 no existe",
+				Instance: "/v1/users",
+				Code:     "USER_NOT_FOUND",
+				Metadata: map[string]string{"user_id": "1"},
+			},
+		},
+		// Test case: localized validation error
+		{
+			err:              business.User{Name: "Yael", Email: "x", BirthDate: "2001-07-14"}.Validate(),
+			acceptLanguage:   "es",
+			expectedLanguage: "es",
+			expectedProblem: Problem{
+				Type:     problemTypes + "validation-error",
+				Title:    "Los parámetros de la solicitud no son válidos",
+				Status:   http.StatusBadRequest,
+				Detail:   "Uno o más campos no son válidos",
+				Instance: "/v1/users",
+				Errors: []FieldProblem{
+					{Field: "email", Code: "USER_EMAIL_INVALID", Detail: "'x' no es un correo electrónico válido"},
+				},
+			},
+		},
+		// Test case: echo error
+		{
+			err: echo.NewHTTPError(http.StatusBadRequest, "Syntax error: offset=1, error=invalid character"),
+			expectedProblem: Problem{
+				Type:     "about:blank",
+				Title:    http.StatusText(http.StatusBadRequest),
+				Status:   http.StatusBadRequest,
+				Detail:   "Syntax error: offset=1, error=invalid character",
+				Instance: "/v1/users",
+			},
+		},
+		// Test case: unexpected error
+		{
+			err: errors.New("connection refused"),
+			expectedProblem: Problem{
+				Type:     "about:blank",
+				Title:    http.StatusText(http.StatusInternalServerError),
+				Status:   http.StatusInternalServerError,
+				Instance: "/v1/users",
+			},
+		},
+	}
+
+	catalog, err := NewCatalog()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := echo.New()
+	handler := ErrorHandler(e.DefaultHTTPErrorHandler, catalog)
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/v1/users", nil)
+			req.Header.Set(headerAcceptLanguage, c.acceptLanguage)
+
+			handler(c.err, e.NewContext(req, rec))
+
+			if rec.Code != c.expectedProblem.Status {
+				t.Fatalf("expected status %d got %d", c.expectedProblem.Status, rec.Code)
+			}
+
+			if contentType := rec.Header().Get(echo.HeaderContentType); contentType != MIMEApplicationProblemJSON {
+				t.Fatalf("expected content type '%s' got '%s'", MIMEApplicationProblemJSON, contentType)
+			}
+
+			expectedLanguage := c.expectedLanguage
+			if expectedLanguage == "" {
+				expectedLanguage = "en"
+			}
+
+			if language := rec.Header().Get(headerContentLanguage); language != expectedLanguage {
+				t.Fatalf("expected language '%s' got '%s'", expectedLanguage, language)
+			}
+
+			var problem Problem
+
+			if err := json.NewDecoder(rec.Body).Decode(&problem); err != nil {
+				t.Fatal(err)
+			}
+
+			// The details of the fields are only compared if they are expected
+			for i := range problem.Errors {
+				if c.expectedProblem.Errors[i].Detail == "" {
+					problem.Errors[i].Detail = ""
+				}
+			}
+
+			if c.expectedProblem.Detail == "" {
+				problem.Detail = ""
+			}
+
+			if !reflect.DeepEqual(problem, c.expectedProblem) {
+				t.Fatalf("expected '%+v' got '%+v'", c.expectedProblem, problem)
+			}
+		})
+	}
+}
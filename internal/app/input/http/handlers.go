@@ -2,6 +2,7 @@ package http
 
 import (
 	"errors"
+	"fmt"
 	"github.com/labstack/echo/v4"
 	"github.com/yael-castro/goarch/internal/app/business"
 	"net/http"
@@ -56,6 +57,17 @@ func (u UserHandler) PutUser(c echo.Context) error {
 	return c.JSON(http.StatusOK, user)
 }
 
+func (u UserHandler) DeleteUser(c echo.Context) error {
+	userID, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+
+	err := u.cases.DeleteUser(c.Request().Context(), business.UserID(userID))
+	if err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
 func (u UserHandler) GetUser(c echo.Context) error {
 	userID, _ := strconv.ParseInt(c.Param("id"), 10, 64)
 
@@ -66,3 +78,114 @@ func (u UserHandler) GetUser(c echo.Context) error {
 
 	return c.JSON(http.StatusOK, NewUser(&user))
 }
+
+// PostEmailVerification verifies the email of a user with the token sent to it
+func (u UserHandler) PostEmailVerification(c echo.Context) error {
+	userID, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+
+	var verification EmailVerification
+
+	if err := c.Bind(&verification); err != nil {
+		return err
+	}
+
+	err := u.cases.VerifyEmail(c.Request().Context(), business.UserID(userID), business.EmailVerificationToken(verification.Token))
+	if err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// GetUserHistory responds a page of the audit entries of a user
+func (u UserHandler) GetUserHistory(c echo.Context) error {
+	const defaultPageSize = 20
+
+	userID, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+	afterID, _ := strconv.ParseUint(c.QueryParam("after_id"), 10, 64)
+
+	page := business.HistoryPage{
+		UserID:  business.UserID(userID),
+		AfterID: business.AuditEntryID(afterID),
+		Size:    defaultPageSize,
+	}
+
+	if pageSize, _ := strconv.ParseUint(c.QueryParam("page_size"), 10, 64); pageSize > 0 {
+		page.Size = business.PageSize(min(pageSize, 1<<16-1))
+	}
+
+	entries, err := u.cases.QueryUserHistory(c.Request().Context(), page)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, NewUserHistory(entries, page))
+}
+
+// GetUserExport responds everything stored about a user as a JSON archive
+func (u UserHandler) GetUserExport(c echo.Context) error {
+	userID, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+
+	export, err := u.cases.ExportUser(c.Request().Context(), business.UserID(userID))
+	if err != nil {
+		return err
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="user-%d.json"`, userID))
+	return c.JSON(http.StatusOK, NewUserExport(export))
+}
+
+// PostUserErasure irreversibly erases the personal data of a user
+func (u UserHandler) PostUserErasure(c echo.Context) error {
+	userID, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+
+	err := u.cases.EraseUser(c.Request().Context(), business.UserID(userID))
+	if err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func NewAPIKeyHandler(cases business.APIKeyCases) (APIKeyHandler, error) {
+	if cases == nil {
+		return APIKeyHandler{}, errors.New("business logic is not provided")
+	}
+
+	return APIKeyHandler{
+		cases: cases,
+	}, nil
+}
+
+type APIKeyHandler struct {
+	cases business.APIKeyCases
+}
+
+// PostAPIKey issues an API key, its value is responded only once
+func (a APIKeyHandler) PostAPIKey(c echo.Context) error {
+	var apiKey APIKey
+
+	if err := c.Bind(&apiKey); err != nil {
+		return err
+	}
+
+	key := apiKey.ToBusiness()
+
+	secret, err := a.cases.IssueAPIKey(c.Request().Context(), key)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, NewAPIKey(key, secret))
+}
+
+func (a APIKeyHandler) DeleteAPIKey(c echo.Context) error {
+	apiKeyID, _ := strconv.ParseUint(c.Param("id"), 10, 64)
+
+	err := a.cases.RevokeAPIKey(c.Request().Context(), business.APIKeyID(apiKeyID))
+	if err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
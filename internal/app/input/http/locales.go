@@ -0,0 +1,17 @@
+package http
+
+import (
+	"embed"
+	"github.com/yael-castro/goarch/pkg/i18n"
+	"golang.org/x/text/language"
+)
+
+// locales contains a catalog of messages per language, the name of each file is the language tag
+//
+//go:embed locales/*.json
+var locales embed.FS
+
+// NewCatalog loads the embedded catalogs of messages, English is the fallback language
+func NewCatalog() (*i18n.Catalog, error) {
+	return i18n.Load(locales, "locales/*.json", language.English)
+}
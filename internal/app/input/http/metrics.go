@@ -0,0 +1,126 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/yael-castro/goarch/internal/app/business"
+	"log/slog"
+	"strconv"
+	"time"
+)
+
+// Metrics builds a middleware that counts the requests and measures their latency per route and status
+func Metrics(registerer prometheus.Registerer) (echo.MiddlewareFunc, error) {
+	if registerer == nil {
+		return nil, errors.New("registerer is nil")
+	}
+
+	labels := []string{"method", "route", "status"}
+
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Number of HTTP requests handled.",
+	}, labels)
+
+	duration := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Latency of the HTTP requests.",
+		Buckets: prometheus.DefBuckets,
+	}, labels)
+
+	err := errors.Join(
+		registerer.Register(requests),
+		registerer.Register(duration),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+
+			err := next(c)
+
+			route := c.Path()
+			if len(route) == 0 {
+				route = "unmatched"
+			}
+
+			// The error is responded afterward by the ErrorHandler, the status is the status of its Problem
+			status := c.Response().Status
+			if err != nil && !c.Response().Committed {
+				status = NewProblem(err).Status
+			}
+
+			method := c.Request().Method
+
+			requests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+			duration.WithLabelValues(method, route, strconv.Itoa(status)).Observe(time.Since(start).Seconds())
+
+			return err
+		}
+	}, nil
+}
+
+// SetMetricsRoutes exposes the metrics gathered by gatherer in the Prometheus format
+func SetMetricsRoutes(e *echo.Echo, gatherer prometheus.Gatherer) {
+	e.GET("/metrics", echo.WrapHandler(promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{})))
+}
+
+// NewRelayCollector builds a prometheus.Collector that reads the backlog of the message relay on each scrape
+func NewRelayCollector(relay business.MessagesRelay, logger *slog.Logger) (prometheus.Collector, error) {
+	if relay == nil || logger == nil {
+		return nil, errors.New("some dependencies are nil")
+	}
+
+	const namespace = "users_relay"
+
+	return relayCollector{
+		relay:  relay,
+		logger: logger,
+		backlog: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "backlog_messages"),
+			"Number of messages pending to be delivered.",
+			nil, nil,
+		),
+		oldestAge: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "oldest_undelivered_age_seconds"),
+			"Age of the oldest message pending to be delivered.",
+			nil, nil,
+		),
+	}, nil
+}
+
+type relayCollector struct {
+	relay     business.MessagesRelay
+	logger    *slog.Logger
+	backlog   *prometheus.Desc
+	oldestAge *prometheus.Desc
+}
+
+func (r relayCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- r.backlog
+	ch <- r.oldestAge
+}
+
+func (r relayCollector) Collect(ch chan<- prometheus.Metric) {
+	const scrapeTimeout = 5 * time.Second
+
+	ctx, cancel := context.WithTimeout(context.Background(), scrapeTimeout)
+	defer cancel()
+
+	stats, err := r.relay.RelayStats(ctx)
+	if err != nil {
+		r.logger.ErrorContext(ctx, "failed_relay_stats_collect", "error", err)
+		ch <- prometheus.NewInvalidMetric(r.backlog, err)
+		ch <- prometheus.NewInvalidMetric(r.oldestAge, err)
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(r.backlog, prometheus.GaugeValue, float64(stats.Pending))
+	ch <- prometheus.MustNewConstMetric(r.oldestAge, prometheus.GaugeValue, stats.OldestPendingAge.Seconds())
+}
@@ -0,0 +1,206 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/yael-castro/goarch/internal/app/business"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetrics(t *testing.T) {
+	cases := [...]struct {
+		method        string
+		target        string
+		expectedErr   error
+		expectedRoute string
+		expectedCode  string
+	}{
+		// Test case: the route is the template of the path, not the raw path
+		{
+			method:        http.MethodGet,
+			target:        "/v1/users/1",
+			expectedRoute: "/v1/users/:id",
+			expectedCode:  "204",
+		},
+		// Test case: the error of the handler is returned and the status is the status of its problem
+		{
+			method:        http.MethodDelete,
+			target:        "/v1/users/2",
+			expectedErr:   business.ErrUserNotFound,
+			expectedRoute: "/v1/users/:id",
+			expectedCode:  "404",
+		},
+		// Test case: unexpected error
+		{
+			method:        http.MethodPost,
+			target:        "/v1/users",
+			expectedErr:   io.ErrUnexpectedEOF,
+			expectedRoute: "/v1/users",
+			expectedCode:  "500",
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			registry := prometheus.NewRegistry()
+
+			metrics, err := Metrics(registry)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			e := echo.New()
+			e.HTTPErrorHandler = ErrorHandler(e.HTTPErrorHandler, nil)
+
+			// Recording the error seen by the middlewares that run before Metrics
+			var returnedErr error
+
+			e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+				return func(c echo.Context) error {
+					returnedErr = next(c)
+					return returnedErr
+				}
+			}, metrics)
+
+			e.GET("/v1/users/:id", func(c echo.Context) error {
+				return c.NoContent(http.StatusNoContent)
+			})
+			e.DELETE("/v1/users/:id", func(echo.Context) error {
+				return business.ErrUserNotFound
+			})
+			e.POST("/v1/users", func(echo.Context) error {
+				return io.ErrUnexpectedEOF
+			})
+
+			e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(c.method, c.target, nil))
+
+			if !errors.Is(returnedErr, c.expectedErr) {
+				t.Fatalf("expected error '%v' got '%v'", c.expectedErr, returnedErr)
+			}
+
+			expected := `
+# HELP http_requests_total Number of HTTP requests handled.
+# TYPE http_requests_total counter
+http_requests_total{method="` + c.method + `",route="` + c.expectedRoute + `",status="` + c.expectedCode + `"} 1
+`
+
+			err = testutil.GatherAndCompare(registry, strings.NewReader(expected), "http_requests_total")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// The latency is observed with the same labels
+			families, err := registry.Gather()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var observations uint64
+
+			for _, family := range families {
+				if family.GetName() != "http_request_duration_seconds" {
+					continue
+				}
+
+				for _, metric := range family.GetMetric() {
+					labels := make(map[string]string)
+
+					for _, label := range metric.GetLabel() {
+						labels[label.GetName()] = label.GetValue()
+					}
+
+					if labels["method"] == c.method && labels["route"] == c.expectedRoute && labels["status"] == c.expectedCode {
+						observations += metric.GetHistogram().GetSampleCount()
+					}
+				}
+			}
+
+			if observations != 1 {
+				t.Fatalf("expected 1 latency observation got %d", observations)
+			}
+		})
+	}
+}
+
+// messagesRelay reads the RelayStats from a function
+type messagesRelay func(context.Context) (business.RelayStats, error)
+
+func (messagesRelay) RelayMessages(context.Context) error {
+	return nil
+}
+
+func (f messagesRelay) RelayStats(ctx context.Context) (business.RelayStats, error) {
+	return f(ctx)
+}
+
+func TestRelayCollector(t *testing.T) {
+	cases := [...]struct {
+		stats         business.RelayStats
+		err           error
+		expected      string
+		expectedCount int
+	}{
+		// Test case: the backlog is read on each scrape
+		{
+			stats: business.RelayStats{
+				Backlog: business.Backlog{Pending: 3, OldestPendingAge: 90 * time.Second},
+			},
+			expected: `
+# HELP users_relay_backlog_messages Number of messages pending to be delivered.
+# TYPE users_relay_backlog_messages gauge
+users_relay_backlog_messages 3
+# HELP users_relay_oldest_undelivered_age_seconds Age of the oldest message pending to be delivered.
+# TYPE users_relay_oldest_undelivered_age_seconds gauge
+users_relay_oldest_undelivered_age_seconds 90
+`,
+			expectedCount: 2,
+		},
+		// Test case: the backlog can not be read, the metrics are invalid
+		{
+			err: errors.New("connection refused"),
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			relay := messagesRelay(func(context.Context) (business.RelayStats, error) {
+				return c.stats, c.err
+			})
+
+			collector, err := NewRelayCollector(relay, slog.New(slog.NewTextHandler(io.Discard, nil)))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if c.err != nil {
+				registry := prometheus.NewRegistry()
+				registry.MustRegister(collector)
+
+				if _, err = registry.Gather(); err == nil {
+					t.Fatal("expected gathering error")
+				}
+
+				return
+			}
+
+			if count := testutil.CollectAndCount(collector); count != c.expectedCount {
+				t.Fatalf("expected %d metrics got %d", c.expectedCount, count)
+			}
+
+			err = testutil.CollectAndCompare(collector, strings.NewReader(c.expected))
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
@@ -2,25 +2,269 @@ package http
 
 import (
 	"github.com/yael-castro/goarch/internal/app/business"
+	"github.com/yael-castro/goarch/pkg/i18n"
 	"github.com/yael-castro/goarch/pkg/jsont"
+	"golang.org/x/text/language"
+	"time"
 )
 
+// NewUser builds the response of a user, its age is computed at the time of the response
 func NewUser(u *business.User) *User {
-	return &User{
-		ID:    int64(u.ID),
-		Age:   uint8(u.Age),
-		Name:  u.Name.String(),
-		Email: u.Email.String(),
+	user := &User{
+		ID:        int64(u.ID),
+		Age:       uint8(u.BirthDate.Age(time.Now())),
+		BirthDate: u.BirthDate.String(),
+		Name:      u.Name.String(),
+		Email:     u.Email.String(),
 	}
+
+	if !u.EmailVerifiedAt.IsZero() {
+		user.EmailVerifiedAt = &u.EmailVerifiedAt
+	}
+
+	return user
 }
 
 type User jsont.User
 
+// ToBusiness builds the user of a request, the deprecated age approximates the birth date only if it is missing
 func (u *User) ToBusiness() *business.User {
-	return &business.User{
-		ID:    business.UserID(u.ID),
-		Age:   business.Age(u.Age),
-		Name:  business.Name(u.Name),
-		Email: business.Email(u.Email),
+	user := &business.User{
+		ID:        business.UserID(u.ID),
+		BirthDate: business.BirthDate(u.BirthDate),
+		Name:      business.Name(u.Name),
+		Email:     business.Email(u.Email),
+	}
+
+	if len(user.BirthDate) == 0 {
+		user.BirthDate = business.ApproximateBirthDate(business.Age(u.Age), time.Now())
+	}
+
+	return user
+}
+
+// EmailVerification is the token sent to the email of a user to verify it
+type EmailVerification struct {
+	Token string `json:"token"`
+}
+
+// NewUserHistory builds the response of a page, the next page is only set if the page is full
+func NewUserHistory(entries []business.AuditEntry, page business.HistoryPage) *UserHistory {
+	history := &UserHistory{
+		Entries: make([]AuditEntry, len(entries)),
+	}
+
+	for i, entry := range entries {
+		history.Entries[i] = NewAuditEntry(entry)
+	}
+
+	if len(entries) > 0 && len(entries) == int(page.Size) {
+		history.NextAfterID = uint64(entries[len(entries)-1].ID)
+	}
+
+	return history
+}
+
+func NewAuditEntry(entry business.AuditEntry) AuditEntry {
+	auditEntry := AuditEntry{
+		ID:        uint64(entry.ID),
+		Actor:     entry.Actor,
+		RequestID: entry.RequestID,
+		Action:    string(entry.Action),
+		Changes:   make([]FieldChange, len(entry.Changes)),
+		Time:      entry.Time,
+	}
+
+	for i, change := range entry.Changes {
+		auditEntry.Changes[i] = FieldChange(change)
 	}
+
+	return auditEntry
+}
+
+type UserHistory struct {
+	Entries     []AuditEntry `json:"entries"`
+	NextAfterID uint64       `json:"next_after_id,omitempty"`
+}
+
+type AuditEntry struct {
+	ID        uint64        `json:"id"`
+	Actor     string        `json:"actor"`
+	RequestID string        `json:"request_id,omitempty"`
+	Action    string        `json:"action"`
+	Changes   []FieldChange `json:"changes"`
+	Time      time.Time     `json:"time"`
+}
+
+type FieldChange struct {
+	Field  string `json:"field"`
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+}
+
+func NewUserExport(export business.UserExport) *UserExport {
+	userExport := &UserExport{
+		User:       NewUser(&export.User),
+		History:    make([]AuditEntry, len(export.History)),
+		Messages:   make([]Message, len(export.Messages)),
+		ExportedAt: export.ExportedAt,
+	}
+
+	if !export.DeletedAt.IsZero() {
+		userExport.DeletedAt = &export.DeletedAt
+	}
+
+	if !export.ErasedAt.IsZero() {
+		userExport.ErasedAt = &export.ErasedAt
+	}
+
+	for i, entry := range export.History {
+		userExport.History[i] = NewAuditEntry(entry)
+	}
+
+	for i, message := range export.Messages {
+		userExport.Messages[i] = Message{
+			ID:      message.ID,
+			Topic:   message.Topic,
+			Headers: make(map[string]string, len(message.Headers)),
+			Value:   message.Value,
+		}
+
+		for _, header := range message.Headers {
+			userExport.Messages[i].Headers[header.Key] = string(header.Value)
+		}
+	}
+
+	return userExport
+}
+
+// UserExport is the archive of everything stored about a user
+type UserExport struct {
+	User       *User        `json:"user"`
+	DeletedAt  *time.Time   `json:"deleted_at,omitempty"`
+	ErasedAt   *time.Time   `json:"erased_at,omitempty"`
+	History    []AuditEntry `json:"history"`
+	Messages   []Message    `json:"messages"`
+	ExportedAt time.Time    `json:"exported_at"`
+}
+
+// Message is an event published about a user, its value is encoded in base64
+type Message struct {
+	ID      uint64            `json:"id"`
+	Topic   string            `json:"topic"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Value   []byte            `json:"value"`
+}
+
+func NewAPIKey(k *business.APIKey, secret business.APIKeySecret) *APIKey {
+	scopes := make([]string, len(k.Scopes))
+
+	for i, scope := range k.Scopes {
+		scopes[i] = string(scope)
+	}
+
+	return &APIKey{
+		ID:        uint64(k.ID),
+		Name:      k.Name,
+		Scopes:    scopes,
+		Key:       secret.Reveal(),
+		Prefix:    k.Prefix,
+		CreatedAt: &k.CreatedAt,
+	}
+}
+
+type APIKey struct {
+	ID     uint64   `json:"id,omitempty"`
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+	// Key is the value of the API key, it is responded only when the key is issued
+	Key       string     `json:"key,omitempty"`
+	Prefix    string     `json:"prefix,omitempty"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+}
+
+func (a *APIKey) ToBusiness() *business.APIKey {
+	scopes := make([]business.Scope, len(a.Scopes))
+
+	for i, scope := range a.Scopes {
+		scopes[i] = business.Scope(scope)
+	}
+
+	return &business.APIKey{
+		Name:   a.Name,
+		Scopes: scopes,
+	}
+}
+
+type RelayLiveness struct {
+	Leader bool `json:"leader"`
+}
+
+func NewRelayStats(stats *business.RelayStats, breakerState string) *RelayStats {
+	relayStats := &RelayStats{
+		PendingMessages:         stats.Pending,
+		OldestPendingAgeSeconds: stats.OldestPendingAge.Seconds(),
+		BreakerState:            breakerState,
+		Leader:                  stats.Leader,
+	}
+
+	if !stats.LastBatchAt.IsZero() {
+		relayStats.LastBatchAt = &stats.LastBatchAt
+	}
+
+	return relayStats
+}
+
+type RelayStats struct {
+	PendingMessages         uint64     `json:"pending_messages"`
+	OldestPendingAgeSeconds float64    `json:"oldest_pending_age_seconds"`
+	LastBatchAt             *time.Time `json:"last_batch_at,omitempty"`
+	BreakerState            string     `json:"breaker_state"`
+	Leader                  bool       `json:"leader"`
+}
+
+// Problem describes an error as defined by the RFC 7807 (application/problem+json)
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	// Code is the business error code (extension member)
+	Code string `json:"code,omitempty"`
+	// Metadata is the context of the business error (extension member)
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// Errors lists every invalid field (extension member)
+	Errors []FieldProblem `json:"errors,omitempty"`
+}
+
+// validationErrorKey is the key of the localized message of the Problem(s) with invalid fields
+const validationErrorKey = "VALIDATION_ERROR"
+
+// Localize translates the title and the details of the Problem to the language tag, the metadata are the params of the messages
+func (p *Problem) Localize(catalog *i18n.Catalog, tag language.Tag) {
+	key := p.Code
+	if len(p.Errors) > 0 {
+		key = validationErrorKey
+	}
+
+	if message, ok := catalog.Localize(tag, key, p.Metadata); ok {
+		p.Title = message.Title
+		p.Detail = message.Detail
+	}
+
+	for i := range p.Errors {
+		if message, ok := catalog.Localize(tag, p.Errors[i].Code, p.Errors[i].params); ok {
+			p.Errors[i].Detail = message.Detail
+		}
+	}
+}
+
+// FieldProblem describes why a field is invalid
+type FieldProblem struct {
+	Field  string `json:"field"`
+	Code   string `json:"code,omitempty"`
+	Detail string `json:"detail"`
+	// params of the localized message
+	params map[string]string
 }
@@ -0,0 +1,179 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/labstack/echo/v4"
+	"github.com/yael-castro/goarch/internal/app/business"
+	"net/http"
+	"strings"
+)
+
+// NewOpenAPI loads the OpenAPI document from data
+func NewOpenAPI(ctx context.Context, data []byte) (*openapi3.T, error) {
+	loader := openapi3.NewLoader()
+	loader.Context = ctx
+
+	doc, err := loader.LoadFromData(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return doc, doc.Validate(ctx)
+}
+
+// OpenAPIValidator builds a middleware that validates the requests (path params, content type and body) against
+// the operation of the matched route in doc, the routes out of doc are not validated
+func OpenAPIValidator(doc *openapi3.T) (echo.MiddlewareFunc, error) {
+	if doc == nil {
+		return nil, errors.New("openapi document is nil")
+	}
+
+	options := &openapi3filter.Options{
+		MultiError:         true,
+		AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			path := OpenAPIPath(c.Path())
+
+			pathItem := doc.Paths.Find(path)
+			if pathItem == nil {
+				return next(c)
+			}
+
+			req := c.Request()
+
+			operation := pathItem.GetOperation(req.Method)
+			if operation == nil {
+				return next(c)
+			}
+
+			pathParams := make(map[string]string, len(c.ParamNames()))
+
+			for i, name := range c.ParamNames() {
+				pathParams[name] = c.ParamValues()[i]
+			}
+
+			err := openapi3filter.ValidateRequest(req.Context(), &openapi3filter.RequestValidationInput{
+				Request:    req,
+				PathParams: pathParams,
+				Route: &routers.Route{
+					Spec:      doc,
+					Path:      path,
+					PathItem:  pathItem,
+					Method:    req.Method,
+					Operation: operation,
+				},
+				Options: options,
+			})
+			if err != nil {
+				return requestError(err)
+			}
+
+			return next(c)
+		}
+	}, nil
+}
+
+// OpenAPIPath converts an echo route path (/v1/users/:id) into an OpenAPI path (/v1/users/{id})
+func OpenAPIPath(path string) string {
+	segments := strings.Split(path, "/")
+
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// requestError converts the errors of the request validation into business.ValidationErrors (one per invalid field),
+// an unexpected content type is responded as 415
+func requestError(err error) error {
+	var violations business.ValidationErrors
+
+	for _, err := range flatten(err) {
+		var requestErr *openapi3filter.RequestError
+
+		if !errors.As(err, &requestErr) {
+			violations.Add("request", err)
+			continue
+		}
+
+		if requestErr.Parameter != nil {
+			violations.Add(requestErr.Parameter.Name, errors.New(fieldReason(requestErr)))
+			continue
+		}
+
+		if strings.HasPrefix(requestErr.Reason, "header Content-Type") {
+			return echo.NewHTTPError(http.StatusUnsupportedMediaType, requestErr.Reason)
+		}
+
+		var schemaErrs []error
+
+		if requestErr.Err != nil {
+			schemaErrs = flatten(requestErr.Err)
+		}
+
+		if len(schemaErrs) == 0 {
+			violations.Add("body", errors.New(fieldReason(requestErr)))
+			continue
+		}
+
+		for _, schemaErr := range schemaErrs {
+			field := "body"
+
+			var e *openapi3.SchemaError
+
+			if errors.As(schemaErr, &e) {
+				if pointer := e.JSONPointer(); len(pointer) > 0 {
+					field = strings.Join(pointer, ".")
+				}
+
+				violations.Add(field, errors.New(e.Reason))
+				continue
+			}
+
+			violations.Add(field, errors.New(fieldReason(requestErr)))
+		}
+	}
+
+	return violations.Err()
+}
+
+// fieldReason describes why a field of the request is invalid
+func fieldReason(requestErr *openapi3filter.RequestError) string {
+	var schemaErr *openapi3.SchemaError
+
+	if errors.As(requestErr.Err, &schemaErr) {
+		return schemaErr.Reason
+	}
+
+	if requestErr.Err != nil && requestErr.Reason == "" {
+		return requestErr.Err.Error()
+	}
+
+	return requestErr.Reason
+}
+
+// flatten lists the errors of the openapi3.MultiError(s)
+func flatten(err error) []error {
+	multiErr, ok := err.(openapi3.MultiError)
+	if !ok {
+		return []error{err}
+	}
+
+	errs := make([]error, 0, len(multiErr))
+
+	for _, err := range multiErr {
+		errs = append(errs, flatten(err)...)
+	}
+
+	return errs
+}
@@ -0,0 +1,180 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/labstack/echo/v4"
+	"github.com/yael-castro/goarch/docs"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestOpenAPI_Routes fails when a registered route is missing from docs/OpenAPI.json
+func TestOpenAPI_Routes(t *testing.T) {
+	doc, err := NewOpenAPI(context.Background(), docs.OpenAPI)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	docsHandler, err := NewDocsHandler(doc, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := echo.New()
+
+	SetRoutes(e, UserHandler{})
+	SetAPIKeyRoutes(e, APIKeyHandler{})
+	SetDocsRoutes(e, docsHandler)
+
+	if err = VerifyOpenAPI(doc, e.Routes()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOpenAPIValidator(t *testing.T) {
+	cases := [...]struct {
+		method         string
+		target         string
+		contentType    string
+		body           string
+		expectedStatus int
+		expectedFields []string
+	}{
+		// Test case: valid request
+		{
+			method:         http.MethodPost,
+			target:         "/v1/users",
+			contentType:    echo.MIMEApplicationJSON,
+			body:           `{"name":"Yael","email":"contacto@yael.mx","birth_date":"2001-07-14"}`,
+			expectedStatus: http.StatusNoContent,
+		},
+		// Test case: valid request with the deprecated age instead of the birth date
+		{
+			method:         http.MethodPost,
+			target:         "/v1/users",
+			contentType:    echo.MIMEApplicationJSON,
+			body:           `{"name":"Yael","email":"contacto@yael.mx","age":23}`,
+			expectedStatus: http.StatusNoContent,
+		},
+		// Test case: every invalid field is listed
+		{
+			method:         http.MethodPost,
+			target:         "/v1/users",
+			contentType:    echo.MIMEApplicationJSON,
+			body:           `{"name":1,"email":"contacto@yael.mx","birth_date":20010714}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedFields: []string{"birth_date", "name"},
+		},
+		// Test case: unknown field
+		{
+			method:         http.MethodPost,
+			target:         "/v1/users",
+			contentType:    echo.MIMEApplicationJSON,
+			body:           `{"name":"Yael","role":"admin"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedFields: []string{"body"},
+		},
+		// Test case: body is not an object
+		{
+			method:         http.MethodPost,
+			target:         "/v1/users",
+			contentType:    echo.MIMEApplicationJSON,
+			body:           `[]`,
+			expectedStatus: http.StatusBadRequest,
+			expectedFields: []string{"body"},
+		},
+		// Test case: missing body
+		{
+			method:         http.MethodPost,
+			target:         "/v1/users",
+			contentType:    echo.MIMEApplicationJSON,
+			expectedStatus: http.StatusBadRequest,
+			expectedFields: []string{"body"},
+		},
+		// Test case: unexpected content type
+		{
+			method:         http.MethodPost,
+			target:         "/v1/users",
+			contentType:    echo.MIMETextPlain,
+			body:           `name=Yael`,
+			expectedStatus: http.StatusUnsupportedMediaType,
+		},
+		// Test case: invalid path param
+		{
+			method:         http.MethodGet,
+			target:         "/v1/users/abc",
+			expectedStatus: http.StatusBadRequest,
+			expectedFields: []string{"id"},
+		},
+		// Test case: valid path param
+		{
+			method:         http.MethodDelete,
+			target:         "/v1/users/1",
+			expectedStatus: http.StatusNoContent,
+		},
+	}
+
+	doc, err := NewOpenAPI(context.Background(), docs.OpenAPI)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	validator, err := OpenAPIValidator(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := echo.New()
+	e.HTTPErrorHandler = ErrorHandler(e.DefaultHTTPErrorHandler, nil)
+	e.Use(validator)
+
+	noContent := func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	}
+
+	e.POST("/v1/users", noContent)
+	e.GET("/v1/users/:id", noContent)
+	e.DELETE("/v1/users/:id", noContent)
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			req := httptest.NewRequest(c.method, c.target, strings.NewReader(c.body))
+			if c.contentType != "" {
+				req.Header.Set(echo.HeaderContentType, c.contentType)
+			}
+
+			rec := httptest.NewRecorder()
+
+			e.ServeHTTP(rec, req)
+
+			if rec.Code != c.expectedStatus {
+				t.Fatalf("expected status %d got %d: %s", c.expectedStatus, rec.Code, rec.Body)
+			}
+
+			if len(c.expectedFields) == 0 {
+				return
+			}
+
+			var problem Problem
+
+			if err := json.NewDecoder(rec.Body).Decode(&problem); err != nil {
+				t.Fatal(err)
+			}
+
+			fields := make([]string, 0, len(problem.Errors))
+
+			for _, fieldProblem := range problem.Errors {
+				fields = append(fields, fieldProblem.Field)
+			}
+
+			if !reflect.DeepEqual(fields, c.expectedFields) {
+				t.Fatalf("expected fields '%v' got '%v': %+v", c.expectedFields, fields, problem)
+			}
+		})
+	}
+}
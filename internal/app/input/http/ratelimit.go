@@ -0,0 +1,156 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/yael-castro/goarch/pkg/ratelimit"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers of the rate limits (draft-ietf-httpapi-ratelimit-headers)
+const (
+	headerRateLimitLimit     = "RateLimit-Limit"
+	headerRateLimitRemaining = "RateLimit-Remaining"
+	headerRateLimitReset     = "RateLimit-Reset"
+	headerRateLimitPolicy    = "RateLimit-Policy"
+)
+
+type RateLimitConfig struct {
+	Store ratelimit.Store
+	// Default is the Limit of the routes out of the Groups
+	Default ratelimit.Limit
+	// Groups indicates the Limit by route group, a group is a route path prefix optionally preceded by a method
+	// (e.g. "/v1/users" or "POST /v1/users"), the most specific group of a route is applied
+	Groups map[string]ratelimit.Limit
+	Logger *slog.Logger
+	// Skipper indicates the requests that are not limited (optional)
+	Skipper middleware.Skipper
+}
+
+// IPRateLimit builds a middleware that limits the requests of each IP by route group with token buckets. It runs
+// before the authentication, so the requests with invalid credentials are limited too. The IP is resolved by the
+// echo.IPExtractor of the server, behind a proxy it must trust the proxy or every client shares the limit of its IP.
+//
+// The requests are allowed if the Store fails, so the API keeps working without the limits.
+func IPRateLimit(config RateLimitConfig) (echo.MiddlewareFunc, error) {
+	return rateLimit(config, func(c echo.Context) (string, bool) {
+		return "ip:" + c.RealIP(), true
+	})
+}
+
+// RateLimit builds a middleware that limits the requests of each authenticated client by route group with token
+// buckets, the clients are identified by their Identity (OAuth client, subject or API key). The requests without
+// Identity are limited only by IPRateLimit.
+//
+// The requests are allowed if the Store fails, so the API keeps working without the limits.
+func RateLimit(config RateLimitConfig) (echo.MiddlewareFunc, error) {
+	return rateLimit(config, rateLimitClient)
+}
+
+func rateLimit(config RateLimitConfig, client func(echo.Context) (string, bool)) (echo.MiddlewareFunc, error) {
+	if config.Store == nil || config.Logger == nil {
+		return nil, errors.New("some dependencies are nil")
+	}
+
+	if err := config.Default.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid default rate limit: %w", err)
+	}
+
+	for group, limit := range config.Groups {
+		if err := limit.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid rate limit of the group '%s': %w", group, err)
+		}
+	}
+
+	if config.Skipper == nil {
+		config.Skipper = middleware.DefaultSkipper
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			key, ok := client(c)
+			if !ok || config.Skipper(c) {
+				return next(c)
+			}
+
+			group, limit := rateLimitGroup(config.Groups, c.Request().Method, c.Path())
+			if len(group) == 0 {
+				limit = config.Default
+			}
+
+			ctx := c.Request().Context()
+
+			result, err := config.Store.Take(ctx, group+"|"+key, limit)
+			if err != nil {
+				config.Logger.WarnContext(ctx, "rate_limit_store", "error", err)
+				return next(c)
+			}
+
+			header := c.Response().Header()
+			header.Set(headerRateLimitLimit, strconv.Itoa(limit.Requests))
+			header.Set(headerRateLimitRemaining, strconv.Itoa(result.Remaining))
+			header.Set(headerRateLimitReset, ceilSeconds(result.Reset))
+			header.Set(headerRateLimitPolicy, strconv.Itoa(limit.Requests)+";w="+ceilSeconds(limit.Period))
+
+			if !result.Allowed {
+				header.Set(echo.HeaderRetryAfter, ceilSeconds(result.RetryAfter))
+				return echo.NewHTTPError(http.StatusTooManyRequests, "Rate limit of "+limit.String()+" exceeded")
+			}
+
+			return next(c)
+		}
+	}, nil
+}
+
+// rateLimitGroup returns the most specific group (and its Limit) that contains the route, a group with method is more
+// specific than a group with the same path
+func rateLimitGroup(groups map[string]ratelimit.Limit, method, path string) (group string, limit ratelimit.Limit) {
+	specificity := -1
+
+	for g, l := range groups {
+		prefix, withMethod := strings.CutPrefix(g, method+" ")
+
+		if !strings.HasPrefix(prefix, "/") || (path != prefix && !strings.HasPrefix(path, strings.TrimSuffix(prefix, "/")+"/")) {
+			continue
+		}
+
+		s := 2 * len(prefix)
+		if withMethod {
+			s++
+		}
+
+		if s > specificity {
+			group, limit, specificity = g, l, s
+		}
+	}
+
+	return
+}
+
+// rateLimitClient identifies the authenticated client of the request (if any)
+func rateLimitClient(c echo.Context) (string, bool) {
+	identity, ok := IdentityFromContext(c.Request().Context())
+	if !ok {
+		return "", false
+	}
+
+	// The OAuth client of the token or the subject if the token has no client
+	for _, claim := range [...]string{"client_id", "azp"} {
+		if client, _ := identity.Claims[claim].(string); len(client) > 0 {
+			return "client:" + client, true
+		}
+	}
+
+	return "sub:" + identity.Subject, true
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
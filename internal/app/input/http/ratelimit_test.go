@@ -0,0 +1,250 @@
+package http
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/yael-castro/goarch/pkg/ratelimit"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRateLimit(t *testing.T) {
+	rateLimit, err := RateLimit(RateLimitConfig{
+		Store:   ratelimit.NewMemoryStore(),
+		Default: ratelimit.Limit{Requests: 2, Period: time.Minute},
+		Groups: map[string]ratelimit.Limit{
+			"POST /v1/users": {Requests: 1, Period: time.Minute},
+		},
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := echo.New()
+	e.HTTPErrorHandler = ErrorHandler(e.HTTPErrorHandler, nil)
+
+	// The Identity is taken from a header instead of a token
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if subject := c.Request().Header.Get("X-Subject"); len(subject) > 0 {
+				ctx := ContextWithIdentity(c.Request().Context(), Identity{Subject: subject})
+				c.SetRequest(c.Request().WithContext(ctx))
+			}
+
+			return next(c)
+		}
+	}, rateLimit)
+
+	ok := func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	}
+
+	e.POST("/v1/users", ok)
+	e.GET("/v1/users/:id", ok)
+
+	cases := [...]struct {
+		method            string
+		target            string
+		subject           string
+		expectedStatus    int
+		expectedLimit     string
+		expectedRemaining string
+	}{
+		// Test case: group limit
+		{
+			method:            http.MethodPost,
+			target:            "/v1/users",
+			subject:           "1",
+			expectedStatus:    http.StatusNoContent,
+			expectedLimit:     "1",
+			expectedRemaining: "0",
+		},
+		// Test case: group limit exceeded
+		{
+			method:            http.MethodPost,
+			target:            "/v1/users",
+			subject:           "1",
+			expectedStatus:    http.StatusTooManyRequests,
+			expectedLimit:     "1",
+			expectedRemaining: "0",
+		},
+		// Test case: the clients are limited independently
+		{
+			method:            http.MethodPost,
+			target:            "/v1/users",
+			subject:           "2",
+			expectedStatus:    http.StatusNoContent,
+			expectedLimit:     "1",
+			expectedRemaining: "0",
+		},
+		// Test case: the routes out of the groups have the default limit
+		{
+			method:            http.MethodGet,
+			target:            "/v1/users/1",
+			subject:           "1",
+			expectedStatus:    http.StatusNoContent,
+			expectedLimit:     "2",
+			expectedRemaining: "1",
+		},
+		// Test case: anonymous clients are left to the IP rate limit
+		{
+			method:         http.MethodGet,
+			target:         "/v1/users/1",
+			expectedStatus: http.StatusNoContent,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			req := httptest.NewRequest(c.method, c.target, nil)
+
+			if len(c.subject) > 0 {
+				req.Header.Set("X-Subject", c.subject)
+			}
+
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			if rec.Code != c.expectedStatus {
+				t.Fatalf("expected status %d, got %d (%s)", c.expectedStatus, rec.Code, rec.Body)
+			}
+
+			if limit := rec.Header().Get(headerRateLimitLimit); limit != c.expectedLimit {
+				t.Errorf("expected limit '%s', got '%s'", c.expectedLimit, limit)
+			}
+
+			if remaining := rec.Header().Get(headerRateLimitRemaining); remaining != c.expectedRemaining {
+				t.Errorf("expected remaining '%s', got '%s'", c.expectedRemaining, remaining)
+			}
+
+			if c.expectedStatus != http.StatusTooManyRequests {
+				return
+			}
+
+			if contentType := rec.Header().Get(echo.HeaderContentType); contentType != MIMEApplicationProblemJSON {
+				t.Errorf("expected content type '%s', got '%s'", MIMEApplicationProblemJSON, contentType)
+			}
+
+			if retryAfter := rec.Header().Get(echo.HeaderRetryAfter); retryAfter != "60" {
+				t.Errorf("expected retry after '60', got '%s'", retryAfter)
+			}
+		})
+	}
+}
+
+func TestIPRateLimit(t *testing.T) {
+	ipRateLimit, err := IPRateLimit(RateLimitConfig{
+		Store:   ratelimit.NewMemoryStore(),
+		Default: ratelimit.Limit{Requests: 1, Period: time.Minute},
+		Logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := echo.New()
+	e.HTTPErrorHandler = ErrorHandler(e.HTTPErrorHandler, nil)
+	e.IPExtractor = echo.ExtractIPDirect()
+	e.Use(ipRateLimit)
+
+	e.GET("/v1/users/:id", func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	})
+
+	cases := [...]struct {
+		remoteAddr     string
+		forwardedFor   string
+		expectedStatus int
+	}{
+		// Test case: first request of the IP
+		{
+			remoteAddr:     "203.0.113.1:1234",
+			expectedStatus: http.StatusNoContent,
+		},
+		// Test case: limit of the IP exceeded
+		{
+			remoteAddr:     "203.0.113.1:1235",
+			expectedStatus: http.StatusTooManyRequests,
+		},
+		// Test case: the X-Forwarded-For header of an untrusted client is ignored
+		{
+			remoteAddr:     "203.0.113.1:1236",
+			forwardedFor:   "198.51.100.1",
+			expectedStatus: http.StatusTooManyRequests,
+		},
+		// Test case: the IPs are limited independently
+		{
+			remoteAddr:     "203.0.113.2:1234",
+			expectedStatus: http.StatusNoContent,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v1/users/1", nil)
+			req.RemoteAddr = c.remoteAddr
+
+			if len(c.forwardedFor) > 0 {
+				req.Header.Set(echo.HeaderXForwardedFor, c.forwardedFor)
+			}
+
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			if rec.Code != c.expectedStatus {
+				t.Fatalf("expected status %d, got %d (%s)", c.expectedStatus, rec.Code, rec.Body)
+			}
+		})
+	}
+}
+
+func TestRateLimitGroup(t *testing.T) {
+	groups := map[string]ratelimit.Limit{
+		"/v1":            {Requests: 1, Period: time.Second},
+		"/v1/users":      {Requests: 2, Period: time.Second},
+		"POST /v1/users": {Requests: 3, Period: time.Second},
+	}
+
+	cases := [...]struct {
+		method        string
+		path          string
+		expectedGroup string
+	}{
+		// Test case: method group
+		{
+			method:        http.MethodPost,
+			path:          "/v1/users",
+			expectedGroup: "POST /v1/users",
+		},
+		// Test case: longest prefix
+		{
+			method:        http.MethodPut,
+			path:          "/v1/users/:id",
+			expectedGroup: "/v1/users",
+		},
+		// Test case: prefixes match whole segments
+		{
+			method:        http.MethodGet,
+			path:          "/v1/users-admin",
+			expectedGroup: "/v1",
+		},
+		// Test case: no group
+		{
+			method: http.MethodGet,
+			path:   "/metrics",
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			if group, _ := rateLimitGroup(groups, c.method, c.path); group != c.expectedGroup {
+				t.Fatalf("expected group '%s', got '%s'", c.expectedGroup, group)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"github.com/labstack/echo/v4"
+	"github.com/yael-castro/goarch/internal/app/business"
+	"net/http"
+)
+
+type RelayHandlerConfig struct {
+	Relay business.MessagesRelay
+	// Leader indicates if the replica holds the leadership
+	Leader func() bool
+	// BreakerState describes the state of the circuit breaker that protects the message sender
+	BreakerState func() string
+}
+
+func NewRelayHandler(config RelayHandlerConfig) (RelayHandler, error) {
+	if config.Relay == nil || config.Leader == nil || config.BreakerState == nil {
+		return RelayHandler{}, errors.New("some dependencies are nil")
+	}
+
+	return RelayHandler{
+		relay:        config.Relay,
+		leader:       config.Leader,
+		breakerState: config.BreakerState,
+	}, nil
+}
+
+type RelayHandler struct {
+	relay        business.MessagesRelay
+	leader       func() bool
+	breakerState func() string
+}
+
+// GetLiveness indicates that the process is alive
+func (r RelayHandler) GetLiveness(c echo.Context) error {
+	return c.JSON(http.StatusOK, RelayLiveness{
+		Leader: r.leader(),
+	})
+}
+
+// GetStats describes the backlog and the state of the message relay
+func (r RelayHandler) GetStats(c echo.Context) error {
+	stats, err := r.relay.RelayStats(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, NewRelayStats(&stats, r.breakerState()))
+}
+
+func SetRelayRoutes(e *echo.Echo, handler RelayHandler, checks ...func(context.Context) error) {
+	e.GET("/healthz", handler.GetLiveness)
+	e.GET("/readyz", health(checks...))
+	e.GET("/stats", handler.GetStats)
+}
@@ -0,0 +1,18 @@
+package http
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/yael-castro/goarch/internal/app/business"
+)
+
+// RequestID builds a middleware that reuses the X-Request-Id header of the caller (or generates one), responds it and
+// carries it on the context of the use cases to be audited
+func RequestID() echo.MiddlewareFunc {
+	return middleware.RequestIDWithConfig(middleware.RequestIDConfig{
+		RequestIDHandler: func(c echo.Context, requestID string) {
+			ctx := business.ContextWithRequestID(c.Request().Context(), requestID)
+			c.SetRequest(c.Request().WithContext(ctx))
+		},
+	})
+}
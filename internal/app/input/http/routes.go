@@ -16,6 +16,26 @@ func SetRoutes(e *echo.Echo, handler UserHandler, checks ...func(context.Context
 	g.POST("", handler.PostUser)
 	g.PUT("/:id", handler.PutUser)
 	g.GET("/:id", handler.GetUser)
+	g.DELETE("/:id", handler.DeleteUser)
+	g.POST("/:id/email/verify", handler.PostEmailVerification)
+	g.GET("/:id/history", handler.GetUserHistory)
+	g.GET("/:id/export", handler.GetUserExport)
+	g.POST("/:id/erasure", handler.PostUserErasure)
+}
+
+// SetAPIKeyRoutes sets the routes to manage the API keys
+func SetAPIKeyRoutes(e *echo.Echo, handler APIKeyHandler) {
+	g := e.Group("/v1/api-keys")
+
+	g.POST("", handler.PostAPIKey)
+	g.DELETE("/:id", handler.DeleteAPIKey)
+}
+
+// SetDocsRoutes serves the OpenAPI spec and its docs UI
+func SetDocsRoutes(e *echo.Echo, handler DocsHandler) {
+	e.GET("/v1/openapi.json", handler.GetSpec)
+	e.GET("/v1/docs", handler.GetDocs)
+	e.GET("/v1/docs/:file", handler.GetDocsFile)
 }
 
 func health(checks ...func(context.Context) error) echo.HandlerFunc {
@@ -0,0 +1,166 @@
+package decorator
+
+import (
+	"context"
+	"errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sony/gobreaker/v2"
+	"github.com/yael-castro/goarch/internal/app/business"
+	"strings"
+	"time"
+)
+
+const metricsNamespace = "users_relay"
+
+func NewSenderMetrics(sender business.MessageSender, registerer prometheus.Registerer) (business.MessageSender, error) {
+	if sender == nil || registerer == nil {
+		return nil, errors.New("sender or registerer is nil")
+	}
+
+	metrics := senderMetrics{
+		sender: sender,
+		batchSize: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "batch_size_messages",
+			Help:      "Number of messages sent in each batch.",
+			Buckets:   []float64{1, 5, 10, 25, 50, 75, 100},
+		}),
+		duration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "send_duration_seconds",
+			Help:      "Time spent sending a batch of messages to the broker.",
+			Buckets:   prometheus.DefBuckets,
+		}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "delivery_errors_total",
+			Help:      "Number of batches that could not be delivered by error type.",
+		}, []string{"type"}),
+	}
+
+	err := errors.Join(
+		registerer.Register(metrics.batchSize),
+		registerer.Register(metrics.duration),
+		registerer.Register(metrics.errors),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return metrics, nil
+}
+
+type senderMetrics struct {
+	sender    business.MessageSender
+	batchSize prometheus.Histogram
+	duration  prometheus.Histogram
+	errors    *prometheus.CounterVec
+}
+
+func (s senderMetrics) SendMessage(ctx context.Context, messages ...business.Message) (err error) {
+	s.batchSize.Observe(float64(len(messages)))
+
+	start := time.Now()
+	err = s.sender.SendMessage(ctx, messages...)
+	s.duration.Observe(time.Since(start).Seconds())
+
+	if err != nil {
+		s.errors.WithLabelValues(errorType(err)).Inc()
+	}
+
+	return
+}
+
+// errorType classifies err to be used as label value
+func errorType(err error) string {
+	switch {
+	case errors.Is(err, gobreaker.ErrOpenState):
+		return "circuit_open"
+	case errors.Is(err, gobreaker.ErrTooManyRequests):
+		return "circuit_too_many_requests"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, business.ErrMessageDeliveryFailed):
+		return "delivery_failed"
+	case errors.Is(err, business.ErrIncompatibleMessageSchema):
+		return "incompatible_schema"
+	}
+
+	var userErr *business.Error
+
+	if errors.As(err, &userErr) {
+		return strings.ToLower(userErr.Code)
+	}
+
+	// The label values are a bounded set
+	return "other"
+}
+
+func NewConfirmerMetrics(confirmer business.MessageDeliveryConfirmer, registerer prometheus.Registerer) (business.MessageDeliveryConfirmer, error) {
+	if confirmer == nil || registerer == nil {
+		return nil, errors.New("confirmer or registerer is nil")
+	}
+
+	metrics := confirmerMetrics{
+		confirmer: confirmer,
+		duration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "confirmation_duration_seconds",
+			Help:      "Time spent confirming the delivery of a batch of messages.",
+			Buckets:   prometheus.DefBuckets,
+		}),
+	}
+
+	err := registerer.Register(metrics.duration)
+	if err != nil {
+		return nil, err
+	}
+
+	return metrics, nil
+}
+
+type confirmerMetrics struct {
+	confirmer business.MessageDeliveryConfirmer
+	duration  prometheus.Histogram
+}
+
+func (c confirmerMetrics) ConfirmMessageDelivery(ctx context.Context, messages ...business.Message) (err error) {
+	start := time.Now()
+	err = c.confirmer.ConfirmMessageDelivery(ctx, messages...)
+	c.duration.Observe(time.Since(start).Seconds())
+	return
+}
+
+// NewBreakerStateObserver builds a hook for gobreaker.Settings.OnStateChange that exports the circuit breaker state transitions
+func NewBreakerStateObserver(registerer prometheus.Registerer) (func(string, gobreaker.State, gobreaker.State), error) {
+	if registerer == nil {
+		return nil, errors.New("registerer is nil")
+	}
+
+	state := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "circuit_breaker_state",
+		Help:      "Current state of the circuit breaker (0 closed, 1 half-open, 2 open).",
+	}, []string{"name"})
+
+	transitions := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "circuit_breaker_transitions_total",
+		Help:      "Number of state transitions of the circuit breaker.",
+	}, []string{"name", "from", "to"})
+
+	err := errors.Join(
+		registerer.Register(state),
+		registerer.Register(transitions),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(name string, from gobreaker.State, to gobreaker.State) {
+		state.WithLabelValues(name).Set(float64(to))
+		transitions.WithLabelValues(name, from.String(), to.String()).Inc()
+	}, nil
+}
@@ -0,0 +1,131 @@
+package decorator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sony/gobreaker/v2"
+	"github.com/yael-castro/goarch/internal/app/business"
+	"github.com/yael-castro/goarch/internal/app/business/mock"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestSenderMetrics(t *testing.T) {
+	cases := [...]struct {
+		err               error
+		expectedErrorType string
+	}{
+		// Test case: delivered batch, no error is counted
+		{},
+		// Test case: open circuit
+		{
+			err:               gobreaker.ErrOpenState,
+			expectedErrorType: "circuit_open",
+		},
+		// Test case: wrapped delivery failure
+		{
+			err:               fmt.Errorf("%w: broker unreachable", business.ErrMessageDeliveryFailed),
+			expectedErrorType: "delivery_failed",
+		},
+		// Test case: the label of an unknown error is bounded
+		{
+			err:               errors.New("unexpected"),
+			expectedErrorType: "other",
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			registry := prometheus.NewRegistry()
+
+			sender, err := NewSenderMetrics(mock.MessageSender(func(context.Context, ...business.Message) error {
+				return c.err
+			}), registry)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = sender.SendMessage(context.Background(), make([]business.Message, 10)...)
+			if !errors.Is(err, c.err) {
+				t.Fatalf("expected error '%v' got '%v'", c.err, err)
+			}
+
+			// Every batch is measured
+			if count := testutil.CollectAndCount(registry, "users_relay_batch_size_messages", "users_relay_send_duration_seconds"); count != 2 {
+				t.Fatalf("expected 2 histograms got %d", count)
+			}
+
+			expected := ""
+
+			if len(c.expectedErrorType) > 0 {
+				expected = `
+# HELP users_relay_delivery_errors_total Number of batches that could not be delivered by error type.
+# TYPE users_relay_delivery_errors_total counter
+users_relay_delivery_errors_total{type="` + c.expectedErrorType + `"} 1
+`
+			}
+
+			err = testutil.GatherAndCompare(registry, strings.NewReader(expected), "users_relay_delivery_errors_total")
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestConfirmerMetrics(t *testing.T) {
+	registry := prometheus.NewRegistry()
+
+	confirmer, err := NewConfirmerMetrics(mock.MessageDeliveryConfirmer(func(context.Context, ...business.Message) error {
+		return nil
+	}), registry)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for range 3 {
+		if err = confirmer.ConfirmMessageDelivery(context.Background(), business.Message{ID: 1}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(families) != 1 || families[0].GetMetric()[0].GetHistogram().GetSampleCount() != 3 {
+		t.Fatalf("expected 3 confirmations measured got %v", families)
+	}
+}
+
+func TestBreakerStateObserver(t *testing.T) {
+	registry := prometheus.NewRegistry()
+
+	observe, err := NewBreakerStateObserver(registry)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	observe("kafka", gobreaker.StateClosed, gobreaker.StateOpen)
+	observe("kafka", gobreaker.StateOpen, gobreaker.StateHalfOpen)
+
+	expected := `
+# HELP users_relay_circuit_breaker_state Current state of the circuit breaker (0 closed, 1 half-open, 2 open).
+# TYPE users_relay_circuit_breaker_state gauge
+users_relay_circuit_breaker_state{name="kafka"} 1
+# HELP users_relay_circuit_breaker_transitions_total Number of state transitions of the circuit breaker.
+# TYPE users_relay_circuit_breaker_transitions_total counter
+users_relay_circuit_breaker_transitions_total{from="closed",name="kafka",to="open"} 1
+users_relay_circuit_breaker_transitions_total{from="open",name="kafka",to="half-open"} 1
+`
+
+	err = testutil.GatherAndCompare(registry, strings.NewReader(expected))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,161 @@
+package decorator
+
+import (
+	"context"
+	"errors"
+	"github.com/yael-castro/goarch/internal/app/business"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/yael-castro/goarch/internal/app/output/decorator")
+
+// NewUserCasesTracing builds a business.UserCases that records a span for each use case, the spans of the stores are
+// its children
+func NewUserCasesTracing(cases business.UserCases) (business.UserCases, error) {
+	if cases == nil {
+		return nil, errors.New("cases is nil")
+	}
+
+	return userCasesTracing{
+		cases: cases,
+	}, nil
+}
+
+type userCasesTracing struct {
+	cases business.UserCases
+}
+
+func (u userCasesTracing) CreateUser(ctx context.Context, user *business.User) (err error) {
+	ctx, span := tracer.Start(ctx, "UserCases.CreateUser")
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	return u.cases.CreateUser(ctx, user)
+}
+
+func (u userCasesTracing) UpdateUser(ctx context.Context, user *business.User) (err error) {
+	ctx, span := tracer.Start(ctx, "UserCases.UpdateUser")
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	return u.cases.UpdateUser(ctx, user)
+}
+
+func (u userCasesTracing) DeleteUser(ctx context.Context, id business.UserID) (err error) {
+	ctx, span := tracer.Start(ctx, "UserCases.DeleteUser")
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	return u.cases.DeleteUser(ctx, id)
+}
+
+func (u userCasesTracing) QueryUser(ctx context.Context, id business.UserID) (_ business.User, err error) {
+	ctx, span := tracer.Start(ctx, "UserCases.QueryUser")
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	return u.cases.QueryUser(ctx, id)
+}
+
+func (u userCasesTracing) ListUsers(ctx context.Context, page business.UsersPage) (_ []business.User, err error) {
+	ctx, span := tracer.Start(ctx, "UserCases.ListUsers")
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	return u.cases.ListUsers(ctx, page)
+}
+
+func (u userCasesTracing) VerifyEmail(ctx context.Context, id business.UserID, token business.EmailVerificationToken) (err error) {
+	ctx, span := tracer.Start(ctx, "UserCases.VerifyEmail")
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	return u.cases.VerifyEmail(ctx, id, token)
+}
+
+func (u userCasesTracing) QueryUserHistory(ctx context.Context, page business.HistoryPage) (_ []business.AuditEntry, err error) {
+	ctx, span := tracer.Start(ctx, "UserCases.QueryUserHistory")
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	return u.cases.QueryUserHistory(ctx, page)
+}
+
+func (u userCasesTracing) ExportUser(ctx context.Context, id business.UserID) (_ business.UserExport, err error) {
+	ctx, span := tracer.Start(ctx, "UserCases.ExportUser")
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	return u.cases.ExportUser(ctx, id)
+}
+
+func (u userCasesTracing) EraseUser(ctx context.Context, id business.UserID) (err error) {
+	ctx, span := tracer.Start(ctx, "UserCases.EraseUser")
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	return u.cases.EraseUser(ctx, id)
+}
+
+// NewAPIKeyCasesTracing builds a business.APIKeyCases that records a span for each use case
+func NewAPIKeyCasesTracing(cases business.APIKeyCases) (business.APIKeyCases, error) {
+	if cases == nil {
+		return nil, errors.New("cases is nil")
+	}
+
+	return apiKeyCasesTracing{
+		cases: cases,
+	}, nil
+}
+
+type apiKeyCasesTracing struct {
+	cases business.APIKeyCases
+}
+
+func (a apiKeyCasesTracing) IssueAPIKey(ctx context.Context, key *business.APIKey) (_ business.APIKeySecret, err error) {
+	ctx, span := tracer.Start(ctx, "APIKeyCases.IssueAPIKey")
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	return a.cases.IssueAPIKey(ctx, key)
+}
+
+func (a apiKeyCasesTracing) RevokeAPIKey(ctx context.Context, id business.APIKeyID) (err error) {
+	ctx, span := tracer.Start(ctx, "APIKeyCases.RevokeAPIKey")
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	return a.cases.RevokeAPIKey(ctx, id)
+}
+
+func (a apiKeyCasesTracing) AuthenticateAPIKey(ctx context.Context, secret business.APIKeySecret) (_ business.Principal, err error) {
+	ctx, span := tracer.Start(ctx, "APIKeyCases.AuthenticateAPIKey")
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	return a.cases.AuthenticateAPIKey(ctx, secret)
+}
+
+// endSpan records err (if any) in span before ending it
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
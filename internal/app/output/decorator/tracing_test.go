@@ -0,0 +1,89 @@
+package decorator
+
+import (
+	"context"
+	"errors"
+	"github.com/yael-castro/goarch/internal/app/business"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"strconv"
+	"testing"
+)
+
+// userCases deletes the users with a function, the other use cases are not implemented
+type userCases struct {
+	business.UserCases
+	deleteUser func(context.Context, business.UserID) error
+}
+
+func (u userCases) DeleteUser(ctx context.Context, id business.UserID) error {
+	return u.deleteUser(ctx, id)
+}
+
+func TestUserCasesTracing(t *testing.T) {
+	cases := [...]struct {
+		err            error
+		expectedStatus codes.Code
+	}{
+		// Test case: successful use case
+		{
+			expectedStatus: codes.Unset,
+		},
+		// Test case: the error of the use case is recorded
+		{
+			err:            business.ErrUserNotFound,
+			expectedStatus: codes.Error,
+		},
+	}
+
+	// The tracer of the package delegates to the first provider set
+	recorder := tracetest.NewSpanRecorder()
+
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			previous := len(recorder.Ended())
+
+			var parent trace.SpanContext
+
+			tracing, err := NewUserCasesTracing(userCases{
+				deleteUser: func(ctx context.Context, _ business.UserID) error {
+					// The spans of the stores are children of the span of the use case
+					parent = trace.SpanContextFromContext(ctx)
+					return c.err
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = tracing.DeleteUser(context.Background(), 1)
+			if !errors.Is(err, c.err) {
+				t.Fatalf("expected error '%v' got '%v'", c.err, err)
+			}
+
+			ended := recorder.Ended()[previous:]
+			if len(ended) != 1 {
+				t.Fatalf("expected 1 span got %d", len(ended))
+			}
+
+			span := ended[0]
+
+			if span.Name() != "UserCases.DeleteUser" {
+				t.Fatalf("expected span 'UserCases.DeleteUser' got '%s'", span.Name())
+			}
+
+			if span.Status().Code != c.expectedStatus {
+				t.Fatalf("expected status '%v' got '%v'", c.expectedStatus, span.Status().Code)
+			}
+
+			if parent.SpanID() != span.SpanContext().SpanID() {
+				t.Fatal("the use case does not run in the context of its span")
+			}
+		})
+	}
+}
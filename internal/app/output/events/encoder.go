@@ -0,0 +1,309 @@
+package events
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/yael-castro/goarch/internal/app/business"
+	"github.com/yael-castro/goarch/pkg/cloudevents"
+	"github.com/yael-castro/goarch/pkg/envelope"
+	"github.com/yael-castro/goarch/pkg/pb"
+	"google.golang.org/protobuf/proto"
+	"strconv"
+)
+
+// Supported values for Encoding
+const (
+	EncodingJSON Encoding = iota
+	EncodingProtobuf
+)
+
+// Encoding is the format of the event data
+type Encoding uint8
+
+type EventEncoderConfig struct {
+	CreateUserTopic string
+	UpdateUserTopic string
+	DeleteUserTopic string
+	EraseUserTopic  string
+	// RequestEmailVerificationTopic is consumed by the mailer that sends the verification tokens
+	RequestEmailVerificationTopic string
+	VerifyEmailTopic              string
+	// Source identifies the producer of the events (CloudEvents source attribute)
+	Source string
+	// Encodings indicates the Encoding by topic, the topics that are not present are encoded as JSON
+	Encodings map[string]Encoding
+	// Keyring encrypts the PII of the events, only the consumers that hold the keyring decrypt it (see pkg/envelope).
+	// The PII is sent in plaintext if it is nil
+	Keyring *envelope.Keyring
+}
+
+// NewEventEncoder builds a business.EventEncoder that encodes the events as CloudEvents in Kafka binary content mode
+func NewEventEncoder(config EventEncoderConfig) business.EventEncoder {
+	return eventEncoder{
+		createUserTopic: config.CreateUserTopic,
+		updateUserTopic: config.UpdateUserTopic,
+		deleteUserTopic: config.DeleteUserTopic,
+		eraseUserTopic:  config.EraseUserTopic,
+		source:          config.Source,
+		encodings:       config.Encodings,
+		keyring:         config.Keyring,
+
+		requestEmailVerificationTopic: config.RequestEmailVerificationTopic,
+		verifyEmailTopic:              config.VerifyEmailTopic,
+	}
+}
+
+type eventEncoder struct {
+	createUserTopic string
+	updateUserTopic string
+	deleteUserTopic string
+	eraseUserTopic  string
+	source          string
+	encodings       map[string]Encoding
+	keyring         *envelope.Keyring
+
+	requestEmailVerificationTopic string
+	verifyEmailTopic              string
+}
+
+func (e eventEncoder) EncodeEvent(event business.Event) (msg business.Message, err error) {
+	var (
+		eventType string
+		userID    business.UserID
+	)
+
+	switch event := event.(type) {
+	case business.UserCreated:
+		msg.Topic, eventType, userID = e.createUserTopic, cloudevents.TypeUserCreated, event.User.ID
+	case business.UserUpdated:
+		msg.Topic, eventType, userID = e.updateUserTopic, cloudevents.TypeUserUpdated, event.User.ID
+	case business.UserDeleted:
+		msg.Topic, eventType, userID = e.deleteUserTopic, cloudevents.TypeUserDeleted, event.UserID
+	case business.UserErased:
+		msg.Topic, eventType, userID = e.eraseUserTopic, cloudevents.TypeUserErased, event.UserID
+	case business.UserEmailVerificationRequested:
+		msg.Topic, eventType, userID = e.requestEmailVerificationTopic, cloudevents.TypeUserEmailVerificationRequested, event.UserID
+	case business.UserEmailVerified:
+		msg.Topic, eventType, userID = e.verifyEmailTopic, cloudevents.TypeUserEmailVerified, event.UserID
+	default:
+		err = fmt.Errorf("event \"%T\" is not supported", event)
+		return
+	}
+
+	cloudEvent := cloudevents.Event{
+		Type:    eventType,
+		Source:  e.source,
+		Subject: strconv.FormatUint(uint64(userID), 10),
+		Time:    event.OccurredAt(),
+	}
+
+	event, cloudEvent.KeyID, cloudEvent.DataKey, err = e.encrypt(event)
+	if err != nil {
+		return
+	}
+
+	switch e.encodings[msg.Topic] {
+	case EncodingProtobuf:
+		msg.Value, err = proto.Marshal(NewProtoEvent(event))
+		cloudEvent.DataContentType = cloudevents.ContentTypeProtobuf
+		cloudEvent.DataSchema = cloudevents.DataSchemaUserEventsProtobuf
+	default:
+		msg.Value, cloudEvent.DataSchema, err = NewJSONData(event)
+		cloudEvent.DataContentType = cloudevents.ContentTypeJSON
+	}
+	if err != nil {
+		return
+	}
+
+	// Messages of the same user are kept in the same partition to preserve their order
+	msg.Key = []byte(cloudEvent.Subject)
+
+	err = msg.Idempotent()
+	if err != nil {
+		return
+	}
+
+	// Wrapping the message as a CloudEvent (binary content mode)
+	eventID, err := uuid.FromBytes(msg.IdempotencyKey)
+	if err != nil {
+		return
+	}
+
+	cloudEvent.ID = eventID.String()
+
+	for _, header := range cloudEvent.Headers() {
+		msg.Headers = append(msg.Headers, business.Header(header))
+	}
+
+	return
+}
+
+// encrypt returns a copy of the event whose PII fields are encrypted with a new data key and the Envelope of the data
+// key, the fields are encrypted as base64 strings so the data keeps its schema
+func (e eventEncoder) encrypt(event business.Event) (business.Event, string, []byte, error) {
+	if e.keyring == nil {
+		return event, "", nil, nil
+	}
+
+	var (
+		keyEnvelope envelope.Envelope
+		err         error
+	)
+
+	switch event := event.(type) {
+	case business.UserCreated:
+		event.User, keyEnvelope, err = e.encryptUser(event.User)
+		return event, keyEnvelope.KeyID, keyEnvelope.DataKey, err
+	case business.UserUpdated:
+		event.User, keyEnvelope, err = e.encryptUser(event.User)
+		return event, keyEnvelope.KeyID, keyEnvelope.DataKey, err
+	case business.UserEmailVerificationRequested:
+		email, token := event.Email.String(), event.Token.Reveal()
+		keyEnvelope, err = e.encryptStrings(map[string]*string{"email": &email, "token": &token})
+		event.Email, event.Token = business.Email(email), business.EmailVerificationToken(token)
+		return event, keyEnvelope.KeyID, keyEnvelope.DataKey, err
+	case business.UserEmailVerified:
+		email := event.Email.String()
+		keyEnvelope, err = e.encryptStrings(map[string]*string{"email": &email})
+		event.Email = business.Email(email)
+		return event, keyEnvelope.KeyID, keyEnvelope.DataKey, err
+	}
+
+	// The event has no PII
+	return event, "", nil, nil
+}
+
+func (e eventEncoder) encryptUser(user business.User) (business.User, envelope.Envelope, error) {
+	name, email := user.Name.String(), user.Email.String()
+
+	keyEnvelope, err := e.encryptStrings(map[string]*string{"name": &name, "email": &email})
+	if err != nil {
+		return business.User{}, keyEnvelope, err
+	}
+
+	user.Name, user.Email = business.Name(name), business.Email(email)
+	return user, keyEnvelope, nil
+}
+
+// encryptStrings replaces the values of fields by their ciphertexts encrypted with the same new data key
+func (e eventEncoder) encryptStrings(fields map[string]*string) (envelope.Envelope, error) {
+	dataKey, keyEnvelope, err := e.keyring.NewDataKey()
+	if err != nil {
+		return keyEnvelope, err
+	}
+
+	for field, value := range fields {
+		*value, err = dataKey.EncryptString(*value, field)
+		if err != nil {
+			return keyEnvelope, err
+		}
+	}
+
+	return keyEnvelope, nil
+}
+
+// ScrubMessage keeps only the ID of the user in the data of the message and removes the envelope of the encrypted fields,
+// the message keeps its ID, its idempotency key and the rest of its headers
+func (e eventEncoder) ScrubMessage(msg business.Message) (business.Message, error) {
+	headers := make([]cloudevents.Header, len(msg.Headers))
+
+	for i, header := range msg.Headers {
+		headers[i] = cloudevents.Header(header)
+	}
+
+	event, err := cloudevents.Decode(headers, msg.Value)
+	if err != nil {
+		return business.Message{}, err
+	}
+
+	// The events without personal data are kept as they are
+	switch event.Type {
+	case cloudevents.TypeUserCreated, cloudevents.TypeUserUpdated, cloudevents.TypeUserEmailVerificationRequested, cloudevents.TypeUserEmailVerified:
+	default:
+		return msg, nil
+	}
+
+	switch event.DataContentType {
+	case cloudevents.ContentTypeProtobuf:
+		msg.Value, err = scrubProtoData(event)
+	default:
+		msg.Value, err = scrubJSONData(event)
+	}
+	if err != nil {
+		return business.Message{}, err
+	}
+
+	msg.Headers = make(business.Headers, 0, len(headers))
+
+	for _, header := range headers {
+		if header.Key != cloudevents.HeaderKeyID && header.Key != cloudevents.HeaderDataKey {
+			msg.Headers = append(msg.Headers, business.Header(header))
+		}
+	}
+
+	return msg, nil
+}
+
+// scrubProtoData keeps only the ID of the user and the times of the protobuf data of the event
+func scrubProtoData(event cloudevents.Event) ([]byte, error) {
+	var data proto.Message
+
+	switch event.Type {
+	case cloudevents.TypeUserCreated:
+		data = &pb.UserCreated{}
+	case cloudevents.TypeUserUpdated:
+		data = &pb.UserUpdated{}
+	case cloudevents.TypeUserEmailVerificationRequested:
+		data = &pb.UserEmailVerificationRequested{}
+	case cloudevents.TypeUserEmailVerified:
+		data = &pb.UserEmailVerified{}
+	}
+
+	if err := event.DecodeData(data); err != nil {
+		return nil, err
+	}
+
+	switch data := data.(type) {
+	case *pb.UserCreated:
+		scrubProtoUser(data.GetUser())
+	case *pb.UserUpdated:
+		scrubProtoUser(data.GetUser())
+	case *pb.UserEmailVerificationRequested:
+		data.Email, data.Token = "", ""
+	case *pb.UserEmailVerified:
+		data.Email = ""
+	}
+
+	return proto.Marshal(data)
+}
+
+func scrubProtoUser(user *pb.User) {
+	if user == nil {
+		return
+	}
+
+	id := user.GetId()
+	proto.Reset(user)
+	user.Id = id
+}
+
+// scrubJSONData keeps only the ID of the user of the JSON data of the event
+func scrubJSONData(event cloudevents.Event) ([]byte, error) {
+	if event.Type == cloudevents.TypeUserEmailVerificationRequested {
+		var verification EmailVerification
+
+		if err := event.DecodeData(&verification); err != nil {
+			return nil, err
+		}
+
+		return (&EmailVerification{UserID: verification.UserID}).MarshalBinary()
+	}
+
+	var user User
+
+	if err := event.DecodeData(&user); err != nil {
+		return nil, err
+	}
+
+	return (&User{ID: user.ID}).MarshalBinary()
+}
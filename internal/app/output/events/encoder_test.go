@@ -0,0 +1,338 @@
+package events
+
+import (
+	"bytes"
+	"github.com/google/uuid"
+	"github.com/yael-castro/goarch/internal/app/business"
+	"github.com/yael-castro/goarch/pkg/cloudevents"
+	"github.com/yael-castro/goarch/pkg/envelope"
+	"github.com/yael-castro/goarch/pkg/jsont"
+	"github.com/yael-castro/goarch/pkg/pb"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestEventEncoder_EncodeEvent(t *testing.T) {
+	const (
+		createTopic            = "user_creation"
+		updateTopic            = "user_update"
+		emailVerificationTopic = "user_email_verification"
+		verifiedEmailTopic     = "user_email_verified"
+	)
+
+	user := business.User{
+		ID:        1,
+		Name:      "Yael",
+		Email:     "contacto@yael.mx",
+		BirthDate: "2001-07-14",
+	}
+
+	occurredAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// Decoders of the encrypted email of the data, the age is computed at the time of the event
+	decodeUser := func(event cloudevents.Event) (string, error) {
+		var data jsont.User
+		err := event.DecodeData(&data)
+
+		if data.Age != 23 || data.BirthDate != user.BirthDate.String() {
+			t.Errorf("expected age 23 and birth date '%s' got '%+v'", user.BirthDate, data)
+		}
+
+		return data.Email, err
+	}
+
+	decodeProtoUser := func(event cloudevents.Event) (string, error) {
+		var data pb.UserUpdated
+		err := event.DecodeData(&data)
+
+		if data.GetUser().GetAge() != 23 || data.GetUser().GetBirthDate() != user.BirthDate.String() {
+			t.Errorf("expected age 23 and birth date '%s' got '%v'", user.BirthDate, data.GetUser())
+		}
+
+		return data.GetUser().GetEmail(), err
+	}
+
+	cases := [...]struct {
+		event               business.Event
+		expectedTopic       string
+		expectedType        string
+		expectedContentType string
+		decodeEmail         func(cloudevents.Event) (string, error)
+	}{
+		// Test case: JSON topic
+		{
+			event:               business.UserCreated{User: user, Time: occurredAt},
+			expectedTopic:       createTopic,
+			expectedType:        cloudevents.TypeUserCreated,
+			expectedContentType: cloudevents.ContentTypeJSON,
+			decodeEmail:         decodeUser,
+		},
+		// Test case: protobuf topic
+		{
+			event:               business.UserUpdated{User: user, Time: occurredAt},
+			expectedTopic:       updateTopic,
+			expectedType:        cloudevents.TypeUserUpdated,
+			expectedContentType: cloudevents.ContentTypeProtobuf,
+			decodeEmail:         decodeProtoUser,
+		},
+		// Test case: email verification request, the token is encrypted as well
+		{
+			event: business.UserEmailVerificationRequested{
+				UserID:    user.ID,
+				Email:     user.Email,
+				Token:     "gev_token",
+				ExpiresAt: occurredAt.Add(time.Hour),
+				Time:      occurredAt,
+			},
+			expectedTopic:       emailVerificationTopic,
+			expectedType:        cloudevents.TypeUserEmailVerificationRequested,
+			expectedContentType: cloudevents.ContentTypeJSON,
+			decodeEmail: func(event cloudevents.Event) (string, error) {
+				var data jsont.EmailVerification
+				err := event.DecodeData(&data)
+
+				if data.Token == "gev_token" || data.ExpiresAt == nil {
+					t.Errorf("expected an encrypted token and its expiration got '%+v'", data)
+				}
+
+				return data.Email, err
+			},
+		},
+		// Test case: verified email
+		{
+			event:               business.UserEmailVerified{UserID: user.ID, Email: user.Email, Time: occurredAt},
+			expectedTopic:       verifiedEmailTopic,
+			expectedType:        cloudevents.TypeUserEmailVerified,
+			expectedContentType: cloudevents.ContentTypeProtobuf,
+			decodeEmail: func(event cloudevents.Event) (string, error) {
+				var data pb.UserEmailVerified
+				err := event.DecodeData(&data)
+				return data.GetEmail(), err
+			},
+		},
+	}
+
+	keyring, err := envelope.NewKeyring(
+		"primary",
+		map[string][]byte{"primary": bytes.Repeat([]byte{1}, envelope.KeySize)},
+		bytes.Repeat([]byte{2}, envelope.KeySize),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encoder := NewEventEncoder(EventEncoderConfig{
+		CreateUserTopic:               createTopic,
+		UpdateUserTopic:               updateTopic,
+		RequestEmailVerificationTopic: emailVerificationTopic,
+		VerifyEmailTopic:              verifiedEmailTopic,
+		Source:                        "/goarch/users-http",
+		Encodings: map[string]Encoding{
+			updateTopic:        EncodingProtobuf,
+			verifiedEmailTopic: EncodingProtobuf,
+		},
+		Keyring: keyring,
+	})
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			msg, err := encoder.EncodeEvent(c.event)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if msg.Topic != c.expectedTopic {
+				t.Fatalf("expected topic '%s' got '%s'", c.expectedTopic, msg.Topic)
+			}
+
+			headers := make([]cloudevents.Header, len(msg.Headers))
+			for i, header := range msg.Headers {
+				headers[i] = cloudevents.Header(header)
+			}
+
+			event, err := cloudevents.Decode(headers, msg.Value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			eventID, _ := uuid.FromBytes(msg.IdempotencyKey)
+
+			if event.ID != eventID.String() {
+				t.Fatalf("expected event id '%s' got '%s'", eventID, event.ID)
+			}
+
+			if event.Type != c.expectedType {
+				t.Fatalf("expected event type '%s' got '%s'", c.expectedType, event.Type)
+			}
+
+			if event.DataContentType != c.expectedContentType {
+				t.Fatalf("expected content type '%s' got '%s'", c.expectedContentType, event.DataContentType)
+			}
+
+			if event.Subject != "1" || string(msg.Key) != "1" {
+				t.Fatalf("expected subject and key '1' got '%s' and '%s'", event.Subject, msg.Key)
+			}
+
+			if !event.Time.Equal(occurredAt) {
+				t.Fatalf("expected time '%v' got '%v'", occurredAt, event.Time)
+			}
+
+			// Decoding data
+			email, err := c.decodeEmail(event)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// The PII is decrypted only with the keyring
+			if email == user.Email.String() {
+				t.Fatal("expected an encrypted email")
+			}
+
+			dataKey, err := keyring.Open(envelope.Envelope{KeyID: event.KeyID, DataKey: event.DataKey})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			email, err = dataKey.DecryptString(email, "email")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if email != user.Email.String() {
+				t.Fatalf("expected email '%s' got '%s'", user.Email, email)
+			}
+		})
+	}
+}
+
+func TestEventEncoder_ScrubMessage(t *testing.T) {
+	const (
+		createTopic = "user_creation"
+		updateTopic = "user_update"
+		deleteTopic = "user_deletion"
+	)
+
+	keyring, err := envelope.NewKeyring(
+		"primary",
+		map[string][]byte{"primary": bytes.Repeat([]byte{1}, envelope.KeySize)},
+		bytes.Repeat([]byte{2}, envelope.KeySize),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encoder := NewEventEncoder(EventEncoderConfig{
+		CreateUserTopic: createTopic,
+		UpdateUserTopic: updateTopic,
+		DeleteUserTopic: deleteTopic,
+		Source:          "/goarch/users-http",
+
+		RequestEmailVerificationTopic: "user_email_verification",
+		Encodings: map[string]Encoding{
+			updateTopic: EncodingProtobuf,
+		},
+		Keyring: keyring,
+	})
+
+	user := business.User{
+		ID:        1,
+		Name:      "Yael",
+		Email:     "contacto@yael.mx",
+		BirthDate: "2001-07-14",
+	}
+
+	cases := [...]struct {
+		event business.Event
+	}{
+		// Test case: JSON data
+		{
+			event: business.UserCreated{User: user, Time: time.Now()},
+		},
+		// Test case: protobuf data
+		{
+			event: business.UserUpdated{User: user, Time: time.Now()},
+		},
+		// Test case: email verification request
+		{
+			event: business.UserEmailVerificationRequested{UserID: user.ID, Email: user.Email, Token: "gev_token", Time: time.Now()},
+		},
+		// Test case: data without personal data
+		{
+			event: business.UserDeleted{UserID: user.ID, Time: time.Now()},
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			msg, err := encoder.EncodeEvent(c.event)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			scrubbed, err := encoder.ScrubMessage(msg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(scrubbed.IdempotencyKey, msg.IdempotencyKey) {
+				t.Fatal("expected the same idempotency key")
+			}
+
+			headers := make([]cloudevents.Header, len(scrubbed.Headers))
+			for i, header := range scrubbed.Headers {
+				headers[i] = cloudevents.Header(header)
+			}
+
+			event, err := cloudevents.Decode(headers, scrubbed.Value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(event.KeyID) > 0 || len(event.DataKey) > 0 {
+				t.Fatal("expected the envelope to be removed")
+			}
+
+			var id int64
+
+			switch event.DataContentType {
+			case cloudevents.ContentTypeProtobuf:
+				var data pb.UserUpdated
+				err = event.DecodeData(&data)
+				id = data.GetUser().GetId()
+
+				if data.GetUser().GetEmail() != "" || data.GetUser().GetName() != "" || data.GetUser().GetBirthDate() != "" {
+					t.Fatalf("expected only the user id got '%v'", data.GetUser())
+				}
+			case cloudevents.ContentTypeJSON:
+				if event.Type == cloudevents.TypeUserEmailVerificationRequested {
+					var data jsont.EmailVerification
+					err = event.DecodeData(&data)
+					id = data.UserID
+
+					if data != (jsont.EmailVerification{UserID: id}) {
+						t.Fatalf("expected only the user id got '%+v'", data)
+					}
+
+					break
+				}
+
+				var data jsont.User
+				err = event.DecodeData(&data)
+				id = data.ID
+
+				if data != (jsont.User{ID: id}) {
+					t.Fatalf("expected only the user id got '%+v'", data)
+				}
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if id != int64(user.ID) {
+				t.Fatalf("expected user id %d got %d", user.ID, id)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package events
+
+import (
+	"encoding/json"
+	"github.com/yael-castro/goarch/internal/app/business"
+	"github.com/yael-castro/goarch/pkg/cloudevents"
+	"github.com/yael-castro/goarch/pkg/jsont"
+	"github.com/yael-castro/goarch/pkg/pb"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"time"
+)
+
+// NewUser builds the JSON data of a user, its age is computed at the time of the event
+func NewUser(u *business.User, at time.Time) *User {
+	return &User{
+		ID:        int64(u.ID),
+		Name:      u.Name.String(),
+		Email:     u.Email.String(),
+		Age:       uint8(u.BirthDate.Age(at)),
+		BirthDate: u.BirthDate.String(),
+	}
+}
+
+// NewUserFromEvent builds the JSON data of a business.Event
+func NewUserFromEvent(event business.Event) *User {
+	switch event := event.(type) {
+	case business.UserCreated:
+		return NewUser(&event.User, event.Time)
+	case business.UserUpdated:
+		return NewUser(&event.User, event.Time)
+	case business.UserDeleted:
+		return &User{ID: int64(event.UserID)}
+	case business.UserErased:
+		return &User{ID: int64(event.UserID)}
+	case business.UserEmailVerified:
+		return &User{ID: int64(event.UserID), Email: event.Email.String()}
+	}
+
+	return &User{}
+}
+
+// NewJSONData builds the JSON data of a business.Event and returns the URI of its schema
+func NewJSONData(event business.Event) ([]byte, string, error) {
+	if event, ok := event.(business.UserEmailVerificationRequested); ok {
+		data, err := NewEmailVerification(&event).MarshalBinary()
+		return data, cloudevents.DataSchemaEmailVerificationV1, err
+	}
+
+	data, err := NewUserFromEvent(event).MarshalBinary()
+	return data, cloudevents.DataSchemaUserV1, err
+}
+
+type User jsont.User
+
+func (u *User) MarshalBinary() ([]byte, error) {
+	return json.Marshal((*jsont.User)(u))
+}
+
+func NewEmailVerification(event *business.UserEmailVerificationRequested) *EmailVerification {
+	verification := &EmailVerification{
+		UserID: int64(event.UserID),
+		Email:  event.Email.String(),
+		Token:  event.Token.Reveal(),
+	}
+
+	if !event.ExpiresAt.IsZero() {
+		verification.ExpiresAt = &event.ExpiresAt
+	}
+
+	return verification
+}
+
+type EmailVerification jsont.EmailVerification
+
+func (e *EmailVerification) MarshalBinary() ([]byte, error) {
+	return json.Marshal((*jsont.EmailVerification)(e))
+}
+
+// NewProtoUser builds the protobuf data of a user, its age is computed at the time of the event
+func NewProtoUser(u *business.User, at time.Time) *pb.User {
+	return &pb.User{
+		Id:        int64(u.ID),
+		Name:      u.Name.String(),
+		Email:     u.Email.String(),
+		Age:       uint32(u.BirthDate.Age(at)),
+		BirthDate: u.BirthDate.String(),
+	}
+}
+
+// NewProtoEvent builds the protobuf data of a business.Event
+func NewProtoEvent(event business.Event) proto.Message {
+	occurredAt := timestamppb.New(event.OccurredAt())
+
+	switch event := event.(type) {
+	case business.UserCreated:
+		return &pb.UserCreated{User: NewProtoUser(&event.User, event.Time), OccurredAt: occurredAt}
+	case business.UserUpdated:
+		return &pb.UserUpdated{User: NewProtoUser(&event.User, event.Time), OccurredAt: occurredAt}
+	case business.UserDeleted:
+		return &pb.UserDeleted{UserId: int64(event.UserID), OccurredAt: occurredAt}
+	case business.UserErased:
+		return &pb.UserErased{UserId: int64(event.UserID), OccurredAt: occurredAt}
+	case business.UserEmailVerificationRequested:
+		return &pb.UserEmailVerificationRequested{
+			UserId:     int64(event.UserID),
+			Email:      event.Email.String(),
+			Token:      event.Token.Reveal(),
+			ExpiresAt:  timestamppb.New(event.ExpiresAt),
+			OccurredAt: occurredAt,
+		}
+	case business.UserEmailVerified:
+		return &pb.UserEmailVerified{UserId: int64(event.UserID), Email: event.Email.String(), OccurredAt: occurredAt}
+	}
+
+	return nil
+}
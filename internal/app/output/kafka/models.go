@@ -14,7 +14,7 @@ func NewMessage(message *business.Message) (msg *Message, err error) {
 		Value: message.Value,
 	}
 
-	if len(message.Headers) > 0 {
+	if len(message.Headers) < 1 {
 		return
 	}
 
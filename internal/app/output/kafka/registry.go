@@ -0,0 +1,261 @@
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/linkedin/goavro/v2"
+	"github.com/yael-castro/goarch/internal/app/business"
+	"github.com/yael-castro/goarch/pkg/cloudevents"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Supported values for SchemaType
+const (
+	SchemaTypeAvro     SchemaType = "AVRO"
+	SchemaTypeProtobuf SchemaType = "PROTOBUF"
+)
+
+// SchemaType is the format of a Schema supported by the schema registry
+type SchemaType string
+
+// Schema describes the value of the messages of a topic
+type Schema struct {
+	Type       SchemaType
+	Definition string
+	// MessageIndexes locates the message of the value in the Definition (only for SchemaTypeProtobuf)
+	MessageIndexes []int
+}
+
+// ValueEncoder defines a way to encode the value of a Message before it is produced
+type ValueEncoder interface {
+	EncodeValue(context.Context, *Message) error
+}
+
+type SchemaRegistryEncoderConfig struct {
+	URL    string
+	Client *http.Client
+	// Schemas indicates the Schema by topic, the values of the topics that are not present are not encoded
+	Schemas map[string]Schema
+}
+
+// NewSchemaRegistryEncoder builds a ValueEncoder that encodes the values in the Confluent wire format
+// (magic byte, schema ID and payload), registering the schema of each topic before its first message is produced.
+//
+// The JSON values of Avro topics are converted into Avro binary and the values of protobuf topics are kept as they are.
+func NewSchemaRegistryEncoder(config SchemaRegistryEncoderConfig) (ValueEncoder, error) {
+	if len(config.URL) == 0 || config.Client == nil {
+		return nil, errors.New("schema registry url or client is missing")
+	}
+
+	codecs := make(map[string]*goavro.Codec)
+
+	for topic, schema := range config.Schemas {
+		switch schema.Type {
+		case SchemaTypeAvro:
+			codec, err := goavro.NewCodec(schema.Definition)
+			if err != nil {
+				return nil, fmt.Errorf("invalid avro schema for topic '%s': %w", topic, err)
+			}
+
+			codecs[topic] = codec
+		case SchemaTypeProtobuf:
+			if len(schema.MessageIndexes) == 0 {
+				return nil, fmt.Errorf("message indexes are required for topic '%s'", topic)
+			}
+		default:
+			return nil, fmt.Errorf("schema type '%s' is not supported", schema.Type)
+		}
+	}
+
+	return &schemaRegistryEncoder{
+		url:       strings.TrimSuffix(config.URL, "/"),
+		client:    config.Client,
+		schemas:   config.Schemas,
+		codecs:    codecs,
+		schemaIDs: make(map[string]uint32),
+	}, nil
+}
+
+type schemaRegistryEncoder struct {
+	sync.Mutex
+	url       string
+	client    *http.Client
+	schemas   map[string]Schema
+	codecs    map[string]*goavro.Codec
+	schemaIDs map[string]uint32
+}
+
+func (s *schemaRegistryEncoder) EncodeValue(ctx context.Context, msg *Message) (err error) {
+	topic := *msg.TopicPartition.Topic
+
+	schema, ok := s.schemas[topic]
+	if !ok {
+		return
+	}
+
+	// Surfacing compatibility errors before publishing
+	schemaID, err := s.schemaID(ctx, topic, schema)
+	if err != nil {
+		return
+	}
+
+	carrier := headersCarrier{headers: &msg.Headers}
+	contentType := carrier.Get(cloudevents.HeaderDataContentType)
+
+	// Confluent wire format: magic byte + schema ID + (message indexes) + payload
+	const magicByte = 0
+
+	value := binary.BigEndian.AppendUint32([]byte{magicByte}, schemaID)
+
+	switch schema.Type {
+	case SchemaTypeAvro:
+		if contentType != "" && contentType != cloudevents.ContentTypeJSON {
+			return fmt.Errorf("%w: topic '%s' expects JSON values to encode them as Avro, got '%s'", business.ErrIncompatibleMessageSchema.With("topic", topic), topic, contentType)
+		}
+
+		codec := s.codecs[topic]
+
+		native, _, err := codec.NativeFromTextual(msg.Value)
+		if err != nil {
+			return fmt.Errorf("%w: %v", business.ErrIncompatibleMessageSchema.With("topic", topic), err)
+		}
+
+		value, err = codec.BinaryFromNative(value, native)
+		if err != nil {
+			return fmt.Errorf("%w: %v", business.ErrIncompatibleMessageSchema.With("topic", topic), err)
+		}
+
+		carrier.Set(cloudevents.HeaderDataContentType, cloudevents.ContentTypeAvro)
+	case SchemaTypeProtobuf:
+		if contentType != cloudevents.ContentTypeProtobuf {
+			return fmt.Errorf("%w: topic '%s' expects protobuf values, got '%s'", business.ErrIncompatibleMessageSchema.With("topic", topic), topic, contentType)
+		}
+
+		value = appendMessageIndexes(value, schema.MessageIndexes)
+		value = append(value, msg.Value...)
+	}
+
+	msg.Value = value
+	return
+}
+
+// appendMessageIndexes appends the indexes as an array of zig-zag varints, the first message ([0]) is encoded as a single 0
+func appendMessageIndexes(b []byte, indexes []int) []byte {
+	if len(indexes) == 1 && indexes[0] == 0 {
+		return append(b, 0)
+	}
+
+	b = binary.AppendVarint(b, int64(len(indexes)))
+
+	for _, index := range indexes {
+		b = binary.AppendVarint(b, int64(index))
+	}
+
+	return b
+}
+
+// schemaID returns the ID of the schema of topic, checking its compatibility and registering it the first time
+func (s *schemaRegistryEncoder) schemaID(ctx context.Context, topic string, schema Schema) (uint32, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	if schemaID, ok := s.schemaIDs[topic]; ok {
+		return schemaID, nil
+	}
+
+	// Topic name strategy
+	subject := url.PathEscape(topic + "-value")
+
+	request := registrySchema{
+		Schema:     schema.Definition,
+		SchemaType: schema.Type,
+	}
+
+	// Checking compatibility with the latest version
+	var compatibility registryCompatibility
+
+	status, err := s.do(ctx, "/compatibility/subjects/"+subject+"/versions/latest", request, &compatibility)
+	if err != nil && status != http.StatusNotFound { // The subject does not exist yet
+		return 0, err
+	}
+
+	if status == http.StatusOK && !compatibility.IsCompatible {
+		return 0, fmt.Errorf("%w: schema of topic '%s' is not compatible: %s", business.ErrIncompatibleMessageSchema.With("topic", topic), topic, strings.Join(compatibility.Messages, "; "))
+	}
+
+	// Registering schema (the registry returns the same ID if it is already registered)
+	var registered registrySchemaID
+
+	status, err = s.do(ctx, "/subjects/"+subject+"/versions", request, &registered)
+	if err != nil {
+		if status == http.StatusConflict || status == http.StatusUnprocessableEntity {
+			err = fmt.Errorf("%w: %v", business.ErrIncompatibleMessageSchema.With("topic", topic), err)
+		}
+
+		return 0, err
+	}
+
+	s.schemaIDs[topic] = registered.ID
+	return registered.ID, nil
+}
+
+// do sends a request to the schema registry and decodes the response in out
+func (s *schemaRegistryEncoder) do(ctx context.Context, path string, in, out any) (int, error) {
+	const contentType = "application/vnd.schemaregistry.v1+json"
+
+	body, err := json.Marshal(in)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url+path, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Accept", contentType)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		var registryErr registryError
+		_ = json.NewDecoder(resp.Body).Decode(&registryErr)
+
+		return resp.StatusCode, fmt.Errorf("schema registry error %d (%d): %s", registryErr.ErrorCode, resp.StatusCode, registryErr.Message)
+	}
+
+	return resp.StatusCode, json.NewDecoder(resp.Body).Decode(out)
+}
+
+type registrySchema struct {
+	Schema     string     `json:"schema"`
+	SchemaType SchemaType `json:"schemaType"`
+}
+
+type registrySchemaID struct {
+	ID uint32 `json:"id"`
+}
+
+type registryCompatibility struct {
+	IsCompatible bool     `json:"is_compatible"`
+	Messages     []string `json:"messages"`
+}
+
+type registryError struct {
+	ErrorCode int    `json:"error_code"`
+	Message   string `json:"message"`
+}
@@ -0,0 +1,202 @@
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/linkedin/goavro/v2"
+	"github.com/yael-castro/goarch/internal/app/business"
+	"github.com/yael-castro/goarch/pkg/avsc"
+	"github.com/yael-castro/goarch/pkg/cloudevents"
+	"github.com/yael-castro/goarch/pkg/pb"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// registryStandIn is a local schema registry that registers every compatible schema
+type registryStandIn struct {
+	sync.Mutex
+	incompatible  bool
+	registrations int
+	schemaIDs     map[string]uint32
+}
+
+func (r *registryStandIn) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.Lock()
+	defer r.Unlock()
+
+	var schema registrySchema
+
+	if err := json.NewDecoder(req.Body).Decode(&schema); err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.schemaregistry.v1+json")
+
+	switch {
+	case strings.HasPrefix(req.URL.Path, "/compatibility/subjects/"):
+		if !r.incompatible {
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(registryError{ErrorCode: 40401, Message: "Subject not found"})
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(registryCompatibility{Messages: []string{"READER_FIELD_MISSING_DEFAULT_VALUE"}})
+	case strings.HasPrefix(req.URL.Path, "/subjects/") && strings.HasSuffix(req.URL.Path, "/versions"):
+		if r.incompatible {
+			w.WriteHeader(http.StatusConflict)
+			_ = json.NewEncoder(w).Encode(registryError{ErrorCode: 409, Message: "Schema being registered is incompatible"})
+			return
+		}
+
+		r.registrations++
+
+		schemaID, ok := r.schemaIDs[schema.Schema]
+		if !ok {
+			schemaID = uint32(len(r.schemaIDs) + 1)
+			r.schemaIDs[schema.Schema] = schemaID
+		}
+
+		_ = json.NewEncoder(w).Encode(registrySchemaID{ID: schemaID})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestSchemaRegistryEncoder_EncodeValue(t *testing.T) {
+	const (
+		avroTopic     = "user_creation"
+		protobufTopic = "user_deletion"
+	)
+
+	codec, err := goavro.NewCodec(avsc.User)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	avroValue, err := codec.BinaryFromNative(nil, map[string]any{"id": int64(1), "name": "Yael", "email": "", "age": int32(0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	protobufIndex := (&pb.UserDeleted{}).ProtoReflect().Descriptor().Index()
+
+	cases := [...]struct {
+		topic               string
+		value               []byte
+		contentType         string
+		incompatible        bool
+		expectedValue       []byte
+		expectedContentType string
+		expectedErr         error
+	}{
+		// Test case: JSON value of an Avro topic
+		{
+			topic:               avroTopic,
+			value:               []byte(`{"id":1,"name":"Yael"}`),
+			contentType:         cloudevents.ContentTypeJSON,
+			expectedValue:       append([]byte{0, 0, 0, 0, 1}, avroValue...),
+			expectedContentType: cloudevents.ContentTypeAvro,
+		},
+		// Test case: protobuf value (third message of user.proto)
+		{
+			topic:               protobufTopic,
+			value:               []byte{8, 1},
+			contentType:         cloudevents.ContentTypeProtobuf,
+			expectedValue:       []byte{0, 0, 0, 0, 1, 2, byte(protobufIndex << 1), 8, 1},
+			expectedContentType: cloudevents.ContentTypeProtobuf,
+		},
+		// Test case: topic without schema
+		{
+			topic:               "user_update",
+			value:               []byte(`{"id":1}`),
+			contentType:         cloudevents.ContentTypeJSON,
+			expectedValue:       []byte(`{"id":1}`),
+			expectedContentType: cloudevents.ContentTypeJSON,
+		},
+		// Test case: content type that does not match the schema
+		{
+			topic:       protobufTopic,
+			value:       []byte(`{"id":1}`),
+			contentType: cloudevents.ContentTypeJSON,
+			expectedErr: business.ErrIncompatibleMessageSchema,
+		},
+		// Test case: schema incompatible with the latest version
+		{
+			topic:        avroTopic,
+			value:        []byte(`{"id":1}`),
+			contentType:  cloudevents.ContentTypeJSON,
+			incompatible: true,
+			expectedErr:  business.ErrIncompatibleMessageSchema,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			registry := &registryStandIn{
+				incompatible: c.incompatible,
+				schemaIDs:    make(map[string]uint32),
+			}
+
+			server := httptest.NewServer(registry)
+			defer server.Close()
+
+			encoder, err := NewSchemaRegistryEncoder(SchemaRegistryEncoderConfig{
+				URL:    server.URL,
+				Client: server.Client(),
+				Schemas: map[string]Schema{
+					avroTopic: {
+						Type:       SchemaTypeAvro,
+						Definition: avsc.User,
+					},
+					protobufTopic: {
+						Type:           SchemaTypeProtobuf,
+						Definition:     pb.UserProto,
+						MessageIndexes: []int{protobufIndex},
+					},
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Encoding twice to make sure the schema ID is cached
+			for range 2 {
+				msg := &Message{
+					TopicPartition: TopicPartition{Topic: &c.topic},
+					Value:          c.value,
+					Headers:        []Header{{Key: cloudevents.HeaderDataContentType, Value: []byte(c.contentType)}},
+				}
+
+				err = encoder.EncodeValue(context.Background(), msg)
+				if !errors.Is(err, c.expectedErr) {
+					t.Fatalf("expected error '%v' got '%v'", c.expectedErr, err)
+				}
+
+				if err != nil {
+					return
+				}
+
+				if !bytes.Equal(msg.Value, c.expectedValue) {
+					t.Fatalf("expected value '%v' got '%v'", c.expectedValue, msg.Value)
+				}
+
+				carrier := headersCarrier{headers: &msg.Headers}
+
+				if contentType := carrier.Get(cloudevents.HeaderDataContentType); contentType != c.expectedContentType {
+					t.Fatalf("expected content type '%s' got '%s'", c.expectedContentType, contentType)
+				}
+			}
+
+			if registry.registrations > 1 {
+				t.Fatalf("expected at most 1 registration got %d", registry.registrations)
+			}
+		})
+	}
+}
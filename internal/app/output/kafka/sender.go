@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
 	"github.com/yael-castro/goarch/internal/app/business"
+	"go.opentelemetry.io/otel/trace"
 	"log/slog"
 	"reflect"
 	"sync"
@@ -14,12 +15,15 @@ import (
 type MessageSenderConfig struct {
 	Producer *kafka.Producer
 	Logger   *slog.Logger
+	// Encoder encodes the values before they are produced (optional)
+	Encoder ValueEncoder
 }
 
 func NewMessageSender(config MessageSenderConfig) business.MessageSender {
 	return &messageSender{
 		producer: config.Producer,
 		logger:   config.Logger,
+		encoder:  config.Encoder,
 	}
 }
 
@@ -27,6 +31,7 @@ type messageSender struct {
 	sync.Mutex
 	producer *kafka.Producer
 	logger   *slog.Logger
+	encoder  ValueEncoder
 }
 
 func (p *messageSender) SendMessage(ctx context.Context, messages ...business.Message) error {
@@ -38,10 +43,21 @@ func (p *messageSender) SendMessage(ctx context.Context, messages ...business.Me
 	return p.sendMessage(ctx, messages...)
 }
 
-func (p *messageSender) sendMessage(ctx context.Context, messages ...business.Message) error {
+func (p *messageSender) sendMessage(ctx context.Context, messages ...business.Message) (err error) {
 	p.Lock()
 	defer p.Unlock()
 
+	// Ending the producer spans of the messages without delivery report
+	spans := make([]trace.Span, 0, len(messages))
+
+	defer func() {
+		for _, span := range spans {
+			if span.IsRecording() {
+				endProducerSpan(span, err)
+			}
+		}
+	}()
+
 	// Trying to send Kafka's message
 	deliveryChan := make(chan kafka.Event, len(messages))
 
@@ -61,6 +77,18 @@ func (p *messageSender) sendMessage(ctx context.Context, messages ...business.Me
 			return err
 		}
 
+		if p.encoder != nil {
+			err = p.encoder.EncodeValue(ctx, message)
+			if err != nil {
+				return err
+			}
+		}
+
+		span := startProducerSpan(ctx, message)
+		spans = append(spans, span)
+
+		message.Opaque = span
+
 		err = p.producer.Produce(message, deliveryChan)
 		if err != nil {
 			return err
@@ -94,6 +122,10 @@ func (p *messageSender) sendMessage(ctx context.Context, messages ...business.Me
 func (p *messageSender) evaluateEvt(ctx context.Context, evt kafka.Event) error {
 	switch evt := evt.(type) {
 	case *kafka.Message:
+		if span, ok := evt.Opaque.(trace.Span); ok {
+			endProducerSpan(span, evt.TopicPartition.Error)
+		}
+
 		if evt.TopicPartition.Error != nil {
 			return evt.TopicPartition.Error
 		}
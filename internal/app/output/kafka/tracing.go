@@ -0,0 +1,87 @@
+package kafka
+
+import (
+	"context"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/yael-castro/goarch/internal/app/output/kafka")
+
+// startProducerSpan resumes the trace context stored in the message headers and starts a producer span as its child.
+// The trace context of the producer span replaces the stored one, so consumers continue the same trace
+func startProducerSpan(ctx context.Context, msg *Message) trace.Span {
+	carrier := headersCarrier{headers: &msg.Headers}
+	propagator := otel.GetTextMapPropagator()
+
+	// Only the trace context is resumed, the cancellation and values belong to the relay
+	parent := trace.SpanContextFromContext(propagator.Extract(context.Background(), carrier))
+
+	ctx, span := tracer.Start(
+		trace.ContextWithRemoteSpanContext(ctx, parent),
+		"send "+*msg.TopicPartition.Topic,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKafka,
+			semconv.MessagingOperationTypePublish,
+			semconv.MessagingDestinationName(*msg.TopicPartition.Topic),
+		),
+	)
+
+	propagator.Inject(ctx, carrier)
+	return span
+}
+
+// endProducerSpan records err (if any) in span before ending it
+func endProducerSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
+
+var _ propagation.TextMapCarrier = headersCarrier{}
+
+// headersCarrier allows to propagate the trace context through the headers of a Kafka record
+type headersCarrier struct {
+	headers *[]Header
+}
+
+func (h headersCarrier) Get(key string) string {
+	for _, header := range *h.headers {
+		if header.Key == key {
+			return string(header.Value)
+		}
+	}
+
+	return ""
+}
+
+func (h headersCarrier) Set(key string, value string) {
+	for i, header := range *h.headers {
+		if header.Key == key {
+			(*h.headers)[i].Value = []byte(value)
+			return
+		}
+	}
+
+	*h.headers = append(*h.headers, Header{
+		Key:   key,
+		Value: []byte(value),
+	})
+}
+
+func (h headersCarrier) Keys() []string {
+	keys := make([]string, len(*h.headers))
+
+	for i, header := range *h.headers {
+		keys[i] = header.Key
+	}
+
+	return keys
+}
@@ -0,0 +1,65 @@
+package kafka
+
+import (
+	"context"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"testing"
+)
+
+func TestStartProducerSpan(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	// Trace context stored by the HTTP request
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	topic := "user_creation"
+	msg := &Message{
+		TopicPartition: TopicPartition{
+			Topic: &topic,
+		},
+		Headers: []Header{
+			{Key: "idempotency_key", Value: []byte("key")},
+			{Key: "traceparent", Value: []byte(traceparent)},
+		},
+	}
+
+	span := startProducerSpan(context.Background(), msg)
+	endProducerSpan(span, nil)
+
+	ended := recorder.Ended()
+	if len(ended) != 1 {
+		t.Fatalf("expected 1 span got %d", len(ended))
+	}
+
+	producerSpan := ended[0]
+
+	const expectedTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+	if traceID := producerSpan.SpanContext().TraceID().String(); traceID != expectedTraceID {
+		t.Fatalf("expected trace id '%s' got '%s'", expectedTraceID, traceID)
+	}
+
+	if producerSpan.SpanKind() != trace.SpanKindProducer {
+		t.Fatalf("expected span kind '%v' got '%v'", trace.SpanKindProducer, producerSpan.SpanKind())
+	}
+
+	// The record must carry the trace context of the producer span
+	carrier := headersCarrier{headers: &msg.Headers}
+
+	expectedTraceparent := "00-" + expectedTraceID + "-" + producerSpan.SpanContext().SpanID().String() + "-01"
+
+	if got := carrier.Get("traceparent"); got != expectedTraceparent {
+		t.Fatalf("expected traceparent '%s' got '%s'", expectedTraceparent, got)
+	}
+
+	if len(msg.Headers) != 2 {
+		t.Fatalf("expected 2 headers got %d", len(msg.Headers))
+	}
+}
@@ -0,0 +1,113 @@
+//go:build http || grpc
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"github.com/yael-castro/goarch/internal/app/business"
+	"log/slog"
+	"strconv"
+)
+
+type APIKeyStoreConfig struct {
+	Logger *slog.Logger
+	DB     *sql.DB
+}
+
+// NewAPIKeyStore builds a business.APIKeyStore, only the hashes of the keys are stored
+func NewAPIKeyStore(config APIKeyStoreConfig) business.APIKeyStore {
+	return apiKeyStore{
+		logger: config.Logger,
+		db:     config.DB,
+	}
+}
+
+type apiKeyStore struct {
+	logger *slog.Logger
+	db     *sql.DB
+}
+
+func (a apiKeyStore) CreateAPIKey(ctx context.Context, key *business.APIKey) (err error) {
+	ctx, span := startSpan(ctx, "INSERT", "api_keys", insertAPIKey)
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	scopes := make([]string, len(key.Scopes))
+
+	for i, scope := range key.Scopes {
+		scopes[i] = string(scope)
+	}
+
+	err = a.db.QueryRowContext(ctx, insertAPIKey, key.Name, key.Hash, key.Prefix, pq.Array(scopes)).Scan(&key.ID, &key.CreatedAt)
+	if err != nil {
+		return
+	}
+
+	a.logger.InfoContext(ctx, "api_key_issued", "api_key_id", key.ID, "api_key_prefix", key.Prefix, "name", key.Name)
+	return
+}
+
+func (a apiKeyStore) RevokeAPIKey(ctx context.Context, id business.APIKeyID) (err error) {
+	ctx, span := startSpan(ctx, "UPDATE", "api_keys", revokeAPIKey)
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	result, err := a.db.ExecContext(ctx, revokeAPIKey, id)
+	if err != nil {
+		return
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return
+	}
+
+	if affected != 1 {
+		err = fmt.Errorf("%w: unable to revoke API key %d", business.ErrAPIKeyNotFound.With("api_key_id", strconv.FormatUint(uint64(id), 10)), id)
+		return
+	}
+
+	a.logger.InfoContext(ctx, "api_key_revoked", "api_key_id", id)
+	return
+}
+
+func (a apiKeyStore) UseAPIKey(ctx context.Context, hash []byte) (key business.APIKey, err error) {
+	ctx, span := startSpan(ctx, "SELECT", "api_keys", useAPIKey)
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	var scopes []string
+
+	err = a.db.QueryRowContext(ctx, useAPIKey, hash).Scan(
+		&key.ID,
+		&key.Name,
+		&key.Prefix,
+		pq.Array(&scopes),
+		&key.CreatedAt,
+		&key.LastUsedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = business.ErrAPIKeyNotFound
+		return
+	}
+
+	if err != nil {
+		return
+	}
+
+	key.Hash = hash
+	key.Scopes = make([]business.Scope, len(scopes))
+
+	for i, scope := range scopes {
+		key.Scopes[i] = business.Scope(scope)
+	}
+
+	return
+}
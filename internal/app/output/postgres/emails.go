@@ -0,0 +1,164 @@
+//go:build keys
+
+package postgres
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"github.com/yael-castro/goarch/internal/app/business"
+	"github.com/yael-castro/goarch/pkg/envelope"
+	"log/slog"
+	"strings"
+)
+
+type EmailIndexerConfig struct {
+	Logger  *slog.Logger
+	DB      *sql.DB
+	Keyring *envelope.Keyring
+}
+
+// NewEmailIndexer builds a business.EmailIndexer that recomputes the blind index of the emails (see emailIndex).
+//
+// The emails that are equal regardless of their case (stored while the index kept the case) collide: the email that is
+// already lowercase, or else the email of the oldest user, gets the index and the others keep an index with their
+// case, so they are still unique. The collisions are logged to be resolved by the admins
+func NewEmailIndexer(config EmailIndexerConfig) business.EmailIndexer {
+	return emailIndexer{
+		logger:  config.Logger,
+		db:      config.DB,
+		keyring: config.Keyring,
+	}
+}
+
+type emailIndexer struct {
+	logger  *slog.Logger
+	db      *sql.DB
+	keyring *envelope.Keyring
+}
+
+func (e emailIndexer) IndexEmails(ctx context.Context) (indexed int, err error) {
+	ctx, span := startSpan(ctx, "UPDATE", "users", updateUserEmailIndex)
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	// BEGIN
+	tx, err := e.db.BeginTx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer func() {
+		// ROLLBACK
+		_ = tx.Rollback()
+	}()
+
+	if _, err = tx.ExecContext(ctx, lockUsers); err != nil {
+		return
+	}
+
+	users, err := e.selectUserEmails(ctx, tx)
+	if err != nil {
+		return
+	}
+
+	// Grouping the users by the new index of their email
+	groups := make(map[string][]int)
+
+	for i, u := range users {
+		index := string(emailIndex(u.email, e.keyring))
+		groups[index] = append(groups[index], i)
+	}
+
+	type change struct {
+		id    int64
+		index []byte
+	}
+
+	var changes []change
+
+	for index, group := range groups {
+		owner := group[0]
+
+		for _, i := range group {
+			if users[i].email.String() == strings.ToLower(users[i].email.String()) {
+				owner = i
+			}
+		}
+
+		for _, i := range group {
+			newIndex := []byte(index)
+
+			if i != owner {
+				newIndex = e.keyring.BlindIndex(users[i].email.String())
+				e.logger.WarnContext(ctx, "email_index_collision", "user_id", users[i].id, "owner_user_id", users[owner].id)
+			}
+
+			if !bytes.Equal(newIndex, users[i].index) {
+				changes = append(changes, change{id: users[i].id, index: newIndex})
+			}
+		}
+	}
+
+	for _, c := range changes {
+		if _, err = tx.ExecContext(ctx, updateUserEmailIndexPlaceholder, c.id); err != nil {
+			return
+		}
+	}
+
+	for _, c := range changes {
+		if _, err = tx.ExecContext(ctx, updateUserEmailIndex, c.index, c.id); err != nil {
+			return
+		}
+	}
+
+	// COMMIT
+	return len(changes), tx.Commit()
+}
+
+type userEmail struct {
+	id    int64
+	email business.Email
+	index []byte
+}
+
+// selectUserEmails returns the decrypted emails of the users ordered by their ID
+func (e emailIndexer) selectUserEmails(ctx context.Context, tx *sql.Tx) (_ []userEmail, err error) {
+	rows, err := tx.QueryContext(ctx, selectUserEmails)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var users []userEmail
+
+	for rows.Next() {
+		var (
+			u           userEmail
+			email       []byte
+			keyEnvelope envelope.Envelope
+			dataKey     envelope.DataKey
+		)
+
+		if err = rows.Scan(&u.id, &email, &u.index, &keyEnvelope.KeyID, &keyEnvelope.DataKey); err != nil {
+			return
+		}
+
+		dataKey, err = e.keyring.Open(keyEnvelope)
+		if err != nil {
+			return
+		}
+
+		email, err = dataKey.Decrypt(email, "email")
+		if err != nil {
+			return
+		}
+
+		u.email = business.Email(email)
+		users = append(users, u)
+	}
+
+	return users, rows.Err()
+}
@@ -0,0 +1,505 @@
+//go:build keys
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"github.com/yael-castro/goarch/internal/app/business"
+	"github.com/yael-castro/goarch/pkg/cloudevents"
+	"github.com/yael-castro/goarch/pkg/envelope"
+	"log/slog"
+)
+
+// encryptedTables are the tables whose records are encrypted with their own data key
+var encryptedTables = [...]struct {
+	name            string
+	selectKeys      string
+	updateKey       string
+	countFormerKeys string
+}{
+	{name: "users", selectKeys: selectUserKeys, updateKey: updateUserKey, countFormerKeys: countUserFormerKeys},
+	{name: "user_audit", selectKeys: selectUserAuditKeys, updateKey: updateUserAuditKey, countFormerKeys: countUserAuditFormerKeys},
+}
+
+type KeyRotatorConfig struct {
+	Logger  *slog.Logger
+	DB      *sql.DB
+	Keyring *envelope.Keyring
+	// BatchSize is the number of records re-wrapped by transaction
+	BatchSize int
+}
+
+// NewKeyRotator builds a business.KeyRotator that re-wraps the data keys of the records and of the outbox messages with
+// the primary key of the keyring, the encrypted fields are not changed. The records stored in plain text before the
+// encryption are encrypted first
+func NewKeyRotator(config KeyRotatorConfig) business.KeyRotator {
+	return keyRotator{
+		logger:    config.Logger,
+		db:        config.DB,
+		keyring:   config.Keyring,
+		batchSize: config.BatchSize,
+	}
+}
+
+type keyRotator struct {
+	logger    *slog.Logger
+	db        *sql.DB
+	keyring   *envelope.Keyring
+	batchSize int
+}
+
+func (k keyRotator) RotateKeys(ctx context.Context) (rotated int, err error) {
+	encrypted, err := k.encryptPlainRecords(ctx)
+	rotated += encrypted
+	if err != nil {
+		return
+	}
+
+	for _, table := range encryptedTables {
+		for {
+			var n int
+
+			n, err = k.rotateBatch(ctx, table.name, table.selectKeys, table.updateKey)
+			rotated += n
+			if err != nil {
+				return
+			}
+
+			if n > 0 {
+				k.logger.InfoContext(ctx, "keys_rotated", "table", table.name, "records", n, "key_id", k.keyring.Primary())
+			}
+
+			if n < k.batchSize {
+				break
+			}
+		}
+	}
+
+	n, err := k.rotateMessages(ctx)
+	rotated += n
+	if err != nil {
+		return
+	}
+
+	// The records written with a former key during the rotation (e.g. by a server that still has the former keyring)
+	// fail the rotation, so the former key is not removed from the keyring while it is used
+	for _, table := range encryptedTables {
+		var remaining int
+
+		if err = k.db.QueryRowContext(ctx, table.countFormerKeys, k.keyring.Primary()).Scan(&remaining); err != nil {
+			return
+		}
+
+		if remaining > 0 {
+			err = fmt.Errorf("%d records of %s are still wrapped by a former key", remaining, table.name)
+			return
+		}
+	}
+
+	return
+}
+
+func (k keyRotator) rotateBatch(ctx context.Context, table, selectKeys, updateKey string) (_ int, err error) {
+	ctx, span := startSpan(ctx, "UPDATE", table, updateKey)
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	// BEGIN
+	tx, err := k.db.BeginTx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer func() {
+		// ROLLBACK
+		_ = tx.Rollback()
+	}()
+
+	rows, err := tx.QueryContext(ctx, selectKeys, k.keyring.Primary(), k.batchSize)
+	if err != nil {
+		return
+	}
+
+	type record struct {
+		id       int64
+		envelope envelope.Envelope
+	}
+
+	records := make([]record, 0, k.batchSize)
+
+	for rows.Next() {
+		var r record
+
+		if err = rows.Scan(&r.id, &r.envelope.KeyID, &r.envelope.DataKey); err != nil {
+			_ = rows.Close()
+			return
+		}
+
+		records = append(records, r)
+	}
+
+	if err = rows.Close(); err != nil {
+		return
+	}
+
+	if err = rows.Err(); err != nil {
+		return
+	}
+
+	for _, r := range records {
+		var rewrapped envelope.Envelope
+
+		rewrapped, err = k.keyring.Rewrap(r.envelope)
+		if err != nil {
+			return
+		}
+
+		if _, err = tx.ExecContext(ctx, updateKey, rewrapped.KeyID, rewrapped.DataKey, r.id); err != nil {
+			return
+		}
+	}
+
+	// COMMIT
+	return len(records), tx.Commit()
+}
+
+// rotateMessages re-wraps the data keys of the ce_datakey headers of the outbox messages, delivered or not
+func (k keyRotator) rotateMessages(ctx context.Context) (rotated int, err error) {
+	var lastID int64
+
+	for {
+		var n, scanned int
+
+		lastID, n, scanned, err = k.rotateMessagesBatch(ctx, lastID)
+		rotated += n
+		if err != nil {
+			return
+		}
+
+		if n > 0 {
+			k.logger.InfoContext(ctx, "keys_rotated", "table", "outbox_messages", "records", n, "key_id", k.keyring.Primary())
+		}
+
+		if scanned < k.batchSize {
+			return
+		}
+	}
+}
+
+// rotateMessagesBatch re-wraps the messages after the message afterID, it returns the ID of the last scanned message
+func (k keyRotator) rotateMessagesBatch(ctx context.Context, afterID int64) (lastID int64, rotated, scanned int, err error) {
+	ctx, span := startSpan(ctx, "UPDATE", "outbox_messages", updateMessageHeaders)
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	// BEGIN
+	tx, err := k.db.BeginTx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer func() {
+		// ROLLBACK
+		_ = tx.Rollback()
+	}()
+
+	rows, err := tx.QueryContext(ctx, selectMessageHeaders, afterID, k.batchSize)
+	if err != nil {
+		return
+	}
+
+	type message struct {
+		id      int64
+		headers Headers
+	}
+
+	messages := make([]message, 0, k.batchSize)
+
+	for rows.Next() {
+		var (
+			m          message
+			rawHeaders []byte
+		)
+
+		if err = rows.Scan(&m.id, &rawHeaders); err != nil {
+			_ = rows.Close()
+			return
+		}
+
+		if err = m.headers.UnmarshalBinary(rawHeaders); err != nil {
+			_ = rows.Close()
+			return
+		}
+
+		messages = append(messages, m)
+	}
+
+	if err = rows.Close(); err != nil {
+		return
+	}
+
+	if err = rows.Err(); err != nil {
+		return
+	}
+
+	lastID, scanned = afterID, len(messages)
+
+	for _, m := range messages {
+		lastID = m.id
+
+		var ok bool
+
+		ok, err = rewrapHeaders(m.headers, k.keyring)
+		if err != nil {
+			err = fmt.Errorf("message %d: %w", m.id, err)
+			return
+		}
+
+		if !ok {
+			continue
+		}
+
+		var headers []byte
+
+		headers, err = m.headers.MarshalBinary()
+		if err != nil {
+			return
+		}
+
+		if _, err = tx.ExecContext(ctx, updateMessageHeaders, headers, m.id); err != nil {
+			return
+		}
+
+		rotated++
+	}
+
+	// COMMIT
+	return lastID, rotated, scanned, tx.Commit()
+}
+
+// rewrapHeaders re-wraps the data key of the ce_datakey header with the primary key, it indicates if the headers changed
+func rewrapHeaders(headers Headers, keyring *envelope.Keyring) (bool, error) {
+	keyID, dataKey := -1, -1
+
+	for i, header := range headers {
+		switch header.Key {
+		case cloudevents.HeaderKeyID:
+			keyID = i
+		case cloudevents.HeaderDataKey:
+			dataKey = i
+		}
+	}
+
+	// The message has no encrypted fields (e.g. erased or scrubbed) or it is already wrapped by the primary key
+	if keyID < 0 || dataKey < 0 || string(headers[keyID].Value) == keyring.Primary() {
+		return false, nil
+	}
+
+	wrapped, err := base64.StdEncoding.DecodeString(string(headers[dataKey].Value))
+	if err != nil {
+		return false, err
+	}
+
+	rewrapped, err := keyring.Rewrap(envelope.Envelope{KeyID: string(headers[keyID].Value), DataKey: wrapped})
+	if err != nil {
+		return false, err
+	}
+
+	headers[keyID].Value = []byte(rewrapped.KeyID)
+	headers[dataKey].Value = []byte(base64.StdEncoding.EncodeToString(rewrapped.DataKey))
+	return true, nil
+}
+
+// encryptPlainRecords encrypts the users and the audit entries stored in plain text before the encryption
+func (k keyRotator) encryptPlainRecords(ctx context.Context) (encrypted int, err error) {
+	batches := [...]struct {
+		table string
+		batch func(context.Context) (int, error)
+	}{
+		{table: "users", batch: k.encryptUsersBatch},
+		{table: "user_audit", batch: k.encryptUserAuditBatch},
+	}
+
+	for _, b := range batches {
+		for {
+			var n int
+
+			n, err = b.batch(ctx)
+			encrypted += n
+			if err != nil {
+				return
+			}
+
+			if n > 0 {
+				k.logger.InfoContext(ctx, "records_encrypted", "table", b.table, "records", n, "key_id", k.keyring.Primary())
+			}
+
+			if n < k.batchSize {
+				break
+			}
+		}
+	}
+
+	return
+}
+
+func (k keyRotator) encryptUsersBatch(ctx context.Context) (_ int, err error) {
+	ctx, span := startSpan(ctx, "UPDATE", "users", updatePlainUser)
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	// BEGIN
+	tx, err := k.db.BeginTx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer func() {
+		// ROLLBACK
+		_ = tx.Rollback()
+	}()
+
+	rows, err := tx.QueryContext(ctx, selectPlainUsers, k.batchSize)
+	if err != nil {
+		return
+	}
+
+	type user struct {
+		id    int64
+		name  []byte
+		email []byte
+	}
+
+	users := make([]user, 0, k.batchSize)
+
+	for rows.Next() {
+		var u user
+
+		if err = rows.Scan(&u.id, &u.name, &u.email); err != nil {
+			_ = rows.Close()
+			return
+		}
+
+		users = append(users, u)
+	}
+
+	if err = rows.Close(); err != nil {
+		return
+	}
+
+	if err = rows.Err(); err != nil {
+		return
+	}
+
+	for _, u := range users {
+		var (
+			dataKey     envelope.DataKey
+			keyEnvelope envelope.Envelope
+			name, email []byte
+		)
+
+		dataKey, keyEnvelope, err = k.keyring.NewDataKey()
+		if err != nil {
+			return
+		}
+
+		name, err = dataKey.Encrypt(u.name, "name")
+		if err != nil {
+			return
+		}
+
+		email, err = dataKey.Encrypt(u.email, "email")
+		if err != nil {
+			return
+		}
+
+		// The emails stored before the encryption were unique with their case, so their index keeps the case until the
+		// emails are re-indexed (see NewEmailIndexer)
+		index := k.keyring.BlindIndex(string(u.email))
+
+		_, err = tx.ExecContext(ctx, updatePlainUser, name, email, index, keyEnvelope.KeyID, keyEnvelope.DataKey, u.id)
+		if err != nil {
+			return
+		}
+	}
+
+	// COMMIT
+	return len(users), tx.Commit()
+}
+
+func (k keyRotator) encryptUserAuditBatch(ctx context.Context) (_ int, err error) {
+	ctx, span := startSpan(ctx, "UPDATE", "user_audit", updatePlainUserAudit)
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	// BEGIN
+	tx, err := k.db.BeginTx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer func() {
+		// ROLLBACK
+		_ = tx.Rollback()
+	}()
+
+	rows, err := tx.QueryContext(ctx, selectPlainUserAudit, k.batchSize)
+	if err != nil {
+		return
+	}
+
+	type entry struct {
+		id      int64
+		changes []byte
+	}
+
+	entries := make([]entry, 0, k.batchSize)
+
+	for rows.Next() {
+		var e entry
+
+		if err = rows.Scan(&e.id, &e.changes); err != nil {
+			_ = rows.Close()
+			return
+		}
+
+		entries = append(entries, e)
+	}
+
+	if err = rows.Close(); err != nil {
+		return
+	}
+
+	if err = rows.Err(); err != nil {
+		return
+	}
+
+	for _, e := range entries {
+		var (
+			dataKey     envelope.DataKey
+			keyEnvelope envelope.Envelope
+			changes     []byte
+		)
+
+		dataKey, keyEnvelope, err = k.keyring.NewDataKey()
+		if err != nil {
+			return
+		}
+
+		changes, err = dataKey.Encrypt(e.changes, "changes")
+		if err != nil {
+			return
+		}
+
+		_, err = tx.ExecContext(ctx, updatePlainUserAudit, changes, keyEnvelope.KeyID, keyEnvelope.DataKey, e.id)
+		if err != nil {
+			return
+		}
+	}
+
+	// COMMIT
+	return len(entries), tx.Commit()
+}
@@ -0,0 +1,181 @@
+//go:build relay
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"github.com/yael-castro/goarch/internal/app/business"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	errLeadershipLost = fmt.Errorf("%w: the session that holds the advisory lock is lost", business.ErrLeadershipLost)
+	errResigned       = errors.New("the leadership was given up")
+)
+
+type LeaderElectorConfig struct {
+	// LockName identifies the advisory lock shared by the replicas
+	LockName string
+	// Interval is the time between attempts to acquire the lock and between session health checks
+	Interval time.Duration
+	Logger   *slog.Logger
+	DB       *sql.DB
+}
+
+// NewLeaderElector builds a business.LeaderElector based on session-level Postgres advisory locks
+func NewLeaderElector(config LeaderElectorConfig) business.LeaderElector {
+	return &leaderElector{
+		lockName: config.LockName,
+		interval: config.Interval,
+		logger:   config.Logger,
+		db:       config.DB,
+	}
+}
+
+type leaderElector struct {
+	leader   atomic.Bool
+	lockName string
+	interval time.Duration
+	logger   *slog.Logger
+	db       *sql.DB
+}
+
+func (l *leaderElector) Campaign(ctx context.Context) (context.Context, func(), error) {
+	// maxCampaignBackoff limits the time between the campaigns that fail (e.g. the database is unavailable)
+	const maxCampaignBackoff = time.Minute
+
+	backoff := l.interval
+
+	for {
+		conn, err := l.acquire(ctx)
+		if err == nil {
+			return l.lead(ctx, conn)
+		}
+
+		if ctx.Err() != nil {
+			return nil, nil, ctx.Err()
+		}
+
+		l.logger.WarnContext(ctx, "failed_campaign", "error", err, "backoff", backoff)
+
+		select {
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		backoff = min(2*backoff, maxCampaignBackoff)
+	}
+}
+
+// acquire blocks until the advisory lock is acquired and returns the session that holds it
+func (l *leaderElector) acquire(ctx context.Context) (*sql.Conn, error) {
+	// The advisory lock belongs to the session, so the same connection must be held while leading
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ticker := time.NewTicker(l.interval)
+	defer ticker.Stop()
+
+	for {
+		var acquired bool
+
+		err = conn.QueryRowContext(ctx, tryAdvisoryLock, l.lockName).Scan(&acquired)
+		if err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+
+		if acquired {
+			return conn, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			_ = conn.Close()
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// lead checks the session that holds the advisory lock until resign is called, the lock is released only by resign
+func (l *leaderElector) lead(ctx context.Context, conn *sql.Conn) (context.Context, func(), error) {
+	l.leader.Store(true)
+
+	leaderCtx, cancel := context.WithCancelCause(ctx)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		l.keepAlive(leaderCtx, cancel, conn)
+	}()
+
+	var once sync.Once
+
+	resign := func() {
+		once.Do(func() {
+			cancel(errResigned)
+			<-done
+			l.release(ctx, conn)
+		})
+	}
+
+	return leaderCtx, resign, nil
+}
+
+// keepAlive checks the session that holds the advisory lock until ctx is done or the session is lost
+func (l *leaderElector) keepAlive(ctx context.Context, cancel context.CancelCauseFunc, conn *sql.Conn) {
+	ticker := time.NewTicker(l.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		err := conn.PingContext(ctx)
+		if err != nil && ctx.Err() == nil {
+			l.logger.ErrorContext(ctx, "failed_leader_session_check", "error", err)
+			cancel(errors.Join(errLeadershipLost, err))
+			return
+		}
+	}
+}
+
+// release gives up the advisory lock and returns the session to the pool
+func (l *leaderElector) release(ctx context.Context, conn *sql.Conn) {
+	l.leader.Store(false)
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), l.interval)
+	defer cancel()
+
+	var released bool
+
+	err := conn.QueryRowContext(ctx, advisoryUnlock, l.lockName).Scan(&released)
+	if err != nil {
+		l.logger.WarnContext(ctx, "failed_advisory_unlock", "error", err)
+
+		// Discarding the session, so the lock is not kept by a pooled connection
+		_ = conn.Raw(func(any) error {
+			return driver.ErrBadConn
+		})
+	}
+
+	_ = conn.Close()
+}
+
+func (l *leaderElector) IsLeader() bool {
+	return l.leader.Load()
+}
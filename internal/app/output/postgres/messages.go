@@ -7,6 +7,7 @@ import (
 	"database/sql"
 	"github.com/yael-castro/goarch/internal/app/business"
 	"log/slog"
+	"time"
 )
 
 func NewMessagesReader(db *sql.DB, logger *slog.Logger) business.MessagesReader {
@@ -85,3 +86,30 @@ func (m messageDeliveryConfirmer) ConfirmMessageDelivery(ctx context.Context, me
 	_, err = m.db.ExecContext(ctx, stmt, args...)
 	return
 }
+
+func NewMessagesBacklogReader(db *sql.DB) business.MessagesBacklogReader {
+	return messagesBacklogReader{
+		db: db,
+	}
+}
+
+type messagesBacklogReader struct {
+	db *sql.DB
+}
+
+func (m messagesBacklogReader) ReadBacklog(ctx context.Context) (business.Backlog, error) {
+	var (
+		pending          int64
+		oldestPendingAge float64
+	)
+
+	err := m.db.QueryRowContext(ctx, selectMessagesBacklog).Scan(&pending, &oldestPendingAge)
+	if err != nil {
+		return business.Backlog{}, err
+	}
+
+	return business.Backlog{
+		Pending:          uint64(pending),
+		OldestPendingAge: time.Duration(oldestPendingAge * float64(time.Second)),
+	}, nil
+}
@@ -4,55 +4,106 @@ import (
 	"database/sql"
 	"encoding/json"
 	"github.com/yael-castro/goarch/internal/app/business"
-	"github.com/yael-castro/goarch/pkg/jsont"
+	"github.com/yael-castro/goarch/pkg/cloudevents"
+	"github.com/yael-castro/goarch/pkg/envelope"
+	"github.com/yael-castro/goarch/pkg/pb"
+	"google.golang.org/protobuf/proto"
+	"strings"
+	"time"
 )
 
 type NullBytes = sql.Null[[]byte]
 
-func NewUser(u *business.User) *User {
+// NewUser builds the record of the User whose name and email are encrypted with a new data key wrapped by keyring
+func NewUser(u *business.User, keyring *envelope.Keyring) (*User, error) {
+	dataKey, keyEnvelope, err := keyring.NewDataKey()
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := dataKey.Encrypt([]byte(u.Name), "name")
+	if err != nil {
+		return nil, err
+	}
+
+	email, err := dataKey.Encrypt([]byte(u.Email), "email")
+	if err != nil {
+		return nil, err
+	}
+
 	return &User{
 		ID: sql.NullInt64{
 			Int64: int64(u.ID),
 			Valid: u.ID > 0,
 		},
-		Name: sql.NullString{
-			String: u.Name.String(),
-			Valid:  len(u.Name) > 0,
+		Name:       name,
+		Email:      email,
+		EmailIndex: emailIndex(u.Email, keyring),
+		BirthDate: sql.NullString{
+			String: u.BirthDate.String(),
+			Valid:  len(u.BirthDate) > 0,
 		},
-		Email: sql.NullString{
-			String: u.Email.String(),
-			Valid:  len(u.Email) > 0,
+		KeyID:   keyEnvelope.KeyID,
+		DataKey: keyEnvelope.DataKey,
+		EmailVerifiedAt: sql.NullTime{
+			Time:  u.EmailVerifiedAt,
+			Valid: !u.EmailVerifiedAt.IsZero(),
 		},
-		Age: sql.NullInt64{
-			Int64: int64(u.Age),
-			Valid: u.Age > 0,
+		EmailVerificationHash: u.EmailVerification.Hash,
+		EmailVerificationExpiresAt: sql.NullTime{
+			Time:  u.EmailVerification.ExpiresAt,
+			Valid: !u.EmailVerification.IsZero(),
 		},
-	}
+	}, nil
+}
+
+// emailIndex is the blind index of the lowercase email, the emails are unique regardless of their case
+func emailIndex(email business.Email, keyring *envelope.Keyring) []byte {
+	return keyring.BlindIndex(strings.ToLower(email.String()))
 }
 
 type User struct {
-	ID    sql.NullInt64
-	Name  sql.NullString
-	Email sql.NullString
-	Age   sql.NullInt64
+	ID sql.NullInt64
+	// Name and Email are encrypted with the DataKey
+	Name       []byte
+	Email      []byte
+	EmailIndex []byte
+	// BirthDate is NULL only if the user is erased
+	BirthDate sql.NullString
+	// KeyID identifies the master key that wraps the DataKey
+	KeyID           string
+	DataKey         []byte
+	EmailVerifiedAt sql.NullTime
+	// EmailVerificationHash is the SHA-256 of the pending email verification token (if any)
+	EmailVerificationHash      []byte
+	EmailVerificationExpiresAt sql.NullTime
 }
 
-func (u *User) ToBusiness() *business.User {
-	return &business.User{
-		ID:    business.UserID(u.ID.Int64),
-		Age:   business.Age(u.Age.Int64),
-		Name:  business.Name(u.Name.String),
-		Email: business.Email(u.Email.String),
+// ToBusiness decrypts the User with its data key unwrapped by keyring
+func (u *User) ToBusiness(keyring *envelope.Keyring) (*business.User, error) {
+	dataKey, err := keyring.Open(envelope.Envelope{KeyID: u.KeyID, DataKey: u.DataKey})
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := dataKey.Decrypt(u.Name, "name")
+	if err != nil {
+		return nil, err
+	}
+
+	email, err := dataKey.Decrypt(u.Email, "email")
+	if err != nil {
+		return nil, err
 	}
-}
 
-func (u *User) MarshalBinary() ([]byte, error) {
-	return json.Marshal(jsont.User{
-		ID:    u.ID.Int64,
-		Name:  u.Name.String,
-		Email: u.Email.String,
-		Age:   uint8(u.Age.Int64),
-	})
+	return &business.User{
+		ID:        business.UserID(u.ID.Int64),
+		Name:      business.Name(name),
+		Email:     business.Email(email),
+		BirthDate: business.BirthDate(u.BirthDate.String),
+		// The pending verification is never read
+		EmailVerifiedAt: u.EmailVerifiedAt.Time,
+	}, nil
 }
 
 func NewHeader(header business.Header) Header {
@@ -70,20 +121,53 @@ func (h Header) ToBusiness() business.Header {
 
 type Headers []Header
 
+// MarshalBinary encodes the headers as pb.Headers
 func (h *Headers) MarshalBinary() (data []byte, err error) {
 	if len(*h) < 1 {
 		return
 	}
 
-	return json.Marshal(h)
+	headers := &pb.Headers{
+		Headers: make([]*pb.Header, len(*h)),
+	}
+
+	for i, header := range *h {
+		headers.Headers[i] = &pb.Header{
+			Key:   header.Key,
+			Value: header.Value,
+		}
+	}
+
+	return proto.Marshal(headers)
 }
 
+// UnmarshalBinary decodes the headers encoded as pb.Headers or as a JSON array (previous format)
 func (h *Headers) UnmarshalBinary(data []byte) error {
 	if len(data) < 1 {
 		return nil
 	}
 
-	return json.Unmarshal(data, h)
+	if data[0] == '[' {
+		return json.Unmarshal(data, h)
+	}
+
+	var headers pb.Headers
+
+	err := proto.Unmarshal(data, &headers)
+	if err != nil {
+		return err
+	}
+
+	*h = make(Headers, len(headers.Headers))
+
+	for i, header := range headers.Headers {
+		(*h)[i] = Header{
+			Key:   header.Key,
+			Value: header.Value,
+		}
+	}
+
+	return nil
 }
 
 func NewMessage(m business.Message) (message Message) {
@@ -132,6 +216,17 @@ type Message struct {
 	IdempotencyKey NullBytes
 }
 
+// Type returns the value of the cloud event type header (ce_type)
+func (m *Message) Type() string {
+	for _, header := range m.Headers {
+		if header.Key == cloudevents.HeaderType {
+			return string(header.Value)
+		}
+	}
+
+	return ""
+}
+
 func (m *Message) ToBusiness() (message *business.Message) {
 	message = &business.Message{
 		ID:             uint64(m.ID.Int64),
@@ -153,3 +248,92 @@ func (m *Message) ToBusiness() (message *business.Message) {
 
 	return
 }
+
+// NewAuditEntry builds the record of the AuditEntry whose changes are encrypted with a new data key wrapped by keyring
+func NewAuditEntry(entry business.AuditEntry, keyring *envelope.Keyring) (AuditEntry, error) {
+	changes := make([]FieldChange, len(entry.Changes))
+
+	for i, change := range entry.Changes {
+		changes[i] = FieldChange(change)
+	}
+
+	data, err := json.Marshal(changes)
+	if err != nil {
+		return AuditEntry{}, err
+	}
+
+	dataKey, keyEnvelope, err := keyring.NewDataKey()
+	if err != nil {
+		return AuditEntry{}, err
+	}
+
+	data, err = dataKey.Encrypt(data, "changes")
+	if err != nil {
+		return AuditEntry{}, err
+	}
+
+	return AuditEntry{
+		UserID:    int64(entry.UserID),
+		Actor:     entry.Actor,
+		RequestID: entry.RequestID,
+		Action:    string(entry.Action),
+		Changes:   data,
+		KeyID:     keyEnvelope.KeyID,
+		DataKey:   keyEnvelope.DataKey,
+		CreatedAt: entry.Time,
+	}, nil
+}
+
+type AuditEntry struct {
+	ID        int64
+	UserID    int64
+	Actor     string
+	RequestID string
+	Action    string
+	// Changes is the JSON of the FieldChange(s) encrypted with the DataKey
+	Changes   []byte
+	KeyID     string
+	DataKey   []byte
+	CreatedAt time.Time
+}
+
+// ToBusiness decrypts the AuditEntry with its data key unwrapped by keyring
+func (a *AuditEntry) ToBusiness(keyring *envelope.Keyring) (business.AuditEntry, error) {
+	dataKey, err := keyring.Open(envelope.Envelope{KeyID: a.KeyID, DataKey: a.DataKey})
+	if err != nil {
+		return business.AuditEntry{}, err
+	}
+
+	data, err := dataKey.Decrypt(a.Changes, "changes")
+	if err != nil {
+		return business.AuditEntry{}, err
+	}
+
+	var changes []FieldChange
+
+	if err = json.Unmarshal(data, &changes); err != nil {
+		return business.AuditEntry{}, err
+	}
+
+	entry := business.AuditEntry{
+		ID:        business.AuditEntryID(a.ID),
+		UserID:    business.UserID(a.UserID),
+		Actor:     a.Actor,
+		RequestID: a.RequestID,
+		Action:    business.Action(a.Action),
+		Changes:   make([]business.FieldChange, len(changes)),
+		Time:      a.CreatedAt,
+	}
+
+	for i, change := range changes {
+		entry.Changes[i] = business.FieldChange(change)
+	}
+
+	return entry, nil
+}
+
+type FieldChange struct {
+	Field  string `json:"field"`
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+}
@@ -0,0 +1,340 @@
+//go:build http || grpc
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/yael-castro/goarch/internal/app/business"
+	"github.com/yael-castro/goarch/pkg/cloudevents"
+	"strconv"
+)
+
+func (s userStore) ExportUser(ctx context.Context, id business.UserID) (_ business.UserExport, err error) {
+	// BEGIN (the export is a consistent snapshot)
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return
+	}
+	defer func() {
+		// ROLLBACK
+		_ = tx.Rollback()
+	}()
+
+	export, err := s.exportUser(ctx, tx, id)
+	if err != nil {
+		return
+	}
+
+	export.History, err = s.exportUserHistory(ctx, tx, id)
+	if err != nil {
+		return
+	}
+
+	export.Messages, err = s.exportUserMessages(ctx, tx, id)
+	if err != nil {
+		return
+	}
+
+	return export, tx.Commit()
+}
+
+// exportUser selects the user even if it is deleted or erased
+func (s userStore) exportUser(ctx context.Context, tx *sql.Tx, id business.UserID) (_ business.UserExport, err error) {
+	ctx, span := startSpan(ctx, "SELECT", "users", selectUserForExport)
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	var (
+		userSQL   User
+		deletedAt sql.NullTime
+		erasedAt  sql.NullTime
+	)
+
+	err = tx.QueryRowContext(ctx, selectUserForExport, id).Scan(
+		&userSQL.ID,
+		&userSQL.Name,
+		&userSQL.BirthDate,
+		&userSQL.Email,
+		&userSQL.KeyID,
+		&userSQL.DataKey,
+		&userSQL.EmailVerifiedAt,
+		&deletedAt,
+		&erasedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = fmt.Errorf("%w: user %d not found", business.ErrUserNotFound.With("user_id", strconv.FormatUint(uint64(id), 10)), id)
+		}
+
+		return
+	}
+
+	export := business.UserExport{
+		User:      business.User{ID: id},
+		DeletedAt: deletedAt.Time,
+		ErasedAt:  erasedAt.Time,
+	}
+
+	// The personal data of an erased user no longer exists
+	if erasedAt.Valid {
+		return export, nil
+	}
+
+	user, err := userSQL.ToBusiness(s.keyring)
+	if err != nil {
+		return
+	}
+
+	export.User = *user
+	return export, nil
+}
+
+func (s userStore) exportUserHistory(ctx context.Context, tx *sql.Tx, id business.UserID) (_ []business.AuditEntry, err error) {
+	ctx, span := startSpan(ctx, "SELECT", "user_audit", selectAllUserAudit)
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	rows, err := tx.QueryContext(ctx, selectAllUserAudit, id)
+	if err != nil {
+		return
+	}
+
+	auditSQL, err := scanAuditEntries(rows)
+	if err != nil {
+		return
+	}
+
+	entries := make([]business.AuditEntry, len(auditSQL))
+
+	for i := range auditSQL {
+		entries[i], err = auditSQL[i].ToBusiness(s.keyring)
+		if err != nil {
+			return
+		}
+	}
+
+	return entries, nil
+}
+
+func (s userStore) exportUserMessages(ctx context.Context, tx *sql.Tx, id business.UserID) (_ []business.Message, err error) {
+	ctx, span := startSpan(ctx, "SELECT", "outbox_messages", selectUserMessages)
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	rows, err := tx.QueryContext(ctx, selectUserMessages, strconv.FormatUint(uint64(id), 10))
+	if err != nil {
+		return
+	}
+
+	messagesSQL, err := scanMessages(rows)
+	if err != nil {
+		return
+	}
+
+	messages := make([]business.Message, 0, len(messagesSQL))
+
+	for i := range messagesSQL {
+		// The verification tokens are credentials, they are not part of the export
+		if messagesSQL[i].Type() == cloudevents.TypeUserEmailVerificationRequested {
+			continue
+		}
+
+		messages = append(messages, *messagesSQL[i].ToBusiness())
+	}
+
+	return messages, nil
+}
+
+func (s userStore) EraseUser(ctx context.Context, id business.UserID, scrub business.ScrubFunc, outbox business.OutboxFunc) error {
+	// BEGIN
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// ROLLBACK
+		_ = tx.Rollback()
+	}()
+
+	err = s.eraseUser(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	err = s.eraseUserHistory(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	err = s.scrubUserMessages(ctx, tx, id, scrub)
+	if err != nil {
+		return err
+	}
+
+	// Auditing the erasure (without changes, the former values are the erased data)
+	err = s.insertUserAudit(ctx, tx, business.NewAuditEntry(ctx, business.ActionEraseUser, business.User{ID: id}, business.User{ID: id}))
+	if err != nil {
+		return err
+	}
+
+	// Inserting outbox messages
+	err = s.insertOutboxMessages(ctx, tx, business.User{ID: id}, outbox)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// eraseUser anonymizes the user, the erased users are not found again
+func (s userStore) eraseUser(ctx context.Context, tx *sql.Tx, id business.UserID) (err error) {
+	ctx, span := startSpan(ctx, "UPDATE", "users", eraseUser)
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	var userID int64
+
+	err = tx.QueryRowContext(ctx, selectUserForErasure, id).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = fmt.Errorf("%w: user %d not found", business.ErrUserNotFound.With("user_id", strconv.FormatUint(uint64(id), 10)), id)
+		}
+
+		return
+	}
+
+	_, err = tx.ExecContext(ctx, eraseUser, id)
+	return
+}
+
+// eraseUserHistory removes the values of the audit entries, the fields that changed are kept
+func (s userStore) eraseUserHistory(ctx context.Context, tx *sql.Tx, id business.UserID) (err error) {
+	ctx, span := startSpan(ctx, "UPDATE", "user_audit", updateUserAuditChanges)
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	rows, err := tx.QueryContext(ctx, selectUserAuditForErasure, id)
+	if err != nil {
+		return
+	}
+
+	auditSQL, err := scanAuditEntries(rows)
+	if err != nil {
+		return
+	}
+
+	for i := range auditSQL {
+		var entry business.AuditEntry
+
+		entry, err = auditSQL[i].ToBusiness(s.keyring)
+		if err != nil {
+			return
+		}
+
+		for j := range entry.Changes {
+			entry.Changes[j].Before, entry.Changes[j].After = "", ""
+		}
+
+		var erased AuditEntry
+
+		erased, err = NewAuditEntry(entry, s.keyring)
+		if err != nil {
+			return
+		}
+
+		_, err = tx.ExecContext(ctx, updateUserAuditChanges, erased.Changes, erased.KeyID, erased.DataKey, auditSQL[i].ID)
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// scrubUserMessages removes the personal data and the data keys of every message of the user, delivered or not
+func (s userStore) scrubUserMessages(ctx context.Context, tx *sql.Tx, id business.UserID, scrub business.ScrubFunc) (err error) {
+	ctx, span := startSpan(ctx, "UPDATE", "outbox_messages", updateMessageData)
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	rows, err := tx.QueryContext(ctx, selectUserMessagesForErasure, strconv.FormatUint(uint64(id), 10))
+	if err != nil {
+		return
+	}
+
+	messagesSQL, err := scanMessages(rows)
+	if err != nil {
+		return
+	}
+
+	for i := range messagesSQL {
+		var message business.Message
+
+		message, err = scrub(*messagesSQL[i].ToBusiness())
+		if err != nil {
+			return
+		}
+
+		scrubbed := NewMessage(message)
+
+		var headers []byte
+
+		headers, err = scrubbed.Headers.MarshalBinary()
+		if err != nil {
+			return
+		}
+
+		_, err = tx.ExecContext(ctx, updateMessageData, headers, scrubbed.Value.V, messagesSQL[i].ID)
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// scanMessages scans and closes the rows of outbox_messages
+func scanMessages(rows *sql.Rows) (_ []Message, err error) {
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var messages []Message
+
+	for rows.Next() {
+		var (
+			message    Message
+			rawHeaders []byte
+		)
+
+		err = rows.Scan(
+			&message.ID,
+			&message.Topic,
+			&message.Key,
+			&rawHeaders,
+			&message.Value,
+			&message.IdempotencyKey,
+		)
+		if err != nil {
+			return
+		}
+
+		err = message.Headers.UnmarshalBinary(rawHeaders)
+		if err != nil {
+			return
+		}
+
+		messages = append(messages, message)
+	}
+
+	return messages, rows.Err()
+}
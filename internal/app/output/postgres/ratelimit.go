@@ -0,0 +1,82 @@
+//go:build http
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"github.com/yael-castro/goarch/pkg/ratelimit"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// sweepInterval is the time between the removals of the full buckets
+const sweepInterval = time.Minute
+
+type RateLimitStoreConfig struct {
+	Logger *slog.Logger
+	DB     *sql.DB
+}
+
+// NewRateLimitStore builds a ratelimit.Store shared by the replicas, the buckets are rows of rate_limit_buckets
+func NewRateLimitStore(config RateLimitStoreConfig) ratelimit.Store {
+	return &rateLimitStore{
+		logger: config.Logger,
+		db:     config.DB,
+	}
+}
+
+type rateLimitStore struct {
+	mutex     sync.Mutex
+	lastSweep time.Time
+	logger    *slog.Logger
+	db        *sql.DB
+}
+
+func (r *rateLimitStore) Take(ctx context.Context, key string, limit ratelimit.Limit) (_ ratelimit.Result, err error) {
+	if err = limit.Validate(); err != nil {
+		return ratelimit.Result{}, err
+	}
+
+	r.sweep(ctx)
+
+	ctx, span := startSpan(ctx, "INSERT", "rate_limit_buckets", upsertRateLimitBucket)
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	var (
+		tokens  float64
+		allowed bool
+	)
+
+	err = r.db.QueryRowContext(ctx, upsertRateLimitBucket, key, limit.Requests, limit.Rate()).Scan(&tokens, &allowed)
+	if err != nil {
+		return ratelimit.Result{}, err
+	}
+
+	return ratelimit.NewResult(limit, tokens, allowed), nil
+}
+
+// sweep deletes the full buckets once every sweepInterval
+func (r *rateLimitStore) sweep(ctx context.Context) {
+	r.mutex.Lock()
+
+	if time.Since(r.lastSweep) < sweepInterval {
+		r.mutex.Unlock()
+		return
+	}
+
+	r.lastSweep = time.Now()
+	r.mutex.Unlock()
+
+	result, err := r.db.ExecContext(ctx, deleteFullRateLimitBuckets)
+	if err != nil {
+		r.logger.WarnContext(ctx, "rate_limit_sweep", "error", err)
+		return
+	}
+
+	deleted, _ := result.RowsAffected()
+	r.logger.DebugContext(ctx, "rate_limit_sweep", "deleted", deleted)
+}
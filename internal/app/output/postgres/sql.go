@@ -1,12 +1,219 @@
 package postgres
 
-// SQL statements for users
+// SQL statements for users, the name and the email are encrypted with the data key of the record and
+// email_index is the blind index that checks the uniqueness of the email.
+// The birth dates are read in ISO 8601 format (YYYY-MM-DD) regardless of the DateStyle of the session
 const (
-	insertUser = `INSERT INTO users(name, age, email) VALUES ($1, $2, $3) RETURNING id`
+	insertUser = `
+		INSERT INTO users(name, birth_date, email, email_index, key_id, data_key, email_verification_hash, email_verification_expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`
+
+	// The pending email verification is only replaced if a new one is given
+	updateUser = `
+		UPDATE users
+		SET
+			name = $1,
+			birth_date = $2,
+			email = $3,
+			email_index = $4,
+			key_id = $5,
+			data_key = $6,
+			email_verified_at = $7,
+			email_verification_hash = COALESCE($8, email_verification_hash),
+			email_verification_expires_at = COALESCE($9, email_verification_expires_at),
+			updated_at = now()
+		WHERE id = $10 AND deleted_at IS NULL
+	`
+
+	// The email verification is used only once
+	verifyUserEmail = `
+		UPDATE users
+		SET
+			email_verified_at = now(),
+			email_verification_hash = NULL,
+			email_verification_expires_at = NULL,
+			updated_at = now()
+		WHERE
+			id = $1
+			AND
+			email_verification_hash = $2
+			AND
+			email_verification_expires_at > now()
+			AND
+			deleted_at IS NULL
+		RETURNING email_verified_at
+	`
+
+	softDeleteUser = `UPDATE users SET updated_at = now(), deleted_at = now() WHERE id = $1 AND deleted_at IS NULL`
+
+	selectUser = `SELECT id, name, to_char(birth_date, 'YYYY-MM-DD'), email, key_id, data_key, email_verified_at FROM users WHERE id = $1 AND deleted_at IS NULL`
+
+	selectUsers = `
+		SELECT id, name, to_char(birth_date, 'YYYY-MM-DD'), email, key_id, data_key, email_verified_at
+		FROM users
+		WHERE id > $1 AND deleted_at IS NULL
+		ORDER BY id ASC
+		LIMIT $2
+	`
+
+	// selectUserForUpdate locks the user until the end of the transaction to record its state before the mutation
+	selectUserForUpdate = `
+		SELECT id, name, to_char(birth_date, 'YYYY-MM-DD'), email, key_id, data_key, email_verified_at
+		FROM users
+		WHERE id = $1 AND deleted_at IS NULL
+		FOR UPDATE
+	`
+)
+
+// SQL statements for the audit of users
+const (
+	// The changes are encrypted with the data key of the entry since they contain PII
+	insertUserAudit = `
+		INSERT INTO user_audit(user_id, actor, request_id, action, changes, key_id, data_key, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+
+	selectUserAudit = `
+		SELECT id, user_id, actor, request_id, action, changes, key_id, data_key, created_at
+		FROM user_audit
+		WHERE
+			user_id = $1
+			AND
+			id > $2
+		ORDER BY id ASC
+		LIMIT $3
+	`
+)
+
+// SQL statements for the data subject requests (export and erasure), they include the deleted users
+const (
+	selectUserForExport = `
+		SELECT id, name, to_char(birth_date, 'YYYY-MM-DD'), email, key_id, data_key, email_verified_at, deleted_at, erased_at
+		FROM users
+		WHERE id = $1
+	`
+
+	selectAllUserAudit = `
+		SELECT id, user_id, actor, request_id, action, changes, key_id, data_key, created_at
+		FROM user_audit
+		WHERE user_id = $1
+		ORDER BY id ASC
+	`
+
+	// The messages of a user are identified by their partition key (the ID of the user)
+	selectUserMessages = `
+		SELECT id, topic, partition_key, headers, "value", idempotency_key
+		FROM outbox_messages
+		WHERE partition_key = convert_to($1::text, 'UTF8')
+		ORDER BY id ASC
+	`
+
+	selectUserForErasure = `SELECT id FROM users WHERE id = $1 AND erased_at IS NULL FOR UPDATE`
+
+	// The personal data is removed and the data key is discarded, the email index is replaced to keep its uniqueness
+	eraseUser = `
+		UPDATE users
+		SET
+			name = '',
+			birth_date = NULL,
+			email = '',
+			email_index = convert_to('erased:' || id, 'UTF8'),
+			key_id = '',
+			data_key = '',
+			email_verification_hash = NULL,
+			email_verification_expires_at = NULL,
+			updated_at = now(),
+			deleted_at = COALESCE(deleted_at, now()),
+			erased_at = now()
+		WHERE id = $1
+	`
+
+	selectUserAuditForErasure = `
+		SELECT id, user_id, actor, request_id, action, changes, key_id, data_key, created_at
+		FROM user_audit
+		WHERE user_id = $1
+		ORDER BY id ASC
+		FOR UPDATE
+	`
+
+	updateUserAuditChanges = `UPDATE user_audit SET changes = $1, key_id = $2, data_key = $3 WHERE id = $4`
+
+	// The delivered messages are scrubbed too, they are exported and keep the data key that decrypts their copies
+	selectUserMessagesForErasure = `
+		SELECT id, topic, partition_key, headers, "value", idempotency_key
+		FROM outbox_messages
+		WHERE partition_key = convert_to($1::text, 'UTF8')
+		ORDER BY id ASC
+		FOR UPDATE
+	`
+
+	updateMessageData = `UPDATE outbox_messages SET headers = $1, "value" = $2, updated_at = now() WHERE id = $3`
+)
+
+// SQL statements for the rotation of the master keys, the records locked by other transactions are waited for so that
+// no record keeps a former key
+const (
+	// The erased users have no data key
+	selectUserKeys = `
+		SELECT id, key_id, data_key
+		FROM users
+		WHERE key_id <> $1 AND erased_at IS NULL
+		ORDER BY id ASC
+		LIMIT $2
+		FOR UPDATE
+	`
+
+	updateUserKey = `UPDATE users SET key_id = $1, data_key = $2 WHERE id = $3`
+
+	countUserFormerKeys = `SELECT count(*) FROM users WHERE key_id IS DISTINCT FROM $1 AND erased_at IS NULL`
+
+	selectUserAuditKeys = `SELECT id, key_id, data_key FROM user_audit WHERE key_id <> $1 ORDER BY id ASC LIMIT $2 FOR UPDATE`
+
+	updateUserAuditKey = `UPDATE user_audit SET key_id = $1, data_key = $2 WHERE id = $3`
+
+	countUserAuditFormerKeys = `SELECT count(*) FROM user_audit WHERE key_id IS DISTINCT FROM $1`
+
+	// The envelope of the messages is in their headers (ce_keyid and ce_datakey), so every message is scanned by id
+	selectMessageHeaders = `
+		SELECT id, headers
+		FROM outbox_messages
+		WHERE id > $1 AND headers IS NOT NULL
+		ORDER BY id ASC
+		LIMIT $2
+		FOR UPDATE
+	`
+
+	updateMessageHeaders = `UPDATE outbox_messages SET headers = $1, updated_at = now() WHERE id = $2`
+
+	// The records stored in plain text before the encryption have no key (see scripts/sql/6_encrypt_pii.sql)
+	selectPlainUsers = `SELECT id, name, email FROM users WHERE key_id IS NULL ORDER BY id ASC LIMIT $1 FOR UPDATE`
 
-	updateUser = `UPDATE users SET name = $1, age = $2, email = $3 WHERE id = $4`
+	updatePlainUser = `UPDATE users SET name = $1, email = $2, email_index = $3, key_id = $4, data_key = $5 WHERE id = $6`
 
-	selectUser = `SELECT id, name, age, email FROM users WHERE id = $1`
+	selectPlainUserAudit = `SELECT id, changes FROM user_audit WHERE key_id IS NULL ORDER BY id ASC LIMIT $1 FOR UPDATE`
+
+	updatePlainUserAudit = `UPDATE user_audit SET changes = $1, key_id = $2, data_key = $3 WHERE id = $4`
+)
+
+// SQL statements for the re-indexing of the emails, the writes of the users are blocked while the emails are re-indexed
+// so that no email is stored with the former index
+const (
+	lockUsers = `LOCK TABLE users IN SHARE ROW EXCLUSIVE MODE`
+
+	// The erased users have no email
+	selectUserEmails = `
+		SELECT id, email, email_index, key_id, data_key
+		FROM users
+		WHERE erased_at IS NULL AND key_id IS NOT NULL
+		ORDER BY id ASC
+	`
+
+	// The indexes are replaced in two steps since the new index of a user may be the former index of another user
+	updateUserEmailIndexPlaceholder = `UPDATE users SET email_index = convert_to('reindex:' || id, 'UTF8') WHERE id = $1`
+
+	updateUserEmailIndex = `UPDATE users SET email_index = $1 WHERE id = $2`
 )
 
 // SQL statements for message relay
@@ -26,4 +233,76 @@ const (
 		ORDER BY created_at ASC
 		LIMIT $1
 	`
+
+	selectMessagesBacklog = `
+		SELECT
+			count(*),
+			COALESCE(EXTRACT(EPOCH FROM now() - min(created_at)), 0)
+		FROM outbox_messages
+		WHERE
+			delivered_at IS NULL
+			AND
+			deleted_at IS NULL
+	`
+)
+
+// SQL statements for leader election
+const (
+	tryAdvisoryLock = `SELECT pg_try_advisory_lock(hashtext($1))`
+
+	advisoryUnlock = `SELECT pg_advisory_unlock(hashtext($1))`
+)
+
+// refilledTokens is the tokens of the bucket b refilled up to its capacity ($2) at $3 tokens per second
+const refilledTokens = `LEAST($2::float8, b.tokens + EXTRACT(EPOCH FROM now() - b.updated_at) * $3::float8)`
+
+// SQL statements for rate limiting
+const (
+	// upsertRateLimitBucket takes a token from the bucket $1 (if any) in a single statement, so the concurrent
+	// requests of the replicas are serialized by the row lock
+	upsertRateLimitBucket = `
+		INSERT INTO rate_limit_buckets AS b (key, tokens, capacity, rate, allowed, updated_at)
+		VALUES ($1, $2::float8 - 1, $2::float8, $3::float8, true, now())
+		ON CONFLICT (key) DO UPDATE SET
+			tokens = ` + refilledTokens + ` - CASE WHEN ` + refilledTokens + ` >= 1 THEN 1 ELSE 0 END,
+			allowed = ` + refilledTokens + ` >= 1,
+			capacity = $2::float8,
+			rate = $3::float8,
+			updated_at = now()
+		RETURNING tokens, allowed
+	`
+
+	// deleteFullRateLimitBuckets deletes the buckets that are full, they are equivalent to the buckets that do not exist
+	deleteFullRateLimitBuckets = `
+		DELETE FROM rate_limit_buckets
+		WHERE tokens + EXTRACT(EPOCH FROM now() - updated_at) * rate >= capacity
+	`
+)
+
+// SQL statements for API keys
+const (
+	insertAPIKey = `INSERT INTO api_keys(name, key_hash, key_prefix, scopes) VALUES ($1, $2, $3, $4) RETURNING id, created_at`
+
+	revokeAPIKey = `UPDATE api_keys SET revoked_at = now(), updated_at = now() WHERE id = $1 AND revoked_at IS NULL AND deleted_at IS NULL`
+
+	// useAPIKey selects the active key of the hash $1, the last use is recorded at most once per minute to spare writes
+	useAPIKey = `
+		WITH api_key AS (
+			SELECT id, name, key_prefix, scopes, created_at, last_used_at
+			FROM api_keys
+			WHERE
+				key_hash = $1
+				AND
+				revoked_at IS NULL
+				AND
+				deleted_at IS NULL
+		), touch AS (
+			UPDATE api_keys SET last_used_at = now()
+			WHERE
+				id = (SELECT id FROM api_key)
+				AND
+				(last_used_at IS NULL OR last_used_at < now() - INTERVAL '1 minute')
+		)
+		SELECT id, name, key_prefix, scopes, created_at, COALESCE(last_used_at, now()) FROM api_key
+	`
 )
@@ -0,0 +1,78 @@
+package postgres
+
+import (
+	"context"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/yael-castro/goarch/internal/app/output/postgres")
+
+// startSpan starts a client span for a SQL statement
+func startSpan(ctx context.Context, operation, table, stmt string) (context.Context, trace.Span) {
+	return tracer.Start(
+		ctx,
+		operation+" "+table,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBOperationName(operation),
+			semconv.DBCollectionName(table),
+			semconv.DBQueryTextKey.String(stmt),
+		),
+	)
+}
+
+// endSpan records err (if any) in span before ending it
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
+
+var _ propagation.TextMapCarrier = headersCarrier{}
+
+// headersCarrier allows to propagate the trace context through the Headers of an outbox message
+type headersCarrier struct {
+	headers *Headers
+}
+
+func (h headersCarrier) Get(key string) string {
+	for _, header := range *h.headers {
+		if header.Key == key {
+			return string(header.Value)
+		}
+	}
+
+	return ""
+}
+
+func (h headersCarrier) Set(key string, value string) {
+	for i, header := range *h.headers {
+		if header.Key == key {
+			(*h.headers)[i].Value = []byte(value)
+			return
+		}
+	}
+
+	*h.headers = append(*h.headers, Header{
+		Key:   key,
+		Value: []byte(value),
+	})
+}
+
+func (h headersCarrier) Keys() []string {
+	keys := make([]string, len(*h.headers))
+
+	for i, header := range *h.headers {
+		keys[i] = header.Key
+	}
+
+	return keys
+}
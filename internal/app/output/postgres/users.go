@@ -1,4 +1,4 @@
-//go:build http
+//go:build http || grpc
 
 package postgres
 
@@ -9,34 +9,36 @@ import (
 	"fmt"
 	"github.com/lib/pq"
 	"github.com/yael-castro/goarch/internal/app/business"
+	"github.com/yael-castro/goarch/pkg/envelope"
+	"go.opentelemetry.io/otel"
 	"log/slog"
 	"reflect"
+	"strconv"
+	"time"
 )
 
 type UserStoreConfig struct {
-	CreateUserTopic string
-	UpdateUserTopic string
-	Logger          *slog.Logger
-	DB              *sql.DB
+	Logger *slog.Logger
+	DB     *sql.DB
+	// Keyring encrypts the PII of the users and their audit entries
+	Keyring *envelope.Keyring
 }
 
 func NewUserStore(config UserStoreConfig) business.UserStore {
 	return userStore{
-		createUserTopic: config.CreateUserTopic,
-		updateUserTopic: config.UpdateUserTopic,
-		logger:          config.Logger,
-		db:              config.DB,
+		logger:  config.Logger,
+		db:      config.DB,
+		keyring: config.Keyring,
 	}
 }
 
 type userStore struct {
-	createUserTopic string
-	updateUserTopic string
-	logger          *slog.Logger
-	db              *sql.DB
+	logger  *slog.Logger
+	db      *sql.DB
+	keyring *envelope.Keyring
 }
 
-func (s userStore) CreateUser(ctx context.Context, user *business.User) (err error) {
+func (s userStore) CreateUser(ctx context.Context, user *business.User, outbox business.OutboxFunc) (err error) {
 	// BEGIN
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -48,16 +50,13 @@ func (s userStore) CreateUser(ctx context.Context, user *business.User) (err err
 	}()
 
 	// Building SQL record for User entity
-	userSQL := NewUser(user)
-
-	// Inserting purchase record
-	err = s.createUser(ctx, tx, userSQL)
+	userSQL, err := NewUser(user, s.keyring)
 	if err != nil {
 		return
 	}
 
-	// Inserting outbox message
-	err = s.insertCreateUserMsg(ctx, tx, userSQL, s.createUserTopic)
+	// Inserting purchase record
+	err = s.createUser(ctx, tx, userSQL, user.Email)
 	if err != nil {
 		return
 	}
@@ -65,20 +64,42 @@ func (s userStore) CreateUser(ctx context.Context, user *business.User) (err err
 	// Setting inserted user ID
 	user.ID = business.UserID(userSQL.ID.Int64)
 
+	// Auditing the creation
+	err = s.insertUserAudit(ctx, tx, business.NewAuditEntry(ctx, business.ActionCreateUser, business.User{}, *user))
+	if err != nil {
+		return
+	}
+
+	// Inserting outbox messages
+	err = s.insertOutboxMessages(ctx, tx, *user, outbox)
+	if err != nil {
+		return
+	}
+
 	// COMMIT
 	return tx.Commit()
 }
 
-func (s userStore) createUser(ctx context.Context, tx *sql.Tx, userSQL *User) (err error) {
+func (s userStore) createUser(ctx context.Context, tx *sql.Tx, userSQL *User, email business.Email) (err error) {
 	const violateUniqueConstraint = "23505"
 
+	ctx, span := startSpan(ctx, "INSERT", "users", insertUser)
+	defer func() {
+		endSpan(span, err)
+	}()
+
 	// Inserting SQL record
 	err = tx.QueryRowContext(
 		ctx,
 		insertUser,
 		userSQL.Name,
-		userSQL.Age,
+		userSQL.BirthDate,
 		userSQL.Email,
+		userSQL.EmailIndex,
+		userSQL.KeyID,
+		userSQL.DataKey,
+		userSQL.EmailVerificationHash,
+		userSQL.EmailVerificationExpiresAt,
 	).Scan(&userSQL.ID)
 	if err != nil {
 		s.logger.InfoContext(ctx, "failed_user_insert", "error", err, "error_type", reflect.TypeOf(err), "user_id", userSQL.ID)
@@ -87,7 +108,7 @@ func (s userStore) createUser(ctx context.Context, tx *sql.Tx, userSQL *User) (e
 		var pqErr *pq.Error
 
 		if errors.As(err, &pqErr) && pqErr.Code == violateUniqueConstraint {
-			err = fmt.Errorf("%w: email '%s' already exists", business.ErrDuplicateUserEmail, userSQL.Email.String)
+			err = fmt.Errorf("%w: email '%s' already exists", business.ErrDuplicateUserEmail.With("email", email.String()), email)
 			return
 		}
 
@@ -97,32 +118,42 @@ func (s userStore) createUser(ctx context.Context, tx *sql.Tx, userSQL *User) (e
 	return
 }
 
-func (s userStore) insertCreateUserMsg(ctx context.Context, tx *sql.Tx, user *User, topic string) (err error) {
-	value, err := user.MarshalBinary()
+// insertOutboxMessages inserts the messages built by outbox for user in the same transaction
+func (s userStore) insertOutboxMessages(ctx context.Context, tx *sql.Tx, user business.User, outbox business.OutboxFunc) (err error) {
+	messages, err := outbox(user)
 	if err != nil {
 		return
 	}
 
-	msg := business.Message{
-		Value: value,
-		Topic: topic,
+	for _, msg := range messages {
+		err = s.insertOutboxMessage(ctx, tx, NewMessage(msg))
+		if err != nil {
+			return
+		}
 	}
 
-	err = msg.Idempotent()
-	if err != nil {
-		return
-	}
+	return
+}
 
-	stmt, args, err := insertOutboxMessage(NewMessage(msg))
+func (s userStore) insertOutboxMessage(ctx context.Context, tx *sql.Tx, message Message) (err error) {
+	// Storing the trace context to resume it when the message is relayed
+	otel.GetTextMapPropagator().Inject(ctx, headersCarrier{headers: &message.Headers})
+
+	stmt, args, err := insertOutboxMessage(message)
 	if err != nil {
 		return err
 	}
 
+	ctx, span := startSpan(ctx, "INSERT", "outbox_messages", stmt)
+	defer func() {
+		endSpan(span, err)
+	}()
+
 	_, err = tx.ExecContext(ctx, stmt, args...)
 	return
 }
 
-func (s userStore) UpdateUser(ctx context.Context, user *business.User) error {
+func (s userStore) UpdateUser(ctx context.Context, user *business.User, update business.UpdateFunc, outbox business.OutboxFunc) error {
 	// BEGIN
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -133,16 +164,33 @@ func (s userStore) UpdateUser(ctx context.Context, user *business.User) error {
 		_ = tx.Rollback()
 	}()
 
-	// Building SQL record for User entity
-	userSQL := NewUser(user)
+	// Recording the state before the update
+	before, err := s.lockUser(ctx, tx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	update(before, user)
+
+	// Building SQL record for User entity, the record is encrypted with a new data key wrapped by the primary key
+	userSQL, err := NewUser(user, s.keyring)
+	if err != nil {
+		return err
+	}
 
 	err = s.updateUser(ctx, tx, userSQL)
 	if err != nil {
 		return err
 	}
 
-	// Inserting outbox message
-	err = s.insertCreateUserMsg(ctx, tx, userSQL, s.updateUserTopic)
+	// Auditing the update
+	err = s.insertUserAudit(ctx, tx, business.NewAuditEntry(ctx, business.ActionUpdateUser, before, *user))
+	if err != nil {
+		return err
+	}
+
+	// Inserting outbox messages
+	err = s.insertOutboxMessages(ctx, tx, *user, outbox)
 	if err != nil {
 		return err
 	}
@@ -151,12 +199,23 @@ func (s userStore) UpdateUser(ctx context.Context, user *business.User) error {
 }
 
 func (s userStore) updateUser(ctx context.Context, tx *sql.Tx, userSQL *User) (err error) {
+	ctx, span := startSpan(ctx, "UPDATE", "users", updateUser)
+	defer func() {
+		endSpan(span, err)
+	}()
+
 	result, err := tx.ExecContext(
 		ctx,
 		updateUser,
 		userSQL.Name,
-		userSQL.Age,
+		userSQL.BirthDate,
 		userSQL.Email,
+		userSQL.EmailIndex,
+		userSQL.KeyID,
+		userSQL.DataKey,
+		userSQL.EmailVerifiedAt,
+		userSQL.EmailVerificationHash,
+		userSQL.EmailVerificationExpiresAt,
 		userSQL.ID,
 	)
 	if err != nil {
@@ -169,33 +228,329 @@ func (s userStore) updateUser(ctx context.Context, tx *sql.Tx, userSQL *User) (e
 	}
 
 	if affected != 1 {
-		err = fmt.Errorf("%w: unable to update user %d", business.ErrUserNotFound, userSQL.ID.Int64)
+		err = fmt.Errorf("%w: unable to update user %d", business.ErrUserNotFound.With("user_id", strconv.FormatInt(userSQL.ID.Int64, 10)), userSQL.ID.Int64)
+		return
+	}
+
+	return
+}
+
+func (s userStore) VerifyEmail(ctx context.Context, id business.UserID, hash []byte, outbox business.OutboxFunc) error {
+	// BEGIN
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// ROLLBACK
+		_ = tx.Rollback()
+	}()
+
+	// Recording the state before the verification
+	before, err := s.lockUser(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	after := before
+
+	after.EmailVerifiedAt, err = s.verifyEmail(ctx, tx, id, hash)
+	if err != nil {
+		return err
+	}
+
+	// Auditing the verification
+	err = s.insertUserAudit(ctx, tx, business.NewAuditEntry(ctx, business.ActionVerifyEmail, before, after))
+	if err != nil {
+		return err
+	}
+
+	// Inserting outbox messages
+	err = s.insertOutboxMessages(ctx, tx, after, outbox)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s userStore) verifyEmail(ctx context.Context, tx *sql.Tx, id business.UserID, hash []byte) (_ time.Time, err error) {
+	ctx, span := startSpan(ctx, "UPDATE", "users", verifyUserEmail)
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	var verifiedAt time.Time
+
+	err = tx.QueryRowContext(ctx, verifyUserEmail, id, hash).Scan(&verifiedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = fmt.Errorf("%w: the token of user %d is not pending or it expired", business.ErrInvalidEmailVerificationToken, id)
+	}
+
+	return verifiedAt, err
+}
+
+func (s userStore) DeleteUser(ctx context.Context, id business.UserID, outbox business.OutboxFunc) error {
+	// BEGIN
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// ROLLBACK
+		_ = tx.Rollback()
+	}()
+
+	// Recording the state before the deletion
+	before, err := s.lockUser(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	err = s.deleteUser(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	// Auditing the deletion
+	err = s.insertUserAudit(ctx, tx, business.NewAuditEntry(ctx, business.ActionDeleteUser, before, business.User{ID: id}))
+	if err != nil {
+		return err
+	}
+
+	// Inserting outbox messages
+	err = s.insertOutboxMessages(ctx, tx, business.User{ID: id}, outbox)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s userStore) deleteUser(ctx context.Context, tx *sql.Tx, id business.UserID) (err error) {
+	ctx, span := startSpan(ctx, "UPDATE", "users", softDeleteUser)
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	result, err := tx.ExecContext(ctx, softDeleteUser, id)
+	if err != nil {
+		return
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return
+	}
+
+	if affected != 1 {
+		err = fmt.Errorf("%w: unable to delete user %d", business.ErrUserNotFound.With("user_id", strconv.FormatUint(uint64(id), 10)), id)
 		return
 	}
 
 	return
 }
 
-func (s userStore) QueryUser(ctx context.Context, id business.UserID) (business.User, error) {
+// lockUser selects the user and locks it until the end of tx
+func (s userStore) lockUser(ctx context.Context, tx *sql.Tx, id business.UserID) (_ business.User, err error) {
+	ctx, span := startSpan(ctx, "SELECT", "users", selectUserForUpdate)
+	defer func() {
+		endSpan(span, err)
+	}()
+
 	var userSQL User
 
-	err := s.db.QueryRowContext(
+	err = tx.QueryRowContext(
+		ctx,
+		selectUserForUpdate,
+		id,
+	).Scan(
+		&userSQL.ID,
+		&userSQL.Name,
+		&userSQL.BirthDate,
+		&userSQL.Email,
+		&userSQL.KeyID,
+		&userSQL.DataKey,
+		&userSQL.EmailVerifiedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = fmt.Errorf("%w: user %d not found", business.ErrUserNotFound.With("user_id", strconv.FormatUint(uint64(id), 10)), id)
+		}
+
+		return business.User{}, err
+	}
+
+	user, err := userSQL.ToBusiness(s.keyring)
+	if err != nil {
+		return business.User{}, err
+	}
+
+	return *user, nil
+}
+
+func (s userStore) insertUserAudit(ctx context.Context, tx *sql.Tx, entry business.AuditEntry) (err error) {
+	ctx, span := startSpan(ctx, "INSERT", "user_audit", insertUserAudit)
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	auditSQL, err := NewAuditEntry(entry, s.keyring)
+	if err != nil {
+		return
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		insertUserAudit,
+		auditSQL.UserID,
+		auditSQL.Actor,
+		auditSQL.RequestID,
+		auditSQL.Action,
+		auditSQL.Changes,
+		auditSQL.KeyID,
+		auditSQL.DataKey,
+		auditSQL.CreatedAt,
+	)
+	return
+}
+
+func (s userStore) QueryUser(ctx context.Context, id business.UserID) (_ business.User, err error) {
+	ctx, span := startSpan(ctx, "SELECT", "users", selectUser)
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	var userSQL User
+
+	err = s.db.QueryRowContext(
 		ctx,
 		selectUser,
 		id,
 	).Scan(
 		&userSQL.ID,
 		&userSQL.Name,
-		&userSQL.Age,
+		&userSQL.BirthDate,
 		&userSQL.Email,
+		&userSQL.KeyID,
+		&userSQL.DataKey,
+		&userSQL.EmailVerifiedAt,
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			err = fmt.Errorf("%w: user %d not found", business.ErrUserNotFound, id)
+			err = fmt.Errorf("%w: user %d not found", business.ErrUserNotFound.With("user_id", strconv.FormatUint(uint64(id), 10)), id)
 		}
 
 		return business.User{}, err
 	}
 
-	return *userSQL.ToBusiness(), nil
+	user, err := userSQL.ToBusiness(s.keyring)
+	if err != nil {
+		return business.User{}, err
+	}
+
+	return *user, nil
+}
+
+func (s userStore) QueryUsers(ctx context.Context, page business.UsersPage) (_ []business.User, err error) {
+	ctx, span := startSpan(ctx, "SELECT", "users", selectUsers)
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	rows, err := s.db.QueryContext(ctx, selectUsers, page.AfterID, page.Size)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	users := make([]business.User, 0, page.Size)
+
+	for rows.Next() {
+		var userSQL User
+
+		err = rows.Scan(
+			&userSQL.ID,
+			&userSQL.Name,
+			&userSQL.BirthDate,
+			&userSQL.Email,
+			&userSQL.KeyID,
+			&userSQL.DataKey,
+			&userSQL.EmailVerifiedAt,
+		)
+		if err != nil {
+			return
+		}
+
+		user, err := userSQL.ToBusiness(s.keyring)
+		if err != nil {
+			return nil, err
+		}
+
+		users = append(users, *user)
+	}
+
+	return users, rows.Err()
+}
+
+func (s userStore) QueryUserHistory(ctx context.Context, page business.HistoryPage) (_ []business.AuditEntry, err error) {
+	ctx, span := startSpan(ctx, "SELECT", "user_audit", selectUserAudit)
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	rows, err := s.db.QueryContext(ctx, selectUserAudit, page.UserID, page.AfterID, page.Size)
+	if err != nil {
+		return
+	}
+
+	auditSQL, err := scanAuditEntries(rows)
+	if err != nil {
+		return
+	}
+
+	entries := make([]business.AuditEntry, len(auditSQL))
+
+	for i := range auditSQL {
+		entries[i], err = auditSQL[i].ToBusiness(s.keyring)
+		if err != nil {
+			return
+		}
+	}
+
+	return entries, nil
+}
+
+// scanAuditEntries scans and closes the rows of user_audit
+func scanAuditEntries(rows *sql.Rows) (_ []AuditEntry, err error) {
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var entries []AuditEntry
+
+	for rows.Next() {
+		var auditSQL AuditEntry
+
+		err = rows.Scan(
+			&auditSQL.ID,
+			&auditSQL.UserID,
+			&auditSQL.Actor,
+			&auditSQL.RequestID,
+			&auditSQL.Action,
+			&auditSQL.Changes,
+			&auditSQL.KeyID,
+			&auditSQL.DataKey,
+			&auditSQL.CreatedAt,
+		)
+		if err != nil {
+			return
+		}
+
+		entries = append(entries, auditSQL)
+	}
+
+	return entries, rows.Err()
 }
@@ -0,0 +1,83 @@
+//go:build http || grpc
+
+package container
+
+import (
+	"context"
+	"database/sql"
+	"github.com/yael-castro/goarch/internal/app/business"
+	"github.com/yael-castro/goarch/internal/app/output/decorator"
+	"github.com/yael-castro/goarch/internal/app/output/postgres"
+	"github.com/yael-castro/goarch/pkg/env"
+	"github.com/yael-castro/goarch/pkg/jwtauth"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+)
+
+// injectAPIKeyCases builds the business.APIKeyCases that authenticate the API keys of the http and grpc servers
+func (c *container) injectAPIKeyCases(ctx context.Context, cases *business.APIKeyCases) (err error) {
+	// External dependencies
+	var db *sql.DB
+	if err = c.Inject(ctx, &db); err != nil {
+		return err
+	}
+
+	var logger *slog.Logger
+	if err = c.Inject(ctx, &logger); err != nil {
+		return err
+	}
+
+	// Secondary adapters
+	apiKeyStore := postgres.NewAPIKeyStore(postgres.APIKeyStoreConfig{
+		Logger: logger,
+		DB:     db,
+	})
+
+	// Business logic
+	apiKeyCases, err := business.NewAPIKeyCases(apiKeyStore, business.NewScopePolicy())
+	if err != nil {
+		return err
+	}
+
+	// Decorators
+	*cases, err = decorator.NewAPIKeyCasesTracing(apiKeyCases)
+	return
+}
+
+// newJWTVerifier builds the verifier of the bearer tokens of the http and grpc servers, the keys are loaded from
+// JWKS_URL or JWKS_FILE
+func newJWTVerifier(ctx context.Context, logger *slog.Logger) (*jwtauth.Verifier, error) {
+	const (
+		jwksTimeout         = 5 * time.Second
+		jwksRefreshInterval = 15 * time.Minute
+	)
+
+	issuer, err := env.Get("JWT_ISSUER")
+	if err != nil {
+		return nil, err
+	}
+
+	audience, err := env.Get("JWT_AUDIENCE")
+	if err != nil {
+		return nil, err
+	}
+
+	jwks, err := jwtauth.NewJWKS(ctx, jwtauth.JWKSConfig{
+		URL:             os.Getenv("JWKS_URL"),
+		Client:          &http.Client{Timeout: jwksTimeout},
+		File:            os.Getenv("JWKS_FILE"),
+		RefreshInterval: jwksRefreshInterval,
+		Logger:          logger,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return jwtauth.NewVerifier(jwtauth.VerifierConfig{
+		Keyfunc:  jwks.Keyfunc,
+		Issuer:   issuer,
+		Audience: audience,
+	})
+}
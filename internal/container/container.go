@@ -3,9 +3,19 @@ package container
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	_ "github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/yael-castro/goarch/internal/runtime"
 	"github.com/yael-castro/goarch/pkg/env"
+	"github.com/yael-castro/goarch/pkg/envelope"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
 	"log/slog"
 	"os"
 	"sync"
@@ -18,8 +28,11 @@ type Container interface {
 
 type container struct {
 	sync.Mutex
-	db     *sql.DB
-	logger *slog.Logger
+	db             *sql.DB
+	logger         *slog.Logger
+	registry       *prometheus.Registry
+	tracerProvider *sdktrace.TracerProvider
+	keyring        *envelope.Keyring
 }
 
 func (c *container) Inject(ctx context.Context, a any) error {
@@ -28,20 +41,31 @@ func (c *container) Inject(ctx context.Context, a any) error {
 		return c.injectDB(ctx, a)
 	case **slog.Logger:
 		return c.injectLogger(ctx, a)
+	case **prometheus.Registry:
+		return c.injectRegistry(ctx, a)
+	case **sdktrace.TracerProvider:
+		return c.injectTracerProvider(ctx, a)
+	case **envelope.Keyring:
+		return c.injectKeyring(ctx, a)
 	}
 
 	return fmt.Errorf("type \"%T\" is not supported", a)
 }
 
-func (c *container) Close(_ context.Context) error {
+func (c *container) Close(ctx context.Context) (err error) {
 	c.Lock()
 	defer c.Unlock()
 
+	// Exporting the remaining spans
+	if c.tracerProvider != nil {
+		err = c.tracerProvider.Shutdown(ctx)
+	}
+
 	if c.db != nil {
-		return c.db.Close()
+		err = errors.Join(err, c.db.Close())
 	}
 
-	return nil
+	return
 }
 
 func (c *container) injectLogger(ctx context.Context, logger **slog.Logger) (err error) {
@@ -66,6 +90,121 @@ func (c *container) initLogger(context.Context) (err error) {
 	return
 }
 
+func (c *container) injectRegistry(ctx context.Context, registry **prometheus.Registry) (err error) {
+	err = c.initRegistry(ctx)
+	if err != nil {
+		return
+	}
+
+	*registry = c.registry
+	return
+}
+
+func (c *container) initRegistry(context.Context) (err error) {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.registry != nil {
+		return
+	}
+
+	newRegistry := prometheus.NewRegistry()
+
+	err = errors.Join(
+		newRegistry.Register(collectors.NewGoCollector()),
+		newRegistry.Register(collectors.NewProcessCollector(collectors.ProcessCollectorOpts{})),
+	)
+	if err != nil {
+		return
+	}
+
+	c.registry = newRegistry
+	return
+}
+
+func (c *container) injectTracerProvider(ctx context.Context, tracerProvider **sdktrace.TracerProvider) (err error) {
+	err = c.initTracerProvider(ctx)
+	if err != nil {
+		return
+	}
+
+	*tracerProvider = c.tracerProvider
+	return
+}
+
+// initTracerProvider builds the tracer provider with the exporter selected by OTEL_TRACES_EXPORTER (otlp, console or none)
+func (c *container) initTracerProvider(ctx context.Context) (err error) {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.tracerProvider != nil {
+		return
+	}
+
+	res, err := resource.Merge(
+		resource.Default(),
+		resource.NewSchemaless(semconv.ServiceVersion(runtime.GitCommit)),
+	)
+	if err != nil {
+		return
+	}
+
+	options := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(res),
+	}
+
+	var exporter sdktrace.SpanExporter
+
+	switch tracesExporter := os.Getenv("OTEL_TRACES_EXPORTER"); tracesExporter {
+	case "otlp":
+		// The endpoint is configured by the OTEL_EXPORTER_OTLP_* environment variables
+		exporter, err = otlptracehttp.New(ctx)
+	case "console":
+		exporter, err = stdouttrace.New()
+	case "", "none":
+	default:
+		err = fmt.Errorf("traces exporter \"%s\" is not supported", tracesExporter)
+	}
+	if err != nil {
+		return
+	}
+
+	if exporter != nil {
+		options = append(options, sdktrace.WithBatcher(exporter))
+	}
+
+	c.tracerProvider = sdktrace.NewTracerProvider(options...)
+	return
+}
+
+func (c *container) injectKeyring(ctx context.Context, keyring **envelope.Keyring) (err error) {
+	err = c.initKeyring(ctx)
+	if err != nil {
+		return
+	}
+
+	*keyring = c.keyring
+	return
+}
+
+// initKeyring reads the keyring that encrypts the PII from the file PII_KEYRING_FILE (see envelope.ParseKeyring)
+func (c *container) initKeyring(context.Context) (err error) {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.keyring != nil {
+		return
+	}
+
+	keyringFile, err := env.Get("PII_KEYRING_FILE")
+	if err != nil {
+		return
+	}
+
+	c.keyring, err = envelope.ReadKeyring(keyringFile)
+	return
+}
+
 func (c *container) injectDB(ctx context.Context, db **sql.DB) (err error) {
 	err = c.initDB(ctx)
 	if err != nil {
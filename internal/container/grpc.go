@@ -0,0 +1,100 @@
+//go:build grpc
+
+package container
+
+import (
+	"context"
+	"database/sql"
+	"github.com/yael-castro/goarch/internal/app/business"
+	usersgrpc "github.com/yael-castro/goarch/internal/app/input/grpc"
+	"google.golang.org/grpc"
+	"log/slog"
+)
+
+func New() Container {
+	return new(server)
+}
+
+type server struct {
+	container
+}
+
+func (s *server) Inject(ctx context.Context, a any) error {
+	switch a := a.(type) {
+	case **grpc.Server:
+		return s.injectServer(ctx, a)
+	case *business.UserCases:
+		const eventSource = "/goarch/users-grpc"
+		return s.injectUserCases(ctx, a, eventSource)
+	case *business.APIKeyCases:
+		return s.injectAPIKeyCases(ctx, a)
+	}
+
+	return s.container.Inject(ctx, a)
+}
+
+func (s *server) injectServer(ctx context.Context, g **grpc.Server) (err error) {
+	// External dependencies
+	var db *sql.DB
+	if err = s.Inject(ctx, &db); err != nil {
+		return err
+	}
+
+	var logger *slog.Logger
+	if err = s.Inject(ctx, &logger); err != nil {
+		return err
+	}
+
+	// Business logic
+	var userCases business.UserCases
+	if err = s.Inject(ctx, &userCases); err != nil {
+		return err
+	}
+
+	var apiKeyCases business.APIKeyCases
+	if err = s.Inject(ctx, &apiKeyCases); err != nil {
+		return err
+	}
+
+	// Primary adapters
+	userServer, err := usersgrpc.NewUserServer(userCases)
+	if err != nil {
+		return err
+	}
+
+	verifier, err := newJWTVerifier(ctx, logger)
+	if err != nil {
+		return err
+	}
+
+	// The callers are authenticated with the API keys or the tokens accepted by the http server
+	authentication, err := usersgrpc.Authentication(usersgrpc.AuthenticationConfig{
+		Cases:    apiKeyCases,
+		Verifier: verifier,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Building grpc.Server
+	n := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			usersgrpc.Recover(logger),
+			usersgrpc.Tracing(),
+			usersgrpc.RequestID(),
+			usersgrpc.ErrorInterceptor(),
+			authentication,
+		),
+	)
+
+	// Setting health checks
+	dbCheck := func(ctx context.Context) error {
+		return db.PingContext(ctx)
+	}
+
+	// Setting grpc services
+	usersgrpc.SetServices(n, userServer, dbCheck)
+
+	*g = n
+	return
+}
@@ -5,13 +5,20 @@ package container
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/yael-castro/goarch/docs"
 	"github.com/yael-castro/goarch/internal/app/business"
 	"github.com/yael-castro/goarch/internal/app/input/http"
 	"github.com/yael-castro/goarch/internal/app/output/postgres"
-	"github.com/yael-castro/goarch/pkg/env"
+	"github.com/yael-castro/goarch/internal/runtime"
+	"github.com/yael-castro/goarch/pkg/ratelimit"
 	"log/slog"
+	"net"
+	"os"
+	"strings"
 )
 
 func New() Container {
@@ -22,54 +29,120 @@ type handler struct {
 	container
 }
 
+// AdminServer serves the metrics on the admin port, apart from the public API
+type AdminServer struct {
+	*echo.Echo
+}
+
 func (h *handler) Inject(ctx context.Context, a any) error {
 	switch a := a.(type) {
 	case **echo.Echo:
 		return h.injectEcho(ctx, a)
+	case *AdminServer:
+		return h.injectAdminServer(ctx, a)
+	case *business.UserCases:
+		const eventSource = "/goarch/users-http"
+		return h.injectUserCases(ctx, a, eventSource)
+	case *business.APIKeyCases:
+		return h.injectAPIKeyCases(ctx, a)
 	}
 
 	return h.container.Inject(ctx, a)
 }
 
 func (h *handler) injectEcho(ctx context.Context, e **echo.Echo) (err error) {
-	// Getting environment variables
-	createUserTopic, err := env.Get("CREATE_USER_TOPIC")
+	// External dependencies
+	var db *sql.DB
+	if err = h.Inject(ctx, &db); err != nil {
+		return err
+	}
+
+	var registry *prometheus.Registry
+	if err = h.Inject(ctx, &registry); err != nil {
+		return err
+	}
+
+	var logger *slog.Logger
+	if err = h.Inject(ctx, &logger); err != nil {
+		return err
+	}
+
+	// Business logic
+	var userCases business.UserCases
+	if err = h.Inject(ctx, &userCases); err != nil {
+		return err
+	}
+
+	var apiKeyCases business.APIKeyCases
+	if err = h.Inject(ctx, &apiKeyCases); err != nil {
+		return err
+	}
+
+	// Primary adapters
+	userHandler, err := http.NewUserHandler(userCases)
 	if err != nil {
 		return err
 	}
 
-	updateUserTopic, err := env.Get("UPDATE_USER_TOPIC")
+	apiKeyHandler, err := http.NewAPIKeyHandler(apiKeyCases)
 	if err != nil {
 		return err
 	}
 
-	// External dependencies
-	var db *sql.DB
-	if err = h.Inject(ctx, &db); err != nil {
+	metrics, err := http.Metrics(registry)
+	if err != nil {
 		return err
 	}
 
-	var logger *slog.Logger
-	if err = h.Inject(ctx, &logger); err != nil {
+	catalog, err := http.NewCatalog()
+	if err != nil {
 		return err
 	}
 
-	// Secondary adapters
-	userStore := postgres.NewUserStore(postgres.UserStoreConfig{
-		CreateUserTopic: createUserTopic,
-		UpdateUserTopic: updateUserTopic,
-		Logger:          logger,
-		DB:              db,
+	openAPI, err := http.NewOpenAPI(ctx, docs.OpenAPI)
+	if err != nil {
+		return err
+	}
+
+	validator, err := http.OpenAPIValidator(openAPI)
+	if err != nil {
+		return err
+	}
+
+	docsHandler, err := http.NewDocsHandler(openAPI, runtime.GitCommit)
+	if err != nil {
+		return err
+	}
+
+	authentication, err := newAuthentication(ctx, logger)
+	if err != nil {
+		return err
+	}
+
+	apiKeyAuthentication, err := http.APIKeyAuthentication(http.APIKeyAuthenticationConfig{
+		Cases:   apiKeyCases,
+		Skipper: http.IsPublicRoute,
 	})
+	if err != nil {
+		return err
+	}
 
-	// Business logic
-	userCases, err := business.NewUserCases(userStore)
+	rateLimitConfig, err := newRateLimitConfig(db, logger)
 	if err != nil {
 		return err
 	}
 
-	// Primary adapters
-	userHandler, err := http.NewUserHandler(userCases)
+	ipRateLimit, err := newIPRateLimit(rateLimitConfig)
+	if err != nil {
+		return err
+	}
+
+	rateLimit, err := http.RateLimit(rateLimitConfig)
+	if err != nil {
+		return err
+	}
+
+	ipExtractor, err := newIPExtractor()
 	if err != nil {
 		return err
 	}
@@ -78,18 +151,33 @@ func (h *handler) injectEcho(ctx context.Context, e **echo.Echo) (err error) {
 	n := echo.New()
 
 	// Setting error handler
-	n.HTTPErrorHandler = http.ErrorHandler(n.HTTPErrorHandler)
+	n.HTTPErrorHandler = http.ErrorHandler(n.HTTPErrorHandler, catalog)
 
-	// Setting middlewares
-	n.Use(middleware.Recover(), middleware.Logger())
+	// Setting the IP of the clients (rate limits and logs)
+	n.IPExtractor = ipExtractor
+
+	// Setting middlewares (the IPs are limited before the authentication and the clients after it)
+	middlewares := []echo.MiddlewareFunc{middleware.Recover(), http.RequestID(), middleware.Logger(), metrics, http.Tracing()}
+	if ipRateLimit != nil {
+		middlewares = append(middlewares, ipRateLimit)
+	}
+
+	n.Use(append(middlewares, apiKeyAuthentication, authentication, rateLimit, validator)...)
 
 	// Setting health checks
 	dbCheck := func(ctx context.Context) error {
-		return h.db.PingContext(ctx)
+		return db.PingContext(ctx)
 	}
 
 	// Setting http routes
 	http.SetRoutes(n, userHandler, dbCheck)
+	http.SetAPIKeyRoutes(n, apiKeyHandler)
+	http.SetDocsRoutes(n, docsHandler)
+
+	// Every route must be documented since the requests are validated against the spec
+	if err = http.VerifyOpenAPI(openAPI, n.Routes()); err != nil {
+		return err
+	}
 
 	// Disabling initial logs
 	n.HideBanner = true
@@ -98,3 +186,142 @@ func (h *handler) injectEcho(ctx context.Context, e **echo.Echo) (err error) {
 	*e = n
 	return
 }
+
+func (h *handler) injectAdminServer(ctx context.Context, admin *AdminServer) (err error) {
+	var registry *prometheus.Registry
+	if err = h.Inject(ctx, &registry); err != nil {
+		return err
+	}
+
+	// Building echo.Echo
+	n := echo.New()
+
+	// Setting middlewares
+	n.Use(middleware.Recover())
+
+	// Setting http routes
+	http.SetMetricsRoutes(n, registry)
+
+	// Disabling initial logs
+	n.HideBanner = true
+	n.HidePort = true
+
+	admin.Echo = n
+	return
+}
+
+// newAuthentication builds the JWT authentication of the users routes
+func newAuthentication(ctx context.Context, logger *slog.Logger) (echo.MiddlewareFunc, error) {
+	verifier, err := newJWTVerifier(ctx, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	return http.Authentication(http.AuthenticationConfig{
+		Verifier: verifier,
+		Skipper:  http.IsPublicRoute,
+	})
+}
+
+// newRateLimitConfig builds the rate limits of the routes applied to each authenticated client,
+// configured by RATE_LIMIT (default limit), RATE_LIMITS (limits by route group) and RATE_LIMIT_STORE (memory or
+// postgres to share the limits across the replicas)
+func newRateLimitConfig(db *sql.DB, logger *slog.Logger) (http.RateLimitConfig, error) {
+	const defaultLimit = "600/1m"
+
+	config := http.RateLimitConfig{
+		Groups: make(map[string]ratelimit.Limit),
+		Logger: logger,
+	}
+
+	limit := os.Getenv("RATE_LIMIT")
+	if len(limit) == 0 {
+		limit = defaultLimit
+	}
+
+	var err error
+
+	config.Default, err = ratelimit.ParseLimit(limit)
+	if err != nil {
+		return config, err
+	}
+
+	// Format: <group>=<requests>/<period>,... (e.g. POST /v1/users=60/1m)
+	for _, groupLimit := range strings.Split(os.Getenv("RATE_LIMITS"), ",") {
+		if groupLimit = strings.TrimSpace(groupLimit); len(groupLimit) == 0 {
+			continue
+		}
+
+		group, limit, ok := strings.Cut(groupLimit, "=")
+		if !ok {
+			return config, fmt.Errorf("invalid rate limit '%s': expected <group>=<requests>/<period>", groupLimit)
+		}
+
+		config.Groups[strings.TrimSpace(group)], err = ratelimit.ParseLimit(limit)
+		if err != nil {
+			return config, err
+		}
+	}
+
+	switch store := os.Getenv("RATE_LIMIT_STORE"); store {
+	case "", "memory":
+		config.Store = ratelimit.NewMemoryStore()
+	case "postgres":
+		config.Store = postgres.NewRateLimitStore(postgres.RateLimitStoreConfig{
+			Logger: logger,
+			DB:     db,
+		})
+	default:
+		return config, fmt.Errorf("rate limit store '%s' is not supported", store)
+	}
+
+	return config, nil
+}
+
+// newIPRateLimit builds the limit of the requests of each IP configured by IP_RATE_LIMIT, it is disabled (nil) if
+// IP_RATE_LIMIT is empty. The IP of the clients behind a proxy out of TRUSTED_PROXIES is the IP of the proxy, so
+// all of them would share the same limit
+func newIPRateLimit(config http.RateLimitConfig) (echo.MiddlewareFunc, error) {
+	limit := os.Getenv("IP_RATE_LIMIT")
+	if len(limit) == 0 {
+		return nil, nil
+	}
+
+	var err error
+
+	config.Default, err = ratelimit.ParseLimit(limit)
+	if err != nil {
+		return nil, err
+	}
+
+	// The same limit applies to every route
+	config.Groups = nil
+
+	return http.IPRateLimit(config)
+}
+
+// newIPExtractor resolves the IP of the clients from the X-Forwarded-For header set by the proxies of TRUSTED_PROXIES
+// (comma separated CIDRs), the header is ignored if there are no trusted proxies so the clients can not spoof their IP
+func newIPExtractor() (echo.IPExtractor, error) {
+	trustedProxies := splitList(os.Getenv("TRUSTED_PROXIES"))
+	if len(trustedProxies) == 0 {
+		return echo.ExtractIPDirect(), nil
+	}
+
+	options := []echo.TrustOption{
+		echo.TrustLoopback(false),
+		echo.TrustLinkLocal(false),
+		echo.TrustPrivateNet(false),
+	}
+
+	for _, proxy := range trustedProxies {
+		_, ipRange, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy '%s': %w", proxy, err)
+		}
+
+		options = append(options, echo.TrustIPRange(ipRange))
+	}
+
+	return echo.ExtractIPFromXFFHeader(options...), nil
+}
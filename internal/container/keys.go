@@ -0,0 +1,67 @@
+//go:build keys
+
+package container
+
+import (
+	"context"
+	"database/sql"
+	"github.com/yael-castro/goarch/internal/app/input/command"
+	"github.com/yael-castro/goarch/internal/app/output/postgres"
+	"github.com/yael-castro/goarch/pkg/envelope"
+	"log/slog"
+)
+
+func New() Container {
+	return new(keys)
+}
+
+type keys struct {
+	container
+}
+
+func (k *keys) Inject(ctx context.Context, a any) error {
+	switch a := a.(type) {
+	case *func(context.Context, ...string) int:
+		return k.injectCommand(ctx, a)
+	}
+
+	return k.container.Inject(ctx, a)
+}
+
+func (k *keys) injectCommand(ctx context.Context, cmd *func(context.Context, ...string) int) (err error) {
+	const batchSize = 500
+
+	// External dependencies
+	var db *sql.DB
+	if err = k.Inject(ctx, &db); err != nil {
+		return
+	}
+
+	var logger *slog.Logger
+	if err = k.Inject(ctx, &logger); err != nil {
+		return
+	}
+
+	var keyring *envelope.Keyring
+	if err = k.Inject(ctx, &keyring); err != nil {
+		return
+	}
+
+	// Secondary adapters
+	keyRotator := postgres.NewKeyRotator(postgres.KeyRotatorConfig{
+		Logger:    logger,
+		DB:        db,
+		Keyring:   keyring,
+		BatchSize: batchSize,
+	})
+
+	emailIndexer := postgres.NewEmailIndexer(postgres.EmailIndexerConfig{
+		Logger:  logger,
+		DB:      db,
+		Keyring: keyring,
+	})
+
+	// Primary adapters
+	*cmd, err = command.Keys(keyRotator, emailIndexer, logger)
+	return
+}
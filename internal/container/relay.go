@@ -6,14 +6,24 @@ import (
 	"context"
 	"database/sql"
 	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sony/gobreaker/v2"
 	"github.com/yael-castro/goarch/internal/app/business"
 	"github.com/yael-castro/goarch/internal/app/input/command"
+	"github.com/yael-castro/goarch/internal/app/input/http"
 	"github.com/yael-castro/goarch/internal/app/output/decorator"
 	userskafka "github.com/yael-castro/goarch/internal/app/output/kafka"
 	"github.com/yael-castro/goarch/internal/app/output/postgres"
+	"github.com/yael-castro/goarch/pkg/avsc"
 	"github.com/yael-castro/goarch/pkg/env"
+	"github.com/yael-castro/goarch/pkg/pb"
+	"google.golang.org/protobuf/reflect/protoreflect"
 	"log/slog"
+	gohttp "net/http"
+	"os"
+	"strings"
 	"time"
 )
 
@@ -27,6 +37,9 @@ type usersRelay struct {
 	container
 	logger   *slog.Logger
 	producer *kafka.Producer
+	elector  business.LeaderElector
+	breaker  *gobreaker.CircuitBreaker[struct{}]
+	relay    business.MessagesRelay
 }
 
 func (r *usersRelay) Inject(ctx context.Context, a any) (err error) {
@@ -37,12 +50,57 @@ func (r *usersRelay) Inject(ctx context.Context, a any) (err error) {
 		return r.injectProducer(ctx, a)
 	case **gobreaker.CircuitBreaker[struct{}]:
 		return r.injectCircuitBreaker(ctx, a)
+	case *business.LeaderElector:
+		return r.injectLeaderElector(ctx, a)
+	case *business.MessagesRelay:
+		return r.injectMessagesRelay(ctx, a)
+	case **echo.Echo:
+		return r.injectEcho(ctx, a)
 	}
 
 	return r.container.Inject(ctx, a)
 }
 
 func (r *usersRelay) injectCommand(ctx context.Context, cmd *func(context.Context, ...string) int) (err error) {
+	// Business logic
+	var messagesRelay business.MessagesRelay
+	if err = r.Inject(ctx, &messagesRelay); err != nil {
+		return
+	}
+
+	var logger *slog.Logger
+	if err = r.Inject(ctx, &logger); err != nil {
+		return
+	}
+
+	// Primary adapters
+	cmdRelay, err := command.Relay(messagesRelay, logger)
+	if err != nil {
+		return
+	}
+
+	*cmd = cmdRelay
+	return
+}
+
+func (r *usersRelay) injectMessagesRelay(ctx context.Context, relay *business.MessagesRelay) error {
+	if err := r.initMessagesRelay(ctx); err != nil {
+		return err
+	}
+
+	*relay = r.relay
+	return nil
+}
+
+func (r *usersRelay) initMessagesRelay(ctx context.Context) (err error) {
+	r.Lock()
+	initialized := r.relay != nil
+	r.Unlock()
+
+	if initialized {
+		return
+	}
+
 	// External dependencies
 	var db *sql.DB
 	if err = r.Inject(ctx, &db); err != nil {
@@ -64,17 +122,40 @@ func (r *usersRelay) injectCommand(ctx context.Context, cmd *func(context.Contex
 		return
 	}
 
+	var elector business.LeaderElector
+	if err = r.Inject(ctx, &elector); err != nil {
+		return
+	}
+
+	var registry *prometheus.Registry
+	if err = r.Inject(ctx, &registry); err != nil {
+		return
+	}
+
 	// Secondary adapters
 	confirmer := postgres.NewMessageDeliveryConfirmer(db)
 
 	reader := postgres.NewMessagesReader(db, logger)
 
+	backlog := postgres.NewMessagesBacklogReader(db)
+
+	encoder, err := newValueEncoder()
+	if err != nil {
+		return
+	}
+
 	sender := userskafka.NewMessageSender(userskafka.MessageSenderConfig{
 		Logger:   logger,
 		Producer: producer,
+		Encoder:  encoder,
 	})
 
 	// Decorating secondary adapters
+	confirmer, err = decorator.NewConfirmerMetrics(confirmer, registry)
+	if err != nil {
+		return
+	}
+
 	sender, err = decorator.NewSenderBreaker(sender, breaker)
 	if err != nil {
 		return
@@ -85,24 +166,207 @@ func (r *usersRelay) injectCommand(ctx context.Context, cmd *func(context.Contex
 		return
 	}
 
+	// The metrics wrap the outermost sender, so they include the open circuit and the retried sends
+	sender, err = decorator.NewSenderMetrics(sender, registry)
+	if err != nil {
+		return
+	}
+
 	// Business logic
+	const batchGracePeriod = 5 * time.Second
+
 	messagesRelay, err := business.NewMessagesRelay(business.MessagesRelayConfig{
-		Reader:    reader,
-		Sender:    sender,
-		Confirmer: confirmer,
-		Logger:    logger,
+		Reader:      reader,
+		Backlog:     backlog,
+		Sender:      sender,
+		Confirmer:   confirmer,
+		Logger:      logger,
+		GracePeriod: batchGracePeriod,
 	})
 	if err != nil {
 		return
 	}
 
+	// Only one replica relays messages at the same time (active/passive)
+	messagesRelay, err = business.NewLeaderMessagesRelay(messagesRelay, elector, logger)
+	if err != nil {
+		return
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	if r.relay == nil {
+		r.relay = messagesRelay
+	}
+
+	return
+}
+
+// newValueEncoder builds the schema registry encoder of the user topics, it is nil if SCHEMA_REGISTRY_URL is not set
+func newValueEncoder() (userskafka.ValueEncoder, error) {
+	registryURL := os.Getenv("SCHEMA_REGISTRY_URL")
+	if len(registryURL) == 0 {
+		return nil, nil
+	}
+
+	// Protobuf message and Avro schema of each topic, the Avro schema matches the JSON schema of the topic
+	// (user.avsc is the shared schema of the user events as user.v1.json, see pkg/avsc)
+	topics := map[string]struct {
+		descriptor protoreflect.MessageDescriptor
+		avro       string
+	}{
+		"CREATE_USER_TOPIC":  {(&pb.UserCreated{}).ProtoReflect().Descriptor(), avsc.User},
+		"UPDATE_USER_TOPIC":  {(&pb.UserUpdated{}).ProtoReflect().Descriptor(), avsc.User},
+		"DELETE_USER_TOPIC":  {(&pb.UserDeleted{}).ProtoReflect().Descriptor(), avsc.User},
+		"ERASE_USER_TOPIC":   {(&pb.UserErased{}).ProtoReflect().Descriptor(), avsc.User},
+		"VERIFY_EMAIL_TOPIC": {(&pb.UserEmailVerified{}).ProtoReflect().Descriptor(), avsc.User},
+		"REQUEST_EMAIL_VERIFICATION_TOPIC": {
+			(&pb.UserEmailVerificationRequested{}).ProtoReflect().Descriptor(),
+			avsc.EmailVerification,
+		},
+	}
+
+	protobufTopics := make(map[string]bool)
+
+	for _, topic := range strings.Split(os.Getenv("PROTOBUF_TOPICS"), ",") {
+		protobufTopics[strings.TrimSpace(topic)] = true
+	}
+
+	schemas := make(map[string]userskafka.Schema, len(topics))
+
+	for key, schema := range topics {
+		topic, err := env.Get(key)
+		if err != nil {
+			return nil, err
+		}
+
+		if !protobufTopics[topic] {
+			schemas[topic] = userskafka.Schema{
+				Type:       userskafka.SchemaTypeAvro,
+				Definition: schema.avro,
+			}
+			continue
+		}
+
+		schemas[topic] = userskafka.Schema{
+			Type:           userskafka.SchemaTypeProtobuf,
+			Definition:     pb.UserProto,
+			MessageIndexes: []int{schema.descriptor.Index()},
+		}
+	}
+
+	const registryTimeout = 5 * time.Second
+
+	return userskafka.NewSchemaRegistryEncoder(userskafka.SchemaRegistryEncoderConfig{
+		URL:     registryURL,
+		Client:  &gohttp.Client{Timeout: registryTimeout},
+		Schemas: schemas,
+	})
+}
+
+func (r *usersRelay) injectEcho(ctx context.Context, e **echo.Echo) (err error) {
+	// External dependencies
+	var db *sql.DB
+	if err = r.Inject(ctx, &db); err != nil {
+		return
+	}
+
+	var producer *kafka.Producer
+	if err = r.Inject(ctx, &producer); err != nil {
+		return
+	}
+
+	var breaker *gobreaker.CircuitBreaker[struct{}]
+	if err = r.Inject(ctx, &breaker); err != nil {
+		return
+	}
+
+	var elector business.LeaderElector
+	if err = r.Inject(ctx, &elector); err != nil {
+		return
+	}
+
+	var logger *slog.Logger
+	if err = r.Inject(ctx, &logger); err != nil {
+		return
+	}
+
+	var registry *prometheus.Registry
+	if err = r.Inject(ctx, &registry); err != nil {
+		return
+	}
+
+	// Business logic
+	var messagesRelay business.MessagesRelay
+	if err = r.Inject(ctx, &messagesRelay); err != nil {
+		return
+	}
+
 	// Primary adapters
-	cmdRelay, err := command.Relay(messagesRelay, logger)
+	relayCollector, err := http.NewRelayCollector(messagesRelay, logger)
 	if err != nil {
 		return
 	}
 
-	*cmd = cmdRelay
+	if err = registry.Register(relayCollector); err != nil {
+		return
+	}
+
+	relayHandler, err := http.NewRelayHandler(http.RelayHandlerConfig{
+		Relay:  messagesRelay,
+		Leader: elector.IsLeader,
+		BreakerState: func() string {
+			return breaker.State().String()
+		},
+	})
+	if err != nil {
+		return
+	}
+
+	catalog, err := http.NewCatalog()
+	if err != nil {
+		return
+	}
+
+	// Setting readiness checks
+	dbCheck := func(ctx context.Context) error {
+		return db.PingContext(ctx)
+	}
+
+	producerCheck := func(context.Context) error {
+		const metadataTimeout = time.Second
+
+		_, err := producer.GetMetadata(nil, false, int(metadataTimeout.Milliseconds()))
+		return err
+	}
+
+	breakerCheck := func(context.Context) error {
+		if breaker.State() == gobreaker.StateOpen {
+			return gobreaker.ErrOpenState
+		}
+
+		return nil
+	}
+
+	// Building echo.Echo
+	n := echo.New()
+
+	// Setting error handler
+	n.HTTPErrorHandler = http.ErrorHandler(n.HTTPErrorHandler, catalog)
+
+	// Setting middlewares
+	n.Use(middleware.Recover())
+
+	// Setting http routes
+	http.SetRelayRoutes(n, relayHandler, dbCheck, producerCheck, breakerCheck)
+	http.SetMetricsRoutes(n, registry)
+
+	// Disabling initial logs
+	n.HideBanner = true
+	n.HidePort = true
+
+	*e = n
 	return
 }
 
@@ -119,6 +383,10 @@ func (r *usersRelay) initProducer(_ context.Context) (err error) {
 	r.Lock()
 	defer r.Unlock()
 
+	if r.producer != nil {
+		return
+	}
+
 	kafkaServers, err := env.Get("KAFKA_SERVERS")
 	if err != nil {
 		return err
@@ -136,12 +404,80 @@ func (r *usersRelay) initProducer(_ context.Context) (err error) {
 	return
 }
 
-func (r *usersRelay) injectCircuitBreaker(ctx context.Context, breaker **gobreaker.CircuitBreaker[struct{}]) (err error) {
+func (r *usersRelay) injectLeaderElector(ctx context.Context, elector *business.LeaderElector) error {
+	if err := r.initLeaderElector(ctx); err != nil {
+		return err
+	}
+
+	*elector = r.elector
+	return nil
+}
+
+func (r *usersRelay) initLeaderElector(ctx context.Context) (err error) {
+	var db *sql.DB
+	if err = r.Inject(ctx, &db); err != nil {
+		return
+	}
+
+	var logger *slog.Logger
+	if err = r.Inject(ctx, &logger); err != nil {
+		return
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	if r.elector != nil {
+		return
+	}
+
+	const (
+		lockName         = "users-relay"
+		campaignInterval = 2 * time.Second
+	)
+
+	r.elector = postgres.NewLeaderElector(postgres.LeaderElectorConfig{
+		LockName: lockName,
+		Interval: campaignInterval,
+		Logger:   logger,
+		DB:       db,
+	})
+
+	return
+}
+
+func (r *usersRelay) injectCircuitBreaker(ctx context.Context, breaker **gobreaker.CircuitBreaker[struct{}]) error {
+	if err := r.initCircuitBreaker(ctx); err != nil {
+		return err
+	}
+
+	*breaker = r.breaker
+	return nil
+}
+
+func (r *usersRelay) initCircuitBreaker(ctx context.Context) (err error) {
 	var logger *slog.Logger
 	if err = r.Inject(ctx, &logger); err != nil {
 		return err
 	}
 
+	var registry *prometheus.Registry
+	if err = r.Inject(ctx, &registry); err != nil {
+		return err
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	if r.breaker != nil {
+		return
+	}
+
+	observeStateChange, err := decorator.NewBreakerStateObserver(registry)
+	if err != nil {
+		return
+	}
+
 	const (
 		maxHalfRequests        = 1
 		maxConsecutiveFailures = 3
@@ -149,7 +485,7 @@ func (r *usersRelay) injectCircuitBreaker(ctx context.Context, breaker **gobreak
 		resetCounterInterval   = 10 * time.Second
 	)
 
-	*breaker = gobreaker.NewCircuitBreaker[struct{}](gobreaker.Settings{
+	r.breaker = gobreaker.NewCircuitBreaker[struct{}](gobreaker.Settings{
 		Name:        "MessageSender",
 		Timeout:     openStateTimeout,
 		Interval:    resetCounterInterval,
@@ -159,6 +495,7 @@ func (r *usersRelay) injectCircuitBreaker(ctx context.Context, breaker **gobreak
 		},
 		OnStateChange: func(name string, from gobreaker.State, to gobreaker.State) {
 			logger.Info("circuit_status_change", "name", name, "old", from, "new", to)
+			observeStateChange(name, from, to)
 		},
 		IsSuccessful: func(err error) bool {
 			return err == nil
@@ -170,6 +507,7 @@ func (r *usersRelay) injectCircuitBreaker(ctx context.Context, breaker **gobreak
 
 func (r *usersRelay) Close(ctx context.Context) (err error) {
 	if r.producer != nil {
+		r.flushProducer(ctx)
 		r.producer.Close()
 		r.logger.InfoContext(ctx, "kafka_producer_closed")
 	}
@@ -183,3 +521,22 @@ func (r *usersRelay) Close(ctx context.Context) (err error) {
 	r.logger.InfoContext(ctx, "container_is_closed")
 	return
 }
+
+// flushProducer waits for the outstanding Kafka messages to be delivered until the ctx deadline
+func (r *usersRelay) flushProducer(ctx context.Context) {
+	const defaultFlushTimeout = 5 * time.Second
+
+	timeout := defaultFlushTimeout
+
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+
+	pending := r.producer.Flush(int(timeout.Milliseconds()))
+	if pending > 0 {
+		r.logger.WarnContext(ctx, "kafka_producer_not_flushed", "pending", pending)
+		return
+	}
+
+	r.logger.InfoContext(ctx, "kafka_producer_flushed")
+}
@@ -0,0 +1,194 @@
+//go:build http || grpc
+
+package container
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/yael-castro/goarch/internal/app/business"
+	"github.com/yael-castro/goarch/internal/app/output/decorator"
+	"github.com/yael-castro/goarch/internal/app/output/events"
+	"github.com/yael-castro/goarch/internal/app/output/postgres"
+	"github.com/yael-castro/goarch/pkg/env"
+	"github.com/yael-castro/goarch/pkg/envelope"
+	"golang.org/x/net/idna"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// injectUserCases builds the business.UserCases shared by the http and grpc servers, eventSource identifies the server in the events
+func (c *container) injectUserCases(ctx context.Context, cases *business.UserCases, eventSource string) (err error) {
+	// Getting environment variables
+	createUserTopic, err := env.Get("CREATE_USER_TOPIC")
+	if err != nil {
+		return err
+	}
+
+	updateUserTopic, err := env.Get("UPDATE_USER_TOPIC")
+	if err != nil {
+		return err
+	}
+
+	deleteUserTopic, err := env.Get("DELETE_USER_TOPIC")
+	if err != nil {
+		return err
+	}
+
+	eraseUserTopic, err := env.Get("ERASE_USER_TOPIC")
+	if err != nil {
+		return err
+	}
+
+	requestEmailVerificationTopic, err := env.Get("REQUEST_EMAIL_VERIFICATION_TOPIC")
+	if err != nil {
+		return err
+	}
+
+	verifyEmailTopic, err := env.Get("VERIFY_EMAIL_TOPIC")
+	if err != nil {
+		return err
+	}
+
+	emailRules, err := newEmailRules()
+	if err != nil {
+		return err
+	}
+
+	birthDateRules, err := newBirthDateRules()
+	if err != nil {
+		return err
+	}
+
+	// External dependencies
+	var db *sql.DB
+	if err = c.Inject(ctx, &db); err != nil {
+		return err
+	}
+
+	var logger *slog.Logger
+	if err = c.Inject(ctx, &logger); err != nil {
+		return err
+	}
+
+	var keyring *envelope.Keyring
+	if err = c.Inject(ctx, &keyring); err != nil {
+		return err
+	}
+
+	// Secondary adapters
+	userStore := postgres.NewUserStore(postgres.UserStoreConfig{
+		Logger:  logger,
+		DB:      db,
+		Keyring: keyring,
+	})
+
+	// Topics encoded as protobuf (optional), the rest of the topics are encoded as JSON
+	encodings := make(map[string]events.Encoding)
+
+	for _, topic := range strings.Split(os.Getenv("PROTOBUF_TOPICS"), ",") {
+		if topic = strings.TrimSpace(topic); len(topic) > 0 {
+			encodings[topic] = events.EncodingProtobuf
+		}
+	}
+
+	eventEncoder := events.NewEventEncoder(events.EventEncoderConfig{
+		CreateUserTopic: createUserTopic,
+		UpdateUserTopic: updateUserTopic,
+		DeleteUserTopic: deleteUserTopic,
+		EraseUserTopic:  eraseUserTopic,
+		Source:          eventSource,
+		Encodings:       encodings,
+		Keyring:         keyring,
+
+		RequestEmailVerificationTopic: requestEmailVerificationTopic,
+		VerifyEmailTopic:              verifyEmailTopic,
+	})
+
+	// Business logic
+	userCases, err := business.NewUserCases(userStore, eventEncoder, business.NewScopePolicy(), emailRules, birthDateRules)
+	if err != nil {
+		return err
+	}
+
+	// Decorators
+	*cases, err = decorator.NewUserCasesTracing(userCases)
+	return
+}
+
+// newEmailRules builds the business.EmailRules from EMAIL_LOCAL_PART_RULES, EMAIL_ALLOWED_DOMAINS and EMAIL_DENIED_DOMAINS
+func newEmailRules() (business.EmailRules, error) {
+	var rules business.EmailRules
+
+	// Format: <rule>,... (e.g. lowercase,strip_subaddress)
+	for _, rule := range splitList(os.Getenv("EMAIL_LOCAL_PART_RULES")) {
+		switch rule {
+		case "lowercase":
+			rules.LowercaseLocalPart = true
+		case "strip_subaddress":
+			rules.StripSubaddress = true
+		default:
+			return business.EmailRules{}, fmt.Errorf("email local part rule '%s' is not supported", rule)
+		}
+	}
+
+	var err error
+
+	rules.AllowedDomains, err = domainList(os.Getenv("EMAIL_ALLOWED_DOMAINS"))
+	if err != nil {
+		return business.EmailRules{}, err
+	}
+
+	rules.DeniedDomains, err = domainList(os.Getenv("EMAIL_DENIED_DOMAINS"))
+	if err != nil {
+		return business.EmailRules{}, err
+	}
+
+	return rules, nil
+}
+
+// newBirthDateRules builds the business.BirthDateRules from USER_MIN_AGE (optional)
+func newBirthDateRules() (business.BirthDateRules, error) {
+	minAge := os.Getenv("USER_MIN_AGE")
+	if len(minAge) == 0 {
+		return business.BirthDateRules{}, nil
+	}
+
+	age, err := strconv.ParseUint(minAge, 10, 8)
+	if err != nil {
+		return business.BirthDateRules{}, fmt.Errorf("invalid user min age '%s': %w", minAge, err)
+	}
+
+	return business.BirthDateRules{MinAge: business.Age(age)}, nil
+}
+
+// domainList parses a comma separated list of domains, the internationalized domains are converted to punycode
+func domainList(list string) ([]string, error) {
+	var domains []string
+
+	for _, domain := range splitList(list) {
+		domain, err := idna.Lookup.ToASCII(domain)
+		if err != nil {
+			return nil, fmt.Errorf("invalid email domain '%s': %w", domain, err)
+		}
+
+		domains = append(domains, domain)
+	}
+
+	return domains, nil
+}
+
+// splitList splits a comma separated list ignoring the empty items
+func splitList(list string) []string {
+	var items []string
+
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); len(item) > 0 {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
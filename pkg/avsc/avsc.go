@@ -0,0 +1,16 @@
+// Package avsc contains the Avro schemas of the data published by the users services
+package avsc
+
+import _ "embed"
+
+// User is the Avro schema of the user events data (see pkg/jsont.User), it is the shared schema of every user event
+// except the email verification requests, as user.v1.json is their shared JSON schema (see cloudevents.DataSchemaUserV1).
+// The events that only identify the user (deleted, erased and email verified) leave the rest of the fields in their defaults
+//
+//go:embed user.avsc
+var User string
+
+// EmailVerification is the Avro schema of the email verification requests data (see pkg/jsont.EmailVerification)
+//
+//go:embed email_verification.avsc
+var EmailVerification string
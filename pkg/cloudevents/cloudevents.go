@@ -0,0 +1,187 @@
+// Package cloudevents encodes and decodes CloudEvents 1.0 in Kafka binary content mode.
+//
+// In binary content mode the event attributes travel as record headers prefixed with "ce_",
+// the content type travels in the "content-type" header and the record value is the event data.
+package cloudevents
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"google.golang.org/protobuf/proto"
+	"time"
+)
+
+// SpecVersion is the supported version of the CloudEvents specification
+const SpecVersion = "1.0"
+
+// Header names used by the Kafka binary content mode
+const (
+	HeaderSpecVersion     = "ce_specversion"
+	HeaderID              = "ce_id"
+	HeaderType            = "ce_type"
+	HeaderSource          = "ce_source"
+	HeaderTime            = "ce_time"
+	HeaderSubject         = "ce_subject"
+	HeaderDataSchema      = "ce_dataschema"
+	HeaderDataContentType = "content-type"
+	// HeaderKeyID and HeaderDataKey are extension attributes with the envelope of the encrypted fields (see pkg/envelope)
+	HeaderKeyID   = "ce_keyid"
+	HeaderDataKey = "ce_datakey"
+)
+
+// Supported values for Event.Type
+const (
+	TypeUserCreated = "com.github.yael-castro.goarch.user.created"
+	TypeUserUpdated = "com.github.yael-castro.goarch.user.updated"
+	TypeUserDeleted = "com.github.yael-castro.goarch.user.deleted"
+	TypeUserErased  = "com.github.yael-castro.goarch.user.erased"
+
+	TypeUserEmailVerificationRequested = "com.github.yael-castro.goarch.user.email_verification_requested"
+	TypeUserEmailVerified              = "com.github.yael-castro.goarch.user.email_verified"
+)
+
+// Supported values for Event.DataSchema
+const (
+	// DataSchemaUserV1 describes the JSON data of the user events (see docs/schemas/user.v1.json)
+	DataSchemaUserV1 = "https://github.com/yael-castro/goarch/blob/main/docs/schemas/user.v1.json"
+	// DataSchemaEmailVerificationV1 describes the JSON data of the email verification requests
+	// (see docs/schemas/email_verification.v1.json)
+	DataSchemaEmailVerificationV1 = "https://github.com/yael-castro/goarch/blob/main/docs/schemas/email_verification.v1.json"
+	// DataSchemaUserEventsProtobuf describes the protobuf data of the user events (see pkg/pb/user.proto)
+	DataSchemaUserEventsProtobuf = "https://github.com/yael-castro/goarch/blob/main/pkg/pb/user.proto"
+)
+
+// Supported values for Event.DataContentType
+const (
+	ContentTypeJSON     = "application/json"
+	ContentTypeProtobuf = "application/protobuf"
+	ContentTypeAvro     = "application/avro"
+)
+
+// Header is a Kafka record header
+type Header struct {
+	Key   string
+	Value []byte
+}
+
+// Event is a CloudEvent whose Data is kept encoded as it travels in the record value
+type Event struct {
+	ID              string
+	Type            string
+	Source          string
+	Subject         string
+	DataSchema      string
+	DataContentType string
+	Time            time.Time
+	// KeyID and DataKey are the envelope of the encrypted fields of the Data (see pkg/envelope),
+	// they are empty if no field is encrypted
+	KeyID   string
+	DataKey []byte
+	Data    []byte
+}
+
+func (e Event) Validate() error {
+	if e.ID == "" || e.Type == "" || e.Source == "" {
+		return errors.New("cloud event id, type and source are required")
+	}
+
+	return nil
+}
+
+// Headers encodes the event attributes as Kafka record headers
+func (e Event) Headers() []Header {
+	headers := []Header{
+		{Key: HeaderSpecVersion, Value: []byte(SpecVersion)},
+		{Key: HeaderID, Value: []byte(e.ID)},
+		{Key: HeaderType, Value: []byte(e.Type)},
+		{Key: HeaderSource, Value: []byte(e.Source)},
+	}
+
+	optional := [...]Header{
+		{Key: HeaderSubject, Value: []byte(e.Subject)},
+		{Key: HeaderDataSchema, Value: []byte(e.DataSchema)},
+		{Key: HeaderDataContentType, Value: []byte(e.DataContentType)},
+		{Key: HeaderKeyID, Value: []byte(e.KeyID)},
+		{Key: HeaderDataKey, Value: []byte(base64.StdEncoding.EncodeToString(e.DataKey))},
+	}
+
+	for _, header := range optional {
+		if len(header.Value) > 0 {
+			headers = append(headers, header)
+		}
+	}
+
+	if !e.Time.IsZero() {
+		headers = append(headers, Header{
+			Key:   HeaderTime,
+			Value: []byte(e.Time.UTC().Format(time.RFC3339Nano)),
+		})
+	}
+
+	return headers
+}
+
+// Decode builds an Event from the headers and the value of a Kafka record
+func Decode(headers []Header, value []byte) (event Event, err error) {
+	var specVersion string
+
+	for _, header := range headers {
+		headerValue := string(header.Value)
+
+		switch header.Key {
+		case HeaderSpecVersion:
+			specVersion = headerValue
+		case HeaderID:
+			event.ID = headerValue
+		case HeaderType:
+			event.Type = headerValue
+		case HeaderSource:
+			event.Source = headerValue
+		case HeaderSubject:
+			event.Subject = headerValue
+		case HeaderDataSchema:
+			event.DataSchema = headerValue
+		case HeaderDataContentType:
+			event.DataContentType = headerValue
+		case HeaderKeyID:
+			event.KeyID = headerValue
+		case HeaderDataKey:
+			event.DataKey, err = base64.StdEncoding.DecodeString(headerValue)
+			if err != nil {
+				return Event{}, fmt.Errorf("invalid cloud event data key: %w", err)
+			}
+		case HeaderTime:
+			event.Time, err = time.Parse(time.RFC3339Nano, headerValue)
+			if err != nil {
+				return Event{}, fmt.Errorf("invalid cloud event time: %w", err)
+			}
+		}
+	}
+
+	if specVersion != SpecVersion {
+		return Event{}, fmt.Errorf("cloud event spec version '%s' is not supported", specVersion)
+	}
+
+	event.Data = value
+	return event, event.Validate()
+}
+
+// DecodeData decodes the event data into v according to the content type.
+// Protobuf data must be decoded into a proto.Message (see pkg/pb)
+func (e Event) DecodeData(v any) error {
+	switch e.DataContentType {
+	case "", ContentTypeJSON:
+		return json.Unmarshal(e.Data, v)
+	case ContentTypeProtobuf:
+		message, ok := v.(proto.Message)
+		if !ok {
+			return fmt.Errorf("type '%T' is not a proto.Message", v)
+		}
+
+		return proto.Unmarshal(e.Data, message)
+	}
+
+	return fmt.Errorf("content type '%s' is not supported", e.DataContentType)
+}
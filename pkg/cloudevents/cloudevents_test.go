@@ -0,0 +1,89 @@
+package cloudevents
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestDecode(t *testing.T) {
+	cases := [...]struct {
+		event       Event
+		headers     []Header
+		expectedErr bool
+	}{
+		// Test case: round trip of an event
+		{
+			event: Event{
+				ID:              "0194f1a4-8b3a-7c3e-9b1f-3a9d1c5e7f20",
+				Type:            TypeUserCreated,
+				Source:          "/goarch/users-http",
+				Subject:         "1",
+				DataSchema:      DataSchemaUserV1,
+				DataContentType: ContentTypeJSON,
+				Time:            time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+				Data:            []byte(`{"id":1}`),
+			},
+		},
+		// Test case: round trip of an event with encrypted fields
+		{
+			event: Event{
+				ID:              "0194f1a4-8b3a-7c3e-9b1f-3a9d1c5e7f21",
+				Type:            TypeUserUpdated,
+				Source:          "/goarch/users-http",
+				Subject:         "1",
+				DataContentType: ContentTypeJSON,
+				KeyID:           "2026-10",
+				DataKey:         []byte{1, 2, 3},
+				Data:            []byte(`{"id":1}`),
+			},
+		},
+		// Test case: missing spec version
+		{
+			headers: []Header{
+				{Key: HeaderID, Value: []byte("1")},
+			},
+			expectedErr: true,
+		},
+		// Test case: missing required attributes
+		{
+			headers: []Header{
+				{Key: HeaderSpecVersion, Value: []byte(SpecVersion)},
+				{Key: HeaderID, Value: []byte("1")},
+			},
+			expectedErr: true,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			headers := c.headers
+			if headers == nil {
+				headers = c.event.Headers()
+			}
+
+			event, err := Decode(headers, c.event.Data)
+			if (err != nil) != c.expectedErr {
+				t.Fatalf("unexpected error '%v'", err)
+			}
+
+			if err != nil {
+				t.Log(err)
+				return
+			}
+
+			if !reflect.DeepEqual(event, c.event) {
+				t.Fatalf("expected '%+v' got '%+v'", c.event, event)
+			}
+
+			var data struct {
+				ID int64 `json:"id"`
+			}
+
+			if err = event.DecodeData(&data); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
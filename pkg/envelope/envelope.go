@@ -0,0 +1,249 @@
+// Package envelope implements envelope encryption: every record is encrypted with its own data key and the data key
+// is stored wrapped (encrypted) by a master key of a Keyring.
+//
+// The master keys are rotated by adding a key to the keyring and making it the primary key, the data keys wrapped by
+// the former keys are still opened until they are re-wrapped with Keyring.Rewrap.
+package envelope
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// KeySize is the size in bytes of the master keys, the data keys and the index key (AES-256)
+const KeySize = 32
+
+var (
+	ErrKeyNotFound = errors.New("envelope: master key not found")
+	ErrDecryption  = errors.New("envelope: unable to decrypt")
+)
+
+// Envelope is a data key wrapped by the master key KeyID, it is stored along the encrypted record
+type Envelope struct {
+	KeyID   string
+	DataKey []byte
+}
+
+// ReadKeyring reads a Keyring from a JSON file (see ParseKeyring)
+func ReadKeyring(name string) (*Keyring, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseKeyring(data)
+}
+
+// ParseKeyring parses a Keyring in the format
+//
+//	{"primary": "<key id>", "keys": {"<key id>": "<base64 key>"}, "index_key": "<base64 key>"}
+//
+// The keys are encoded in standard base64
+func ParseKeyring(data []byte) (*Keyring, error) {
+	var file struct {
+		Primary  string            `json:"primary"`
+		Keys     map[string][]byte `json:"keys"`
+		IndexKey []byte            `json:"index_key"`
+	}
+
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid keyring: %w", err)
+	}
+
+	return NewKeyring(file.Primary, file.Keys, file.IndexKey)
+}
+
+// NewKeyring builds a Keyring whose new data keys are wrapped by the master key primary.
+// The indexKey computes the blind indexes, it is not rotated since the indexes would have to be recomputed
+func NewKeyring(primary string, keys map[string][]byte, indexKey []byte) (*Keyring, error) {
+	if _, ok := keys[primary]; !ok {
+		return nil, fmt.Errorf("%w: primary key '%s' is not in the keyring", ErrKeyNotFound, primary)
+	}
+
+	if len(indexKey) != KeySize {
+		return nil, fmt.Errorf("index key must have %d bytes", KeySize)
+	}
+
+	keyring := &Keyring{
+		primary:  primary,
+		keys:     make(map[string]cipher.AEAD, len(keys)),
+		indexKey: indexKey,
+	}
+
+	for keyID, key := range keys {
+		if len(key) != KeySize {
+			return nil, fmt.Errorf("master key '%s' must have %d bytes", keyID, KeySize)
+		}
+
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, err
+		}
+
+		keyring.keys[keyID] = aead
+	}
+
+	return keyring, nil
+}
+
+// Keyring holds the master keys that wrap the data keys
+type Keyring struct {
+	primary  string
+	keys     map[string]cipher.AEAD
+	indexKey []byte
+}
+
+// Primary returns the ID of the master key that wraps the new data keys
+func (k *Keyring) Primary() string {
+	return k.primary
+}
+
+// NewDataKey generates a data key and returns it along its Envelope wrapped by the primary key
+func (k *Keyring) NewDataKey() (DataKey, Envelope, error) {
+	key := make([]byte, KeySize)
+
+	if _, err := rand.Read(key); err != nil {
+		return DataKey{}, Envelope{}, err
+	}
+
+	envelope, err := k.wrap(key)
+	if err != nil {
+		return DataKey{}, Envelope{}, err
+	}
+
+	dataKey, err := newDataKey(key)
+	return dataKey, envelope, err
+}
+
+// Open unwraps the data key of the Envelope
+func (k *Keyring) Open(envelope Envelope) (DataKey, error) {
+	key, err := k.unwrap(envelope)
+	if err != nil {
+		return DataKey{}, err
+	}
+
+	return newDataKey(key)
+}
+
+// Rewrap wraps the data key of the Envelope with the primary key, the data encrypted with the data key is unchanged
+func (k *Keyring) Rewrap(envelope Envelope) (Envelope, error) {
+	if envelope.KeyID == k.primary {
+		return envelope, nil
+	}
+
+	key, err := k.unwrap(envelope)
+	if err != nil {
+		return Envelope{}, err
+	}
+
+	return k.wrap(key)
+}
+
+// BlindIndex computes a deterministic index of value (HMAC-SHA256) to look up or to check the uniqueness of an
+// encrypted value without decrypting it
+func (k *Keyring) BlindIndex(value string) []byte {
+	mac := hmac.New(sha256.New, k.indexKey)
+	mac.Write([]byte(value))
+	return mac.Sum(nil)
+}
+
+func (k *Keyring) wrap(key []byte) (Envelope, error) {
+	ciphertext, err := seal(k.keys[k.primary], key, []byte(k.primary))
+	if err != nil {
+		return Envelope{}, err
+	}
+
+	return Envelope{KeyID: k.primary, DataKey: ciphertext}, nil
+}
+
+func (k *Keyring) unwrap(envelope Envelope) ([]byte, error) {
+	aead, ok := k.keys[envelope.KeyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: '%s'", ErrKeyNotFound, envelope.KeyID)
+	}
+
+	return open(aead, envelope.DataKey, []byte(envelope.KeyID))
+}
+
+// DataKey encrypts the fields of a record
+type DataKey struct {
+	aead cipher.AEAD
+}
+
+// Encrypt encrypts plaintext, the ciphertext is bound to the field so it cannot be moved to another field
+func (d DataKey) Encrypt(plaintext []byte, field string) ([]byte, error) {
+	return seal(d.aead, plaintext, []byte(field))
+}
+
+func (d DataKey) Decrypt(ciphertext []byte, field string) ([]byte, error) {
+	return open(d.aead, ciphertext, []byte(field))
+}
+
+// EncryptString encrypts plaintext and encodes the ciphertext in standard base64 to send it as a string
+func (d DataKey) EncryptString(plaintext, field string) (string, error) {
+	ciphertext, err := d.Encrypt([]byte(plaintext), field)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+// DecryptString decrypts a ciphertext encoded by EncryptString
+func (d DataKey) DecryptString(ciphertext, field string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrDecryption, err)
+	}
+
+	plaintext, err := d.Decrypt(data, field)
+	return string(plaintext), err
+}
+
+func newDataKey(key []byte) (DataKey, error) {
+	aead, err := newAEAD(key)
+	return DataKey{aead: aead}, err
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts plaintext with AES-GCM, the ciphertext is prefixed by its random nonce
+func seal(aead cipher.AEAD, plaintext, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func open(aead cipher.AEAD, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, ErrDecryption
+	}
+
+	nonce, ciphertext := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+
+	plaintext, err := aead.Open(nil, nonce, ciphertext, additionalData)
+	if err != nil {
+		return nil, ErrDecryption
+	}
+
+	return plaintext, nil
+}
@@ -0,0 +1,165 @@
+package envelope
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"testing"
+)
+
+func TestKeyring_Rewrap(t *testing.T) {
+	former, current := bytes.Repeat([]byte{1}, KeySize), bytes.Repeat([]byte{2}, KeySize)
+	indexKey := bytes.Repeat([]byte{3}, KeySize)
+
+	oldKeyring, err := NewKeyring("former", map[string][]byte{"former": former}, indexKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The former key is kept in the keyring until every data key is re-wrapped
+	keyring, err := NewKeyring("current", map[string][]byte{"former": former, "current": current}, indexKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dataKey, envelope, err := oldKeyring.NewDataKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ciphertext, err := dataKey.EncryptString("contacto@yael.mx", "email")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rewrapped, err := keyring.Rewrap(envelope)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rewrapped.KeyID != "current" {
+		t.Fatalf("expected key 'current' got '%s'", rewrapped.KeyID)
+	}
+
+	cases := [...]struct {
+		keyring           *Keyring
+		envelope          Envelope
+		field             string
+		expectedPlaintext string
+		expectedErr       error
+	}{
+		// Test case: data key wrapped by the former key
+		{
+			keyring:           keyring,
+			envelope:          envelope,
+			field:             "email",
+			expectedPlaintext: "contacto@yael.mx",
+		},
+		// Test case: re-wrapped data key
+		{
+			keyring:           keyring,
+			envelope:          rewrapped,
+			field:             "email",
+			expectedPlaintext: "contacto@yael.mx",
+		},
+		// Test case: the former keyring does not have the current key
+		{
+			keyring:     oldKeyring,
+			envelope:    rewrapped,
+			field:       "email",
+			expectedErr: ErrKeyNotFound,
+		},
+		// Test case: the ciphertext is bound to its field
+		{
+			keyring:     keyring,
+			envelope:    rewrapped,
+			field:       "name",
+			expectedErr: ErrDecryption,
+		},
+		// Test case: the data key is bound to its master key
+		{
+			keyring:     keyring,
+			envelope:    Envelope{KeyID: "current", DataKey: envelope.DataKey},
+			field:       "email",
+			expectedErr: ErrDecryption,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			dataKey, err := c.keyring.Open(c.envelope)
+			if err == nil {
+				var plaintext string
+
+				plaintext, err = dataKey.DecryptString(ciphertext, c.field)
+				if err == nil && plaintext != c.expectedPlaintext {
+					t.Fatalf("expected plaintext '%s' got '%s'", c.expectedPlaintext, plaintext)
+				}
+			}
+
+			if !errors.Is(err, c.expectedErr) {
+				t.Fatalf("expected error '%v' got '%v'", c.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestParseKeyring(t *testing.T) {
+	cases := [...]struct {
+		data        string
+		expectedErr bool
+	}{
+		// Test case: valid keyring
+		{
+			data: `{
+				"primary": "2026-10",
+				"keys": {"2026-10": "AQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQE="},
+				"index_key": "AgICAgICAgICAgICAgICAgICAgICAgICAgICAgICAgI="
+			}`,
+		},
+		// Test case: the primary key is not in the keyring
+		{
+			data: `{
+				"primary": "2026-11",
+				"keys": {"2026-10": "AQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQE="},
+				"index_key": "AgICAgICAgICAgICAgICAgICAgICAgICAgICAgICAgI="
+			}`,
+			expectedErr: true,
+		},
+		// Test case: short key
+		{
+			data: `{
+				"primary": "2026-10",
+				"keys": {"2026-10": "AQEBAQ=="},
+				"index_key": "AgICAgICAgICAgICAgICAgICAgICAgICAgICAgICAgI="
+			}`,
+			expectedErr: true,
+		},
+		// Test case: missing index key
+		{
+			data: `{
+				"primary": "2026-10",
+				"keys": {"2026-10": "AQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQE="}
+			}`,
+			expectedErr: true,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			keyring, err := ParseKeyring([]byte(c.data))
+			if (err != nil) != c.expectedErr {
+				t.Fatalf("expected error %v got '%v'", c.expectedErr, err)
+			}
+
+			if err != nil {
+				return
+			}
+
+			// The blind index is deterministic
+			if !bytes.Equal(keyring.BlindIndex("contacto@yael.mx"), keyring.BlindIndex("contacto@yael.mx")) {
+				t.Fatal("expected the same blind index")
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+// Package i18n selects localized messages by language from catalogs of JSON files
+package i18n
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"golang.org/x/text/language"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// Message is a localized message, the placeholders ({name}) are replaced by the params of Catalog.Localize
+type Message struct {
+	Title  string `json:"title"`
+	Detail string `json:"detail"`
+}
+
+// Load reads the catalogs that match the pattern in fsys, the name of each file is the language tag (e.g. es.json)
+//
+// The fallback language is used when there is no catalog or message for the requested languages.
+func Load(fsys fs.FS, pattern string, fallback language.Tag) (*Catalog, error) {
+	files, err := fs.Glob(fsys, pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	catalog := &Catalog{
+		messages: make(map[language.Tag]map[string]Message, len(files)),
+	}
+
+	for _, file := range files {
+		tag, err := language.Parse(strings.TrimSuffix(path.Base(file), path.Ext(file)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid catalog name '%s': %w", file, err)
+		}
+
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+
+		messages := make(map[string]Message)
+
+		if err = json.Unmarshal(data, &messages); err != nil {
+			return nil, fmt.Errorf("invalid catalog '%s': %w", file, err)
+		}
+
+		catalog.messages[tag] = messages
+		catalog.tags = append(catalog.tags, tag)
+	}
+
+	if _, ok := catalog.messages[fallback]; !ok {
+		return nil, errors.New("there is no catalog for the fallback language " + fallback.String())
+	}
+
+	// The first tag is the default of the matcher
+	for i, tag := range catalog.tags {
+		if tag == fallback {
+			catalog.tags[0], catalog.tags[i] = catalog.tags[i], catalog.tags[0]
+		}
+	}
+
+	catalog.fallback = fallback
+	catalog.matcher = language.NewMatcher(catalog.tags)
+	return catalog, nil
+}
+
+// Catalog contains the messages of each supported language
+type Catalog struct {
+	tags     []language.Tag
+	fallback language.Tag
+	matcher  language.Matcher
+	messages map[language.Tag]map[string]Message
+}
+
+// Match selects the supported language that best matches the Accept-Language header value
+func (c *Catalog) Match(acceptLanguage string) language.Tag {
+	tags, _, _ := language.ParseAcceptLanguage(acceptLanguage)
+
+	_, index, _ := c.matcher.Match(tags...)
+	return c.tags[index]
+}
+
+// Localize returns the message of key in the language tag (or in the fallback language), replacing the placeholders by params
+func (c *Catalog) Localize(tag language.Tag, key string, params map[string]string) (Message, bool) {
+	message, ok := c.messages[tag][key]
+	if !ok {
+		message, ok = c.messages[c.fallback][key]
+	}
+
+	if !ok {
+		return Message{}, false
+	}
+
+	if len(params) > 0 {
+		oldnew := make([]string, 0, len(params)*2)
+
+		for name, value := range params {
+			oldnew = append(oldnew, "{"+name+"}", value)
+		}
+
+		replacer := strings.NewReplacer(oldnew...)
+
+		message.Title = replacer.Replace(message.Title)
+		message.Detail = replacer.Replace(message.Detail)
+	}
+
+	return message, true
+}
@@ -0,0 +1,76 @@
+package i18n
+
+import (
+	"golang.org/x/text/language"
+	"strconv"
+	"testing"
+	"testing/fstest"
+)
+
+func TestCatalog_Localize(t *testing.T) {
+	fsys := fstest.MapFS{
+		"locales/en.json": {Data: []byte(`{"USER_NOT_FOUND":{"title":"User not found","detail":"The user {user_id} does not exist"},"USER_NAME_INVALID":{"title":"Invalid user name","detail":"Invalid name"}}`)},
+		"locales/es.json": {Data: []byte(`{"USER_NOT_FOUND":{"title":"Usuario no encontrado","detail":"El usuario {user_id} no existe"}}`)},
+	}
+
+	catalog, err := Load(fsys, "locales/*.json", language.English)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := [...]struct {
+		acceptLanguage  string
+		key             string
+		expectedTag     language.Tag
+		expectedMessage Message
+		expectedOk      bool
+	}{
+		// Test case: Spanish
+		{
+			acceptLanguage:  "es-MX,es;q=0.9,en;q=0.8",
+			key:             "USER_NOT_FOUND",
+			expectedTag:     language.Spanish,
+			expectedMessage: Message{Title: "Usuario no encontrado", Detail: "El usuario 1 no existe"},
+			expectedOk:      true,
+		},
+		// Test case: unsupported language falls back to English
+		{
+			acceptLanguage:  "fr-FR",
+			key:             "USER_NOT_FOUND",
+			expectedTag:     language.English,
+			expectedMessage: Message{Title: "User not found", Detail: "The user 1 does not exist"},
+			expectedOk:      true,
+		},
+		// Test case: message missing in Spanish falls back to English
+		{
+			acceptLanguage:  "es",
+			key:             "USER_NAME_INVALID",
+			expectedTag:     language.Spanish,
+			expectedMessage: Message{Title: "Invalid user name", Detail: "Invalid name"},
+			expectedOk:      true,
+		},
+		// Test case: unknown key
+		{
+			key:         "UNKNOWN",
+			expectedTag: language.English,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			tag := catalog.Match(c.acceptLanguage)
+			if tag != c.expectedTag {
+				t.Fatalf("expected tag '%v' got '%v'", c.expectedTag, tag)
+			}
+
+			message, ok := catalog.Localize(tag, c.key, map[string]string{"user_id": "1"})
+			if ok != c.expectedOk {
+				t.Fatalf("expected ok '%v' got '%v'", c.expectedOk, ok)
+			}
+
+			if message != c.expectedMessage {
+				t.Fatalf("expected '%+v' got '%+v'", c.expectedMessage, message)
+			}
+		})
+	}
+}
@@ -1,8 +1,24 @@
 package jsont
 
+import "time"
+
 type User struct {
 	ID    int64  `json:"id,omitempty"`
 	Name  string `json:"name,omitempty"`
 	Email string `json:"email,omitempty"`
-	Age   uint8  `json:"age,omitempty"`
+	// Age is computed from BirthDate, it is kept for the consumers that do not read BirthDate yet. In the requests it
+	// is deprecated, it approximates BirthDate only if it is missing
+	Age uint8 `json:"age,omitempty"`
+	// BirthDate is the date of birth in ISO 8601 format (YYYY-MM-DD), it is absent in the data encoded before it existed
+	BirthDate string `json:"birth_date,omitempty"`
+	// EmailVerifiedAt is read-only, it is absent until the email is verified
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+}
+
+// EmailVerification is the data of the email verification requests
+type EmailVerification struct {
+	UserID    int64      `json:"user_id,omitempty"`
+	Email     string     `json:"email,omitempty"`
+	Token     string     `json:"token,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
 }
@@ -0,0 +1,293 @@
+package jwtauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// minJWKSRefreshInterval limits the refreshes triggered by tokens signed with unknown keys and the retries of the
+// refreshes that failed
+const minJWKSRefreshInterval = 30 * time.Second
+
+type JWKSConfig struct {
+	// URL of the JWKS, File is ignored if it is set
+	URL    string
+	Client *http.Client
+	// File that contains the JWKS
+	File string
+	// RefreshInterval indicates how long the keys are cached before they are loaded again
+	RefreshInterval time.Duration
+	Logger          *slog.Logger
+}
+
+// NewJWKS loads the JWKS (RFC 7517) described by config, only the RSA and EC (P-256) signature keys are kept
+func NewJWKS(ctx context.Context, config JWKSConfig) (*JWKS, error) {
+	if config.Logger == nil {
+		return nil, errors.New("some dependencies are nil")
+	}
+
+	if config.RefreshInterval <= 0 {
+		return nil, errors.New("jwks refresh interval must be positive")
+	}
+
+	var load func(context.Context) ([]byte, error)
+
+	switch {
+	case len(config.URL) > 0:
+		if config.Client == nil {
+			return nil, errors.New("jwks client is missing")
+		}
+
+		load = func(ctx context.Context) ([]byte, error) {
+			return fetchJWKS(ctx, config.Client, config.URL)
+		}
+	case len(config.File) > 0:
+		load = func(context.Context) ([]byte, error) {
+			return os.ReadFile(config.File)
+		}
+	default:
+		return nil, errors.New("jwks url or file is missing")
+	}
+
+	jwks := &JWKS{
+		// The background refreshes outlive the context of the initialization
+		ctx:             context.WithoutCancel(ctx),
+		load:            load,
+		logger:          config.Logger,
+		refreshInterval: config.RefreshInterval,
+	}
+
+	return jwks, jwks.refresh(ctx)
+}
+
+// JWKS caches the public keys by key ID to verify the signature of the JWTs
+type JWKS struct {
+	mutex    sync.RWMutex
+	keys     map[string]crypto.PublicKey
+	loadedAt time.Time
+	// attemptedAt is the time of the last refresh even if it failed (negative cache)
+	attemptedAt     time.Time
+	refreshing      atomic.Bool
+	ctx             context.Context
+	load            func(context.Context) ([]byte, error)
+	logger          *slog.Logger
+	refreshInterval time.Duration
+}
+
+// Keyfunc returns the key identified by the "kid" header of token (jwt.Keyfunc). The keys are refreshed in the background
+// once they expire or when the key is unknown, at most once every minJWKSRefreshInterval, so the requests never wait
+// for the JWKS and the cached keys are used while it is unavailable
+func (j *JWKS) Keyfunc(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+	if len(kid) == 0 {
+		return nil, errors.New("token has no key id")
+	}
+
+	key, ok, loadedAt, attemptedAt := j.key(kid)
+
+	expired := time.Since(loadedAt) > j.refreshInterval
+
+	if (expired || !ok) && time.Since(attemptedAt) > minJWKSRefreshInterval {
+		j.refreshInBackground()
+	}
+
+	if !ok {
+		return nil, fmt.Errorf("key '%s' is unknown", kid)
+	}
+
+	return key, nil
+}
+
+func (j *JWKS) key(kid string) (crypto.PublicKey, bool, time.Time, time.Time) {
+	j.mutex.RLock()
+	defer j.mutex.RUnlock()
+
+	key, ok := j.keys[kid]
+	return key, ok, j.loadedAt, j.attemptedAt
+}
+
+// refreshInBackground starts a refresh unless one is running
+func (j *JWKS) refreshInBackground() {
+	if !j.refreshing.CompareAndSwap(false, true) {
+		return
+	}
+
+	go func() {
+		defer j.refreshing.Store(false)
+
+		if err := j.refresh(j.ctx); err != nil {
+			j.logger.WarnContext(j.ctx, "jwks_refresh", "error", err)
+		}
+	}()
+}
+
+func (j *JWKS) refresh(ctx context.Context) error {
+	j.mutex.Lock()
+	j.attemptedAt = time.Now()
+	j.mutex.Unlock()
+
+	// The keys are loaded without holding the lock, so the requests keep reading the cached keys
+	data, err := j.load(ctx)
+	if err != nil {
+		return err
+	}
+
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return err
+	}
+
+	j.mutex.Lock()
+	j.keys = keys
+	j.loadedAt = time.Now()
+	j.mutex.Unlock()
+
+	j.logger.InfoContext(ctx, "jwks_loaded", "keys", len(keys))
+	return nil
+}
+
+func fetchJWKS(ctx context.Context, client *http.Client, url string) ([]byte, error) {
+	const maxWaitTime = 5 * time.Second
+
+	ctx, cancel := context.WithTimeout(ctx, maxWaitTime)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code '%d' fetching the jwks", res.StatusCode)
+	}
+
+	return io.ReadAll(res.Body)
+}
+
+// jwk is a JSON Web Key, only the members of the RSA and EC public keys are decoded
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func parseJWKS(data []byte) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("invalid jwks: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+
+	for _, key := range set.Keys {
+		if len(key.Kid) == 0 || (len(key.Use) > 0 && key.Use != "sig") {
+			continue
+		}
+
+		publicKey, err := key.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("invalid key '%s': %w", key.Kid, err)
+		}
+
+		if publicKey != nil {
+			keys[key.Kid] = publicKey
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("jwks has no signature keys")
+	}
+
+	return keys, nil
+}
+
+// publicKey returns the public key or nil if the key type is not supported
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+
+		if !e.IsInt64() || e.Int64() < 3 {
+			return nil, errors.New("invalid rsa exponent")
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, nil
+		}
+
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		curve := elliptic.P256()
+
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("point is not on the curve")
+		}
+
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+
+	return nil, nil
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(b) == 0 {
+		return nil, errors.New("empty key parameter")
+	}
+
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,135 @@
+package jwtauth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/golang-jwt/jwt/v5"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNewJWKS_URL(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"keys": []map[string]string{
+				{
+					"kty": "EC",
+					"kid": "ec",
+					"crv": "P-256",
+					"x":   base64.RawURLEncoding.EncodeToString(key.X.Bytes()),
+					"y":   base64.RawURLEncoding.EncodeToString(key.Y.Bytes()),
+				},
+			},
+		})
+	}))
+	defer server.Close()
+
+	jwks, err := NewJWKS(context.Background(), JWKSConfig{
+		URL:             server.URL,
+		Client:          server.Client(),
+		RefreshInterval: time.Hour,
+		Logger:          slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	publicKey, err := jwks.Keyfunc(&jwt.Token{Header: map[string]any{"kid": "ec"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !key.PublicKey.Equal(publicKey) {
+		t.Error("unexpected public key")
+	}
+}
+
+func TestJWKS_Keyfunc(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var fetches atomic.Int32
+
+	// The JWKS is available only for the first fetch
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fetches.Add(1) > 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"keys": []map[string]string{
+				{
+					"kty": "EC",
+					"kid": "ec",
+					"crv": "P-256",
+					"x":   base64.RawURLEncoding.EncodeToString(key.X.Bytes()),
+					"y":   base64.RawURLEncoding.EncodeToString(key.Y.Bytes()),
+				},
+			},
+		})
+	}))
+	defer server.Close()
+
+	jwks, err := NewJWKS(context.Background(), JWKSConfig{
+		URL:             server.URL,
+		Client:          server.Client(),
+		RefreshInterval: time.Hour,
+		Logger:          slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Unknown keys do not fetch the JWKS again right after it was loaded
+	for range 10 {
+		if _, err = jwks.Keyfunc(&jwt.Token{Header: map[string]any{"kid": "unknown"}}); err == nil {
+			t.Fatal("expected an unknown key error")
+		}
+	}
+
+	if n := fetches.Load(); n != 1 {
+		t.Fatalf("expected 1 fetch got %d", n)
+	}
+
+	// Expiring the keys, the requests trigger a single background refresh and keep using the cached keys
+	jwks.mutex.Lock()
+	jwks.loadedAt, jwks.attemptedAt = time.Time{}, time.Time{}
+	jwks.mutex.Unlock()
+
+	for range 10 {
+		publicKey, err := jwks.Keyfunc(&jwt.Token{Header: map[string]any{"kid": "ec"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !key.PublicKey.Equal(publicKey) {
+			t.Fatal("unexpected public key")
+		}
+	}
+
+	// Waiting for the background refresh
+	for deadline := time.Now().Add(time.Second); jwks.refreshing.Load() && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if n := fetches.Load(); n != 2 {
+		t.Fatalf("expected 2 fetches got %d", n)
+	}
+}
@@ -0,0 +1,89 @@
+// Package jwtauth verifies the JWTs (RS256 or ES256) of the callers against the keys of a JWKS, it is shared by the
+// HTTP and the gRPC authentication so both transports accept the same tokens
+package jwtauth
+
+import (
+	"errors"
+	"github.com/golang-jwt/jwt/v5"
+	"strings"
+)
+
+type VerifierConfig struct {
+	// Keyfunc returns the key to verify the signature of a token (e.g. JWKS.Keyfunc)
+	Keyfunc  jwt.Keyfunc
+	Issuer   string
+	Audience string
+}
+
+// NewVerifier builds a Verifier of the tokens issued by the Issuer for the Audience
+func NewVerifier(config VerifierConfig) (*Verifier, error) {
+	if config.Keyfunc == nil {
+		return nil, errors.New("some dependencies are nil")
+	}
+
+	if len(config.Issuer) == 0 || len(config.Audience) == 0 {
+		return nil, errors.New("jwt issuer or audience is missing")
+	}
+
+	parser := jwt.NewParser(
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodES256.Alg()}),
+		jwt.WithIssuer(config.Issuer),
+		jwt.WithAudience(config.Audience),
+		jwt.WithExpirationRequired(),
+	)
+
+	return &Verifier{
+		parser:  parser,
+		keyfunc: config.Keyfunc,
+	}, nil
+}
+
+// Verifier checks the signature, the issuer, the audience and the expiry of the tokens
+type Verifier struct {
+	parser  *jwt.Parser
+	keyfunc jwt.Keyfunc
+}
+
+// Verify returns the claims of tokenString if it is valid
+func (v *Verifier) Verify(tokenString string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+
+	if _, err := v.parser.ParseWithClaims(tokenString, claims, v.keyfunc); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+// Scopes returns the scopes granted by the "scope" claim (space separated) or the "scp" claim (string or array)
+func Scopes(claims jwt.MapClaims) []string {
+	scopes := make([]string, 0)
+
+	switch scp := claims["scp"].(type) {
+	case string:
+		scopes = append(scopes, strings.Fields(scp)...)
+	case []any:
+		for _, value := range scp {
+			if s, ok := value.(string); ok {
+				scopes = append(scopes, s)
+			}
+		}
+	}
+
+	if scope, ok := claims["scope"].(string); ok {
+		scopes = append(scopes, strings.Fields(scope)...)
+	}
+
+	return scopes
+}
+
+// BearerToken returns the token of an Authorization header (or metadata) value with the "Bearer" scheme
+func BearerToken(authorization string) (string, bool) {
+	const prefix = "Bearer "
+
+	if len(authorization) <= len(prefix) || !strings.EqualFold(authorization[:len(prefix)], prefix) {
+		return "", false
+	}
+
+	return authorization[len(prefix):], true
+}
@@ -0,0 +1,39 @@
+package jwtauth
+
+import (
+	"github.com/golang-jwt/jwt/v5"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestScopes(t *testing.T) {
+	cases := [...]struct {
+		claims         jwt.MapClaims
+		expectedScopes []string
+	}{
+		// Test case: no scopes
+		{
+			claims:         jwt.MapClaims{},
+			expectedScopes: []string{},
+		},
+		// Test case: space separated scope claim
+		{
+			claims:         jwt.MapClaims{"scope": "users:read users:write"},
+			expectedScopes: []string{"users:read", "users:write"},
+		},
+		// Test case: array scp claim
+		{
+			claims:         jwt.MapClaims{"scp": []any{"users:read", "users:admin"}},
+			expectedScopes: []string{"users:read", "users:admin"},
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			if s := Scopes(c.claims); !reflect.DeepEqual(s, c.expectedScopes) {
+				t.Fatalf("expected scopes '%v' got '%v'", c.expectedScopes, s)
+			}
+		})
+	}
+}
@@ -0,0 +1,8 @@
+package pb
+
+import _ "embed"
+
+// UserProto is the definition of user.proto, it is required to register the schema of the user events
+//
+//go:embed user.proto
+var UserProto string
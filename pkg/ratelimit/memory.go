@@ -0,0 +1,76 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval is the time between the removals of the idle buckets
+const sweepInterval = time.Minute
+
+// NewMemoryStore builds a Store that keeps the buckets in memory, so the limits are enforced per process
+func NewMemoryStore() Store {
+	return &memoryStore{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+type bucket struct {
+	limit     Limit
+	tokens    float64
+	updatedAt time.Time
+}
+
+type memoryStore struct {
+	mutex     sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func (m *memoryStore) Take(_ context.Context, key string, limit Limit) (Result, error) {
+	if err := limit.Validate(); err != nil {
+		return Result{}, err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	now := m.now()
+	m.sweep(now)
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Requests)}
+		m.buckets[key] = b
+	} else {
+		b.tokens = refill(limit, b.tokens, now.Sub(b.updatedAt))
+	}
+
+	b.limit = limit
+	b.updatedAt = now
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+
+	return NewResult(limit, b.tokens, allowed), nil
+}
+
+// sweep removes the buckets that are full, they are equivalent to the buckets that do not exist
+func (m *memoryStore) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < sweepInterval {
+		return
+	}
+
+	for key, b := range m.buckets {
+		if refill(b.limit, b.tokens, now.Sub(b.updatedAt)) >= float64(b.limit.Requests) {
+			delete(m.buckets, key)
+		}
+	}
+
+	m.lastSweep = now
+}
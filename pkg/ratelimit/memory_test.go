@@ -0,0 +1,142 @@
+package ratelimit
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestMemoryStore_Take(t *testing.T) {
+	now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	store := NewMemoryStore().(*memoryStore)
+	store.now = func() time.Time {
+		return now
+	}
+
+	limit := Limit{Requests: 2, Period: 10 * time.Second}
+
+	cases := [...]struct {
+		key             string
+		elapsed         time.Duration
+		expectedAllowed bool
+		expectedRemain  int
+		expectedRetry   time.Duration
+	}{
+		// Test case: the bucket is created full
+		{
+			key:             "a",
+			expectedAllowed: true,
+			expectedRemain:  1,
+		},
+		// Test case: last token
+		{
+			key:             "a",
+			expectedAllowed: true,
+			expectedRemain:  0,
+		},
+		// Test case: empty bucket
+		{
+			key:            "a",
+			expectedRetry:  5 * time.Second,
+			expectedRemain: 0,
+		},
+		// Test case: the buckets are independent
+		{
+			key:             "b",
+			expectedAllowed: true,
+			expectedRemain:  1,
+		},
+		// Test case: a token is refilled every 5 seconds
+		{
+			key:             "a",
+			elapsed:         5 * time.Second,
+			expectedAllowed: true,
+			expectedRemain:  0,
+		},
+		// Test case: the capacity is never exceeded
+		{
+			key:             "a",
+			elapsed:         time.Hour,
+			expectedAllowed: true,
+			expectedRemain:  1,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			now = now.Add(c.elapsed)
+
+			result, err := store.Take(context.Background(), c.key, limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if result.Allowed != c.expectedAllowed {
+				t.Fatalf("expected allowed %v got %v", c.expectedAllowed, result.Allowed)
+			}
+
+			if result.Remaining != c.expectedRemain {
+				t.Errorf("expected remaining %d got %d", c.expectedRemain, result.Remaining)
+			}
+
+			if result.RetryAfter != c.expectedRetry {
+				t.Errorf("expected retry after %v got %v", c.expectedRetry, result.RetryAfter)
+			}
+		})
+	}
+
+	// The full buckets are removed
+	now = now.Add(time.Hour)
+
+	if _, err := store.Take(context.Background(), "c", limit); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := store.buckets["a"]; ok || len(store.buckets) != 1 {
+		t.Errorf("expected only the bucket 'c' got %d buckets", len(store.buckets))
+	}
+}
+
+func TestParseLimit(t *testing.T) {
+	cases := [...]struct {
+		s             string
+		expectedLimit Limit
+		expectedErr   bool
+	}{
+		// Test case: valid limit
+		{
+			s:             "100/1m",
+			expectedLimit: Limit{Requests: 100, Period: time.Minute},
+		},
+		// Test case: missing period
+		{
+			s:           "100",
+			expectedErr: true,
+		},
+		// Test case: zero requests
+		{
+			s:           "0/1s",
+			expectedErr: true,
+		},
+		// Test case: invalid period
+		{
+			s:           "10/minute",
+			expectedErr: true,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			limit, err := ParseLimit(c.s)
+			if (err != nil) != c.expectedErr {
+				t.Fatalf("unexpected error '%v'", err)
+			}
+
+			if limit != c.expectedLimit && !c.expectedErr {
+				t.Fatalf("expected limit '%v' got '%v'", c.expectedLimit, limit)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+// Package ratelimit implements token buckets whose state is kept in a swappable Store
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit allows Requests per Period, it is the capacity of a token bucket refilled at Requests/Period
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// ParseLimit parses a Limit in the format <requests>/<period> (e.g. 100/1m)
+func ParseLimit(s string) (Limit, error) {
+	requests, period, ok := strings.Cut(strings.TrimSpace(s), "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid limit '%s': expected <requests>/<period>", s)
+	}
+
+	n, err := strconv.Atoi(requests)
+	if err != nil {
+		return Limit{}, fmt.Errorf("invalid limit '%s': %w", s, err)
+	}
+
+	d, err := time.ParseDuration(period)
+	if err != nil {
+		return Limit{}, fmt.Errorf("invalid limit '%s': %w", s, err)
+	}
+
+	limit := Limit{Requests: n, Period: d}
+	return limit, limit.Validate()
+}
+
+func (l Limit) Validate() error {
+	if l.Requests < 1 || l.Period <= 0 {
+		return errors.New("limit requests and period must be positive")
+	}
+
+	return nil
+}
+
+// Rate is the number of tokens refilled per second
+func (l Limit) Rate() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+func (l Limit) String() string {
+	return strconv.Itoa(l.Requests) + "/" + l.Period.String()
+}
+
+// Result is the state of a bucket after taking a token
+type Result struct {
+	Limit     Limit
+	Allowed   bool
+	Remaining int
+	// Reset is the time until the bucket is full again
+	Reset time.Duration
+	// RetryAfter is the time until the next token is available (only if the request is not allowed)
+	RetryAfter time.Duration
+}
+
+// NewResult describes a bucket that holds tokens after the token of the request was taken (if allowed)
+func NewResult(limit Limit, tokens float64, allowed bool) Result {
+	rate := limit.Rate()
+
+	result := Result{
+		Limit:     limit,
+		Allowed:   allowed,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     seconds((float64(limit.Requests) - tokens) / rate),
+	}
+
+	if !allowed {
+		result.RetryAfter = seconds((1 - tokens) / rate)
+	}
+
+	return result
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Max(s, 0) * float64(time.Second))
+}
+
+// Store keeps the buckets identified by key, an implementation shared by the replicas keeps the limits across them
+type Store interface {
+	// Take takes a token from the bucket of key, the bucket is created full if it does not exist
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// refill returns the tokens of a bucket that held tokens elapsed time ago, the capacity is never exceeded
+func refill(limit Limit, tokens float64, elapsed time.Duration) float64 {
+	return math.Min(float64(limit.Requests), tokens+elapsed.Seconds()*limit.Rate())
+}
@@ -56,5 +56,23 @@ if [ "$subcommand" = "http" ]; then
   CGO_ENABLED=0 build
 fi
 
+if [ "$subcommand" = "grpc" ]; then
+  ldflags="-X $runtime.GitCommit=$commit"
+  binary="users-grpc"
+  tags="grpc"
+
+  printf "\nBuilding API gRPC in \"build\" directory\n"
+  CGO_ENABLED=0 build
+fi
+
+if [ "$subcommand" = "keys" ]; then
+  ldflags="-X $runtime.GitCommit=$commit"
+  binary="users-keys"
+  tags="keys"
+
+  printf "\nBuilding key rotation CLI in \"build\" directory\n"
+  CGO_ENABLED=0 build
+fi
+
 echo "Invalid subcommand: $subcommand"
 exit 1
\ No newline at end of file
@@ -1,4 +1,7 @@
 #!/bin/bash
 
 pwd
-protoc --proto_path=./pkg/pb --go_out=. purchase.proto header.proto
\ No newline at end of file
+protoc --proto_path=./pkg/pb \
+  --go_out=. --go_opt=module=github.com/yael-castro/goarch \
+  --go-grpc_out=. --go-grpc_opt=module=github.com/yael-castro/goarch \
+  user.proto header.proto user_service.proto
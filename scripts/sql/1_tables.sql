@@ -1,14 +1,29 @@
 DROP TABLE IF EXISTS users;
 CREATE TABLE users (
     id SERIAL PRIMARY KEY,
-    name VARCHAR(100) NOT NULL,
-    age SMALLINT NOT NULL,
-    email VARCHAR(100) UNIQUE NOT NULL,
+    -- The name and the email are encrypted with the data key of the record (see pkg/envelope)
+    name BYTEA NOT NULL,
+    email BYTEA NOT NULL,
+    -- Blind index (HMAC) of the lowercase email that checks its uniqueness regardless of its case without decrypting it
+    email_index BYTEA UNIQUE NOT NULL,
+    -- Data key wrapped by the master key key_id
+    key_id VARCHAR NOT NULL,
+    data_key BYTEA NOT NULL,
+    -- The age is computed from the birth date when the user is read, the birth date is NULL only if the user is erased
+    birth_date DATE DEFAULT NULL,
+    -- The email is verified with a single-use token, only the SHA-256 of the pending token is stored
+    email_verified_at TIMESTAMP DEFAULT NULL,
+    email_verification_hash BYTEA DEFAULT NULL,
+    email_verification_expires_at TIMESTAMP DEFAULT NULL,
     -- Common fields
     created_at TIMESTAMP DEFAULT now(),
     updated_at TIMESTAMP DEFAULT now(),
-    deleted_at TIMESTAMP DEFAULT NULL
+    deleted_at TIMESTAMP DEFAULT NULL,
+    -- The personal data of the erased users is removed (data subject erasure requests)
+    erased_at TIMESTAMP DEFAULT NULL
 );
+-- The users are re-wrapped by users-keys when the primary key is rotated
+CREATE INDEX users_key_id_idx ON users (key_id);
 
 DROP TABLE IF EXISTS outbox_messages;
 CREATE TABLE outbox_messages (
@@ -23,4 +38,38 @@ CREATE TABLE outbox_messages (
     created_at TIMESTAMP DEFAULT now(),
     updated_at TIMESTAMP DEFAULT now(),
     deleted_at TIMESTAMP DEFAULT NULL
-);
\ No newline at end of file
+);
+
+DROP TABLE IF EXISTS api_keys;
+CREATE TABLE api_keys (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    -- SHA-256 of the key, the key itself is never stored
+    key_hash BYTEA UNIQUE NOT NULL,
+    key_prefix VARCHAR(16) NOT NULL,
+    scopes VARCHAR[] NOT NULL,
+    last_used_at TIMESTAMP DEFAULT NULL,
+    revoked_at TIMESTAMP DEFAULT NULL,
+    -- Common fields
+    created_at TIMESTAMP DEFAULT now(),
+    updated_at TIMESTAMP DEFAULT now(),
+    deleted_at TIMESTAMP DEFAULT NULL
+);
+
+DROP TABLE IF EXISTS user_audit;
+CREATE TABLE user_audit (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    -- Subject of the caller that performed the action
+    actor VARCHAR NOT NULL,
+    request_id VARCHAR NOT NULL,
+    action VARCHAR(32) NOT NULL,
+    -- Before and after values of the changed fields (JSON encrypted with the data key of the entry)
+    changes BYTEA NOT NULL,
+    key_id VARCHAR NOT NULL,
+    data_key BYTEA NOT NULL,
+    created_at TIMESTAMP DEFAULT now()
+);
+CREATE INDEX user_audit_user_id_idx ON user_audit (user_id, id);
+-- The entries are re-wrapped by users-keys when the primary key is rotated
+CREATE INDEX user_audit_key_id_idx ON user_audit (key_id);
@@ -0,0 +1,18 @@
+-- Replaces the stored age of the users by their birth date (see 1_tables.sql).
+-- The birth date of the existing users is unknown, it is approximated as the middle of the year in which they were
+-- born according to the age they had when they registered, so their computed age on that day is still their stored age.
+-- The migration does nothing if the table was created with the birth date.
+DO $$
+BEGIN
+    IF EXISTS (SELECT FROM information_schema.columns WHERE table_name = 'users' AND column_name = 'age') THEN
+        ALTER TABLE users ADD COLUMN birth_date DATE DEFAULT NULL;
+
+        -- The erased users (age 0) do not have a birth date
+        UPDATE users
+        SET birth_date = (COALESCE(created_at, now()) - make_interval(years => age, months => 6))::date
+        WHERE age > 0;
+
+        ALTER TABLE users DROP COLUMN age;
+    END IF;
+END
+$$;
@@ -0,0 +1,10 @@
+-- Adds the token buckets of the rate limits shared by the replicas of users-http (RATE_LIMIT_STORE=postgres).
+-- The buckets are disposable, so the migration does nothing if the table already exists.
+CREATE TABLE IF NOT EXISTS rate_limit_buckets (
+    key VARCHAR PRIMARY KEY,
+    tokens DOUBLE PRECISION NOT NULL,
+    capacity DOUBLE PRECISION NOT NULL,
+    rate DOUBLE PRECISION NOT NULL,
+    allowed BOOLEAN NOT NULL,
+    updated_at TIMESTAMP DEFAULT now()
+);
@@ -0,0 +1,18 @@
+-- Adds the API keys of the service-to-service callers (see 1_tables.sql).
+-- The migration does nothing if the table and its index already exist.
+CREATE TABLE IF NOT EXISTS api_keys (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    -- SHA-256 of the key, the key itself is never stored
+    key_hash BYTEA NOT NULL,
+    key_prefix VARCHAR(16) NOT NULL,
+    scopes VARCHAR[] NOT NULL,
+    last_used_at TIMESTAMP DEFAULT NULL,
+    revoked_at TIMESTAMP DEFAULT NULL,
+    -- Common fields
+    created_at TIMESTAMP DEFAULT now(),
+    updated_at TIMESTAMP DEFAULT now(),
+    deleted_at TIMESTAMP DEFAULT NULL
+);
+-- Same name as the index of the UNIQUE constraint of 1_tables.sql
+CREATE UNIQUE INDEX IF NOT EXISTS api_keys_key_hash_key ON api_keys (key_hash);
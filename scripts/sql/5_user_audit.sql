@@ -0,0 +1,15 @@
+-- Adds the history of the mutations of the users (see 1_tables.sql).
+-- The table is created as it was before the encryption, 6_encrypt_pii.sql encrypts it with the personal data of the users.
+-- The migration does nothing if the table and its index already exist.
+CREATE TABLE IF NOT EXISTS user_audit (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    -- Subject of the caller that performed the action
+    actor VARCHAR NOT NULL,
+    request_id VARCHAR NOT NULL,
+    action VARCHAR(32) NOT NULL,
+    -- Before and after values of the changed fields
+    changes JSONB NOT NULL,
+    created_at TIMESTAMP DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS user_audit_user_id_idx ON user_audit (user_id, id);
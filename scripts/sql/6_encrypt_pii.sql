@@ -0,0 +1,53 @@
+-- Encrypts the personal data of the users and of their history stored before the envelope encryption (see 1_tables.sql).
+-- The columns are converted to BYTEA keeping the plain text and the records are left without key, users-keys encrypts
+-- the records without key with the primary key of the keyring. The constraints of the encrypted records are added once
+-- every record is encrypted, so the script is run again after users-keys.
+-- The migration does nothing if the tables were created with the encryption.
+DO $$
+BEGIN
+    IF EXISTS (SELECT FROM information_schema.columns WHERE table_name = 'users' AND column_name = 'name' AND data_type <> 'bytea') THEN
+        -- The uniqueness of the emails is checked by their blind index
+        ALTER TABLE users DROP CONSTRAINT IF EXISTS users_email_key;
+
+        ALTER TABLE users
+            ALTER COLUMN name TYPE BYTEA USING convert_to(name, 'UTF8'),
+            ALTER COLUMN email TYPE BYTEA USING convert_to(email, 'UTF8'),
+            ADD COLUMN email_index BYTEA UNIQUE DEFAULT NULL,
+            ADD COLUMN key_id VARCHAR DEFAULT NULL,
+            ADD COLUMN data_key BYTEA DEFAULT NULL;
+
+        CREATE INDEX users_key_id_idx ON users (key_id);
+    END IF;
+
+    IF EXISTS (SELECT FROM information_schema.columns WHERE table_name = 'user_audit' AND column_name = 'changes' AND data_type = 'jsonb') THEN
+        ALTER TABLE user_audit
+            ALTER COLUMN changes TYPE BYTEA USING convert_to(changes::text, 'UTF8'),
+            ADD COLUMN key_id VARCHAR DEFAULT NULL,
+            ADD COLUMN data_key BYTEA DEFAULT NULL;
+
+        CREATE INDEX user_audit_key_id_idx ON user_audit (key_id);
+    END IF;
+END
+$$;
+
+-- Every record has a key once users-keys encrypted the plain text
+DO $$
+BEGIN
+    IF EXISTS (SELECT FROM information_schema.columns WHERE table_name = 'users' AND column_name = 'key_id' AND is_nullable = 'YES') THEN
+        IF NOT EXISTS (SELECT FROM users WHERE key_id IS NULL) THEN
+            ALTER TABLE users
+                ALTER COLUMN email_index SET NOT NULL,
+                ALTER COLUMN key_id SET NOT NULL,
+                ALTER COLUMN data_key SET NOT NULL;
+        END IF;
+    END IF;
+
+    IF EXISTS (SELECT FROM information_schema.columns WHERE table_name = 'user_audit' AND column_name = 'key_id' AND is_nullable = 'YES') THEN
+        IF NOT EXISTS (SELECT FROM user_audit WHERE key_id IS NULL) THEN
+            ALTER TABLE user_audit
+                ALTER COLUMN key_id SET NOT NULL,
+                ALTER COLUMN data_key SET NOT NULL;
+        END IF;
+    END IF;
+END
+$$;
@@ -0,0 +1,3 @@
+-- Adds the erasure date of the users whose personal data was removed (see 1_tables.sql).
+-- The migration does nothing if the column already exists.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS erased_at TIMESTAMP DEFAULT NULL;
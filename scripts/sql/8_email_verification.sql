@@ -0,0 +1,7 @@
+-- Adds the verification of the emails of the users (see 1_tables.sql).
+-- The existing users are left unverified without a pending token.
+-- The migration does nothing if the columns already exist.
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMP DEFAULT NULL,
+    ADD COLUMN IF NOT EXISTS email_verification_hash BYTEA DEFAULT NULL,
+    ADD COLUMN IF NOT EXISTS email_verification_expires_at TIMESTAMP DEFAULT NULL;